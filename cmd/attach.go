@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/multikubectl/pkg/executor"
+	"golang.org/x/term"
+)
+
+// attachValueFlags are the kubectl attach flags that take a separate value
+var attachValueFlags = map[string]bool{
+	"-c": true, "--container": true,
+	"-n": true, "--namespace": true,
+	"--pod-running-timeout": true,
+}
+
+// attachTarget is a pod that can be attached to
+type attachTarget struct {
+	context string
+	pod     string
+}
+
+// runAttach attaches to a single pod, since attach sessions can't be merged.
+// The pod argument is matched in every target cluster by exact name or prefix;
+// if more than one cluster or pod matches, a picker asks which one to use.
+// kubectl then runs interactively against that cluster.
+func runAttach(sess *session, exec *executor.Executor, args []string) {
+	podIndex, namespace := attachOperand(args)
+
+	var targets []attachTarget
+	if podIndex >= 0 && strings.Contains(args[podIndex], "/") {
+		// A resource such as deploy/web: kubectl picks the pod, so only the cluster is chosen
+		for _, ctx := range sess.Contexts() {
+			targets = append(targets, attachTarget{context: ctx, pod: args[podIndex]})
+		}
+	} else {
+		name := ""
+		if podIndex >= 0 {
+			name = args[podIndex]
+		}
+		targets = findAttachTargets(sess, exec, name, namespace)
+	}
+
+	if len(targets) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no matching pods found in the target clusters")
+		os.Exit(1)
+	}
+	target := targets[0]
+	if len(targets) > 1 {
+		target = pickAttachTarget(targets)
+	}
+
+	if podIndex >= 0 {
+		args = slices.Clone(args)
+		args[podIndex] = target.pod
+	} else {
+		args = slices.Insert(slices.Clone(args), 1, target.pod)
+	}
+
+	fmt.Fprintf(os.Stderr, "Attaching to %s in %s\n", target.pod, target.context)
+	result, err := sess.ExecuteInteractive(context.Background(), exec, target.context, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if result.Error != nil {
+		os.Exit(max(result.ExitCode, 1))
+	}
+}
+
+// attachOperand returns the index of the pod argument of "attach" (-1 if
+// there is none) and the namespace given with -n
+func attachOperand(args []string) (int, string) {
+	podIndex := -1
+	namespace := ""
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-") {
+			name, value, hasValue := strings.Cut(arg, "=")
+			if attachValueFlags[name] && !hasValue && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			if name == "-n" || name == "--namespace" {
+				namespace = value
+			}
+			continue
+		}
+		if podIndex < 0 {
+			podIndex = i
+		}
+	}
+	return podIndex, namespace
+}
+
+// findAttachTargets lists the pods in every target cluster and returns those
+// named name, or else starting with it (all pods if name is empty)
+func findAttachTargets(sess *session, exec *executor.Executor, name, namespace string) []attachTarget {
+	listArgs := []string{"get", "pods", "-o", "name"}
+	if namespace != "" {
+		listArgs = append(listArgs, "-n", namespace)
+	}
+	results := sess.run(exec, listArgs)
+
+	var exact, prefixed []attachTarget
+	for _, r := range results {
+		if r.Error != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to list pods in %s: %s\n", r.Context, firstLine(r.Error.Error()))
+			continue
+		}
+		for _, line := range strings.Split(r.Output, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			pod := strings.TrimPrefix(fields[0], "pod/")
+			switch {
+			case pod == name:
+				exact = append(exact, attachTarget{context: r.Context, pod: pod})
+			case strings.HasPrefix(pod, name):
+				prefixed = append(prefixed, attachTarget{context: r.Context, pod: pod})
+			}
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return prefixed
+}
+
+// pickAttachTarget asks which cluster and pod to attach to. Exits if stdin is
+// not a terminal or the prompt is cancelled.
+func pickAttachTarget(targets []attachTarget) attachTarget {
+	options := make([]string, len(targets))
+	width := 0
+	for _, t := range targets {
+		width = max(width, len(t.context))
+	}
+	for i, t := range targets {
+		options[i] = fmt.Sprintf("%-*s  %s", width, t.context, t.pod)
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintf(os.Stderr, "Error: attach needs a single pod, but %d match:\n", len(targets))
+		for _, option := range options {
+			fmt.Fprintf(os.Stderr, "  %s\n", option)
+		}
+		fmt.Fprintln(os.Stderr, "Narrow it down with --contexts and the full pod name.")
+		os.Exit(1)
+	}
+
+	var choice int
+	prompt := &survey.Select{
+		Message:  "Attach to:",
+		Options:  options,
+		PageSize: 15,
+	}
+	if err := survey.AskOne(prompt, &choice, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)); err != nil {
+		if err.Error() == "interrupt" {
+			fmt.Fprintln(os.Stderr, "Cancelled.")
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return targets[choice]
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/multikubectl/pkg/output"
+)
+
+// statusBoard shows a status line per cluster on stderr while a command
+// runs: redrawn in place on a terminal, or printed whenever a cluster's
+// status changes otherwise
+type statusBoard struct {
+	contexts []string
+	width    int
+	live     bool
+	drawn    bool
+	last     map[string]string
+}
+
+func newStatusBoard(contexts []string) *statusBoard {
+	width := 0
+	for _, ctx := range contexts {
+		width = max(width, len(ctx))
+	}
+	return &statusBoard{
+		contexts: contexts,
+		width:    width,
+		live:     !noProgress && !quiet && output.StderrIsTerminal(),
+		last:     make(map[string]string),
+	}
+}
+
+// update shows the latest status line of each cluster
+func (b *statusBoard) update(lines map[string]string) {
+	if b.live && b.drawn {
+		// Move back up to the first cluster's line
+		fmt.Fprintf(os.Stderr, "\033[%dA", len(b.contexts))
+	}
+	for _, ctx := range b.contexts {
+		line := lines[ctx]
+		if b.live {
+			fmt.Fprintf(os.Stderr, "\r\033[K%-*s  %s\n", b.width, ctx, line)
+		} else if b.last[ctx] != line {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", ctx, line)
+		}
+		b.last[ctx] = line
+	}
+	b.drawn = true
+}
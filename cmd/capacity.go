@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/multikubectl/pkg/output"
+	"github.com/multikubectl/pkg/quantity"
+	"github.com/spf13/cobra"
+)
+
+var capacitySelector string
+
+var capacityCmd = &cobra.Command{
+	Use:   "capacity",
+	Short: "Summarize node CPU and memory headroom per cluster",
+	Long: `Sum node CPU and memory capacity, allocatable, and requested resources for
+each target cluster, plus a fleet-wide TOTAL row.
+
+Requested resources are the container requests of all running and pending
+pods scheduled on the selected nodes. FREE is allocatable minus requested.
+
+Examples:
+  multikubectl capacity
+  multikubectl capacity -l node-role.kubernetes.io/worker`,
+	Args: cobra.NoArgs,
+	Run:  runCapacity,
+}
+
+func init() {
+	capacityCmd.Flags().StringVarP(&capacitySelector, "selector", "l", "", "Only include nodes matching this label selector")
+}
+
+// resources holds CPU (cores) and memory (bytes) amounts
+type resources struct {
+	cpu, memory float64
+}
+
+func (r *resources) add(o resources) {
+	r.cpu += o.cpu
+	r.memory += o.memory
+}
+
+// clusterCapacity is the aggregated node capacity of one cluster
+type clusterCapacity struct {
+	nodes                          int
+	capacity, allocatable, request resources
+}
+
+func runCapacity(cmd *cobra.Command, args []string) {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sess := newSession()
+	exec := sess.NewExecutor()
+	merger := sess.newMerger()
+
+	nodeArgs := []string{"get", "nodes", "-o", "json"}
+	if capacitySelector != "" {
+		nodeArgs = append(nodeArgs, "-l", capacitySelector)
+	}
+	nodeObjects, results := sess.fetchObjects(exec, nodeArgs)
+	podObjects, podResults := sess.fetchObjects(exec, []string{"get", "pods", "-A", "-o", "json",
+		"--field-selector=status.phase!=Succeeded,status.phase!=Failed"})
+	results = append(results, podResults...)
+
+	table := &output.Table{Headers: []string{"CLUSTER", "NODES",
+		"CPU CAPACITY", "CPU ALLOCATABLE", "CPU REQUESTED", "CPU FREE",
+		"MEMORY CAPACITY", "MEMORY ALLOCATABLE", "MEMORY REQUESTED", "MEMORY FREE"}}
+	var total clusterCapacity
+	for _, ctx := range sess.Contexts() {
+		nodes, ok := nodeObjects[ctx]
+		if !ok {
+			continue
+		}
+		c := clusterCapacity{}
+		selected := make(map[string]bool)
+		for _, node := range listItems(nodes) {
+			status, _ := node["status"].(map[string]interface{})
+			c.nodes++
+			c.capacity.add(parseResources(status["capacity"]))
+			c.allocatable.add(parseResources(status["allocatable"]))
+			selected[objectName(node)] = true
+		}
+		for _, pod := range listItems(podObjects[ctx]) {
+			spec, _ := pod["spec"].(map[string]interface{})
+			if nodeName, _ := spec["nodeName"].(string); selected[nodeName] {
+				c.request.add(podRequests(spec))
+			}
+		}
+
+		table.Rows = append(table.Rows, capacityRow(ctx, c))
+		total.nodes += c.nodes
+		total.capacity.add(c.capacity)
+		total.allocatable.add(c.allocatable)
+		total.request.add(c.request)
+	}
+	if len(table.Rows) > 1 {
+		table.Rows = append(table.Rows, capacityRow("TOTAL", total))
+	}
+
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+
+	fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+	for _, r := range results {
+		if r.Error != nil {
+			os.Exit(1)
+		}
+	}
+}
+
+func capacityRow(name string, c clusterCapacity) []string {
+	return []string{name, fmt.Sprint(c.nodes),
+		quantity.FormatCPU(c.capacity.cpu), quantity.FormatCPU(c.allocatable.cpu),
+		withPercent(quantity.FormatCPU(c.request.cpu), c.request.cpu, c.allocatable.cpu),
+		quantity.FormatCPU(c.allocatable.cpu - c.request.cpu),
+		quantity.FormatBytes(c.capacity.memory), quantity.FormatBytes(c.allocatable.memory),
+		withPercent(quantity.FormatBytes(c.request.memory), c.request.memory, c.allocatable.memory),
+		quantity.FormatBytes(c.allocatable.memory - c.request.memory),
+	}
+}
+
+// withPercent appends the share of part in whole, e.g. "3.5 (44%)"
+func withPercent(s string, part, whole float64) string {
+	if whole <= 0 {
+		return s
+	}
+	return fmt.Sprintf("%s (%.0f%%)", s, part/whole*100)
+}
+
+// parseResources reads cpu and memory from a resource list such as status.allocatable
+func parseResources(v interface{}) resources {
+	m, _ := v.(map[string]interface{})
+	var r resources
+	if s, ok := m["cpu"].(string); ok {
+		r.cpu, _ = quantity.Parse(s)
+	}
+	if s, ok := m["memory"].(string); ok {
+		r.memory, _ = quantity.Parse(s)
+	}
+	return r
+}
+
+// podRequests returns the effective requests of a pod the way the scheduler
+// counts them: the larger of the summed container requests and the largest
+// single init container request, plus pod overhead
+func podRequests(spec map[string]interface{}) resources {
+	var sum, initMax resources
+	for _, c := range containerList(spec["containers"]) {
+		sum.add(containerRequests(c))
+	}
+	for _, c := range containerList(spec["initContainers"]) {
+		r := containerRequests(c)
+		initMax.cpu = max(initMax.cpu, r.cpu)
+		initMax.memory = max(initMax.memory, r.memory)
+	}
+	r := resources{cpu: max(sum.cpu, initMax.cpu), memory: max(sum.memory, initMax.memory)}
+	r.add(parseResources(spec["overhead"]))
+	return r
+}
+
+func containerRequests(c map[string]interface{}) resources {
+	res, _ := c["resources"].(map[string]interface{})
+	return parseResources(res["requests"])
+}
+
+func containerList(v interface{}) []map[string]interface{} {
+	raw, _ := v.([]interface{})
+	containers := make([]map[string]interface{}, 0, len(raw))
+	for _, c := range raw {
+		if m, ok := c.(map[string]interface{}); ok {
+			containers = append(containers, m)
+		}
+	}
+	return containers
+}
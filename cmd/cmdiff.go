@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/multikubectl/pkg/diff"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmDiffNamespace string
+	cmDiffBaseline  string
+)
+
+var cmDiffCmd = &cobra.Command{
+	Use:   "cmdiff <name>",
+	Short: "Diff a ConfigMap's data keys across clusters",
+	Long: `Compare the data keys of a ConfigMap with the same name across clusters and
+print a unified diff per key for every cluster that differs from the baseline
+(the first cluster, or the context named by --baseline).
+
+Exits with a non-zero status if any cluster differs or fails.
+
+Examples:
+  multikubectl cmdiff app-config -n web
+  multikubectl cmdiff coredns -n kube-system --baseline prod-us`,
+	Args: cobra.ExactArgs(1),
+	Run:  runCMDiff,
+}
+
+func init() {
+	cmDiffCmd.Flags().StringVarP(&cmDiffNamespace, "namespace", "n", "", "Namespace of the configmap")
+	cmDiffCmd.Flags().StringVar(&cmDiffBaseline, "baseline", "", "Context to use as the baseline (default: first cluster)")
+}
+
+func runCMDiff(cmd *cobra.Command, args []string) {
+	name := args[0]
+	getArgs := []string{"get", "configmap", name, "-o", "json"}
+	if cmDiffNamespace != "" {
+		getArgs = append(getArgs, "-n", cmDiffNamespace)
+	}
+
+	sess := newSession()
+	exec := sess.NewExecutor()
+	merger := sess.newMerger()
+	objects, results := sess.fetchObjects(exec, getArgs)
+
+	// data[cluster][key] is the value of each key
+	data := make(map[string]map[string]string)
+	var clusters []string
+	for _, r := range results {
+		obj, ok := objects[r.Context]
+		if !ok {
+			continue
+		}
+		clusters = append(clusters, r.Context)
+		values := make(map[string]string)
+		if d, ok := obj["data"].(map[string]interface{}); ok {
+			for key, value := range d {
+				values[key], _ = value.(string)
+			}
+		}
+		data[r.Context] = values
+	}
+
+	baseline := cmDiffBaseline
+	if baseline == "" && len(clusters) > 0 {
+		baseline = clusters[0]
+	}
+	if _, ok := data[baseline]; !ok {
+		fmt.Fprintf(os.Stderr, "Error: configmap %s was not found in baseline cluster %q\n", name, baseline)
+		fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+		os.Exit(1)
+	}
+
+	failed := len(clusters) < len(results)
+	fmt.Printf("configmap/%s (baseline: %s)\n", name, baseline)
+	for _, cluster := range clusters {
+		if cluster == baseline {
+			continue
+		}
+
+		var patches []string
+		for _, key := range unionDataKeys(data[baseline], data[cluster]) {
+			before, inBaseline := data[baseline][key]
+			after, inCluster := data[cluster][key]
+			aName, bName := baseline+"/"+key, cluster+"/"+key
+			if !inBaseline {
+				aName = "/dev/null"
+			}
+			if !inCluster {
+				bName = "/dev/null"
+			}
+			if inBaseline != inCluster || before != after {
+				if before == after {
+					// Present on one side only with empty content
+					patches = append(patches, fmt.Sprintf("--- %s\n+++ %s\n", aName, bName))
+					continue
+				}
+				patches = append(patches, diff.Unified(aName, bName, before, after, diff.DefaultContextLines))
+			}
+		}
+
+		if len(patches) == 0 {
+			fmt.Printf("\n=== Cluster: %s === (identical)\n", cluster)
+			continue
+		}
+		failed = true
+		fmt.Printf("\n=== Cluster: %s === (%d key(s) differ)\n", cluster, len(patches))
+		for _, patch := range patches {
+			fmt.Print(patch)
+		}
+	}
+
+	fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// unionDataKeys returns the sorted keys present in either map
+func unionDataKeys(a, b map[string]string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []map[string]string{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
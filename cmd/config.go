@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/multikubectl/pkg/cluster"
 	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/output"
+	"github.com/multikubectl/pkg/reachability"
+	"github.com/multikubectl/pkg/runner"
 	"github.com/spf13/cobra"
 )
 
@@ -20,10 +25,18 @@ Configuration is stored in ~/.multikube/config. When this file exists,
 multikubectl will only operate on the configured contexts by default.`,
 }
 
+var configListHealth bool
+
 var configListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all available contexts and show which are configured",
-	Run:   runConfigList,
+	Long: `List all available contexts and show which are configured.
+
+With --health, each context also shows whether its API server could be
+reached, and how fast it answered. Clusters that a recent command or probe
+already reached (or failed to reach) are not probed again; the others are
+probed with a quick "kubectl get --raw /version".`,
+	Run: runConfigList,
 }
 
 var configAddCmd = &cobra.Command{
@@ -69,6 +82,28 @@ Previously configured contexts will be pre-selected.`,
 	Run: runConfigSelect,
 }
 
+var configEffectiveCmd = &cobra.Command{
+	Use:   "effective [verb [subcommand]]",
+	Short: "Show what kubectl runs with for each target context",
+	Long: `Show, for each target context, what kubectl will run with once flags,
+environment variables and config are resolved: the kubeconfig file, API server
+(and SSH tunnel), how the user authenticates, the default namespace, the
+identity impersonated with --as or the config, the other flags added to
+kubectl's command line (e.g. for an SSH tunnel), the environment added to
+kubectl (e.g. a proxy) and the timeout. Given a verb, the timeout is the
+one the config sets for it, unless --timeout is given.
+
+The target contexts are resolved exactly as for any other command, so the
+same flags can be given to check what a command would use.
+
+Examples:
+  multikubectl config effective
+  multikubectl config effective rollout status
+  multikubectl config effective --contexts prod-eu,prod-us --as admin --timeout 1m`,
+	Args: cobra.MaximumNArgs(2),
+	Run:  runConfigEffective,
+}
+
 func init() {
 	configCmd.AddCommand(configListCmd)
 	configCmd.AddCommand(configAddCmd)
@@ -77,16 +112,12 @@ func init() {
 	configCmd.AddCommand(configClearCmd)
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configSelectCmd)
+	configCmd.AddCommand(configEffectiveCmd)
+
+	configListCmd.Flags().BoolVar(&configListHealth, "health", false, "Show whether each context's API server is reachable")
 }
 
 func runConfigList(cmd *cobra.Command, args []string) {
-	// Load kubeconfig to get all available contexts
-	mgr, err := cluster.NewManager("")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading kubeconfig: %v\n", err)
-		os.Exit(1)
-	}
-
 	// Load multikube config
 	cfg, err := config.Load()
 	if err != nil {
@@ -94,6 +125,25 @@ func runConfigList(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	// Load kubeconfig to get all available contexts
+	var mgr *cluster.Manager
+	var health map[string]reachability.Entry
+	if configListHealth {
+		r, err := runner.Resolve(runner.Options{AllContexts: true, Config: cfg, Timeout: reachability.ProbeTimeout, Stderr: os.Stderr})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading kubeconfig: %v\n", err)
+			os.Exit(1)
+		}
+		mgr = r.Manager()
+		health = r.Reachability(context.Background(), r.NewExecutor(), r.Contexts())
+	} else {
+		if mgr, err = cluster.NewManager(""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading kubeconfig: %v\n", err)
+			os.Exit(1)
+		}
+		addExtraContexts(mgr, cfg)
+	}
+
 	allContexts := mgr.GetContexts()
 	currentContext := mgr.GetCurrentContext()
 	hasConfig := config.Exists() && len(cfg.Contexts) > 0
@@ -101,7 +151,9 @@ func runConfigList(cmd *cobra.Command, args []string) {
 	fmt.Println("Available contexts from kubeconfig:")
 	fmt.Println()
 
-	for _, ctx := range allContexts {
+	lines := make([]string, len(allContexts))
+	width := 0
+	for i, ctx := range allContexts {
 		marker := "  "
 		if hasConfig && cfg.HasContext(ctx) {
 			marker = "* "
@@ -110,7 +162,15 @@ func runConfigList(cmd *cobra.Command, args []string) {
 		if ctx == currentContext {
 			current = " (current)"
 		}
-		fmt.Printf("%s%s%s\n", marker, ctx, current)
+		lines[i] = marker + ctx + current
+		width = max(width, len(lines[i]))
+	}
+	for i, ctx := range allContexts {
+		if !configListHealth {
+			fmt.Println(lines[i])
+			continue
+		}
+		fmt.Printf("%-*s  %s\n", width, lines[i], healthIndicator(health, ctx))
 	}
 
 	fmt.Println()
@@ -123,6 +183,21 @@ func runConfigList(cmd *cobra.Command, args []string) {
 	}
 }
 
+// healthIndicator describes the last known reachability of a context
+func healthIndicator(health map[string]reachability.Entry, ctx string) string {
+	entry, ok := health[ctx]
+	switch {
+	case !ok:
+		return "unknown"
+	case !entry.Reachable:
+		return "unreachable"
+	case entry.Latency > 0:
+		return fmt.Sprintf("reachable (%s)", entry.Latency.Round(time.Millisecond))
+	default:
+		return "reachable"
+	}
+}
+
 func runConfigAdd(cmd *cobra.Command, args []string) {
 	// Load kubeconfig to validate contexts
 	mgr, err := cluster.NewManager("")
@@ -131,11 +206,6 @@ func runConfigAdd(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	availableContexts := make(map[string]bool)
-	for _, ctx := range mgr.GetContexts() {
-		availableContexts[ctx] = true
-	}
-
 	// Load existing config
 	cfg, err := config.Load()
 	if err != nil {
@@ -143,10 +213,20 @@ func runConfigAdd(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	// Include contexts from extra kubeconfigs and discovery providers, and group names
+	availableContexts := make(map[string]bool)
+	addExtraContexts(mgr, cfg)
+	for name := range cfg.Groups {
+		availableContexts[name] = true
+	}
+	for _, ctx := range mgr.GetContexts() {
+		availableContexts[ctx] = true
+	}
+
 	added := 0
 	for _, ctx := range args {
 		if !availableContexts[ctx] {
-			fmt.Fprintf(os.Stderr, "Warning: context '%s' not found in kubeconfig, skipping\n", ctx)
+			fmt.Fprintf(os.Stderr, "Warning: '%s' is not a kubeconfig context or group, skipping\n", ctx)
 			continue
 		}
 		if cfg.AddContext(ctx) {
@@ -200,7 +280,19 @@ func runConfigUse(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	// Load existing config
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Include contexts from extra kubeconfigs and discovery providers, and group names
 	availableContexts := make(map[string]bool)
+	addExtraContexts(mgr, cfg)
+	for name := range cfg.Groups {
+		availableContexts[name] = true
+	}
 	for _, ctx := range mgr.GetContexts() {
 		availableContexts[ctx] = true
 	}
@@ -216,7 +308,7 @@ func runConfigUse(cmd *cobra.Command, args []string) {
 			continue
 		}
 		if !availableContexts[ctx] {
-			fmt.Fprintf(os.Stderr, "Warning: context '%s' not found in kubeconfig, skipping\n", ctx)
+			fmt.Fprintf(os.Stderr, "Warning: '%s' is not a kubeconfig context or group, skipping\n", ctx)
 			continue
 		}
 		validContexts = append(validContexts, ctx)
@@ -227,7 +319,7 @@ func runConfigUse(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	cfg := &config.MultiKubeConfig{}
+	// Keep the other settings in the config file
 	cfg.SetContexts(validContexts)
 
 	if err := config.Save(cfg); err != nil {
@@ -297,12 +389,6 @@ func runConfigSelect(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	allContexts := mgr.GetContexts()
-	if len(allContexts) == 0 {
-		fmt.Fprintln(os.Stderr, "No contexts found in kubeconfig")
-		os.Exit(1)
-	}
-
 	// Load existing config to pre-select configured contexts
 	cfg, err := config.Load()
 	if err != nil {
@@ -310,6 +396,15 @@ func runConfigSelect(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	// Include contexts from extra kubeconfigs and discovery providers
+	addExtraContexts(mgr, cfg)
+
+	allContexts := mgr.GetContexts()
+	if len(allContexts) == 0 {
+		fmt.Fprintln(os.Stderr, "No contexts found in kubeconfig")
+		os.Exit(1)
+	}
+
 	// Determine which contexts should be pre-selected
 	var defaultSelected []string
 	if config.Exists() && len(cfg.Contexts) > 0 {
@@ -368,10 +463,9 @@ func runConfigSelect(cmd *cobra.Command, args []string) {
 	}
 
 	// Save selected contexts
-	newConfig := &config.MultiKubeConfig{}
-	newConfig.SetContexts(selectedContexts)
+	cfg.SetContexts(selectedContexts)
 
-	if err := config.Save(newConfig); err != nil {
+	if err := config.Save(cfg); err != nil {
 		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
 		os.Exit(1)
 	}
@@ -382,3 +476,46 @@ func runConfigSelect(cmd *cobra.Command, args []string) {
 	}
 	fmt.Printf("\nConfiguration saved to %s\n", config.GetConfigPath())
 }
+
+func runConfigEffective(cmd *cobra.Command, args []string) {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	sess := resolveSession()
+	// The config's timeout for the verb applies unless --timeout is given,
+	// as when running it
+	verbTimeout, configured := sess.TimeoutFor(args)
+	configured = configured && !cmd.Flags().Changed("timeout")
+
+	table := &output.Table{Headers: []string{"CLUSTER", "KUBECONFIG", "SERVER", "AUTH", "NAMESPACE", "AS", "EXTRA ARGS", "ENV", "TIMEOUT"}}
+	for _, ctx := range sess.Contexts() {
+		e := sess.Effective(ctx)
+		if configured {
+			e.Timeout = verbTimeout
+		}
+		timeout := e.Timeout.String()
+		if e.Timeout == 0 {
+			timeout = "none"
+		}
+		server := e.Server
+		if e.Tunnel != "" {
+			server += " (via " + e.Tunnel + ")"
+		}
+		row := []string{ctx, e.KubeConfig, server, e.Auth, e.Namespace, e.As, strings.Join(e.ExtraArgs, " "), strings.Join(e.Env, " "), timeout}
+		for i, cell := range row {
+			if cell == "" {
+				row[i] = "-"
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	rendered, err := sess.newMerger().RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+}
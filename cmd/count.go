@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/multikubectl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	countNamespace     string
+	countAllNamespaces bool
+	countSelector      string
+)
+
+var countCmd = &cobra.Command{
+	Use:   "count <resource>",
+	Short: "Count resources per cluster (and per namespace with -A)",
+	Long: `Count resources in every target cluster and print a table with totals.
+
+Only object names (or namespaces with -A) are fetched, so counting is cheap
+even for large resource lists.
+
+Examples:
+  multikubectl count pods -n kube-system
+  multikubectl count deployments -A
+  multikubectl count pods -A -l app=nginx`,
+	Args: cobra.ExactArgs(1),
+	Run:  runCount,
+}
+
+func init() {
+	countCmd.Flags().StringVarP(&countNamespace, "namespace", "n", "", "Namespace to count in")
+	countCmd.Flags().BoolVarP(&countAllNamespaces, "all-namespaces", "A", false, "Count across all namespaces, broken down per namespace")
+	countCmd.Flags().StringVarP(&countSelector, "selector", "l", "", "Label selector to filter on")
+}
+
+func runCount(cmd *cobra.Command, args []string) {
+	resource := args[0]
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	getArgs := []string{"get", resource}
+	if countAllNamespaces {
+		// One line per object holding only its namespace
+		getArgs = append(getArgs, "-A", "-o", "custom-columns=NAMESPACE:.metadata.namespace", "--no-headers")
+	} else {
+		getArgs = append(getArgs, "-o", "name")
+		if countNamespace != "" {
+			getArgs = append(getArgs, "-n", countNamespace)
+		}
+	}
+	if countSelector != "" {
+		getArgs = append(getArgs, "-l", countSelector)
+	}
+
+	sess := newSession()
+	exec := sess.NewExecutor()
+	merger := sess.newMerger()
+	results := sess.run(exec, getArgs)
+
+	table := &output.Table{Headers: []string{"CLUSTER", "COUNT"}}
+	if countAllNamespaces {
+		table.Headers = []string{"CLUSTER", "NAMESPACE", "COUNT"}
+	}
+
+	total := 0
+	failed := false
+	for _, r := range results {
+		if r.Error != nil {
+			failed = true
+			continue
+		}
+
+		perNamespace := make(map[string]int)
+		count := 0
+		for _, line := range strings.Split(r.Output, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			count++
+			perNamespace[line]++
+		}
+		total += count
+
+		if !countAllNamespaces {
+			table.Rows = append(table.Rows, []string{r.Context, strconv.Itoa(count)})
+			continue
+		}
+		namespaces := make([]string, 0, len(perNamespace))
+		for ns := range perNamespace {
+			namespaces = append(namespaces, ns)
+		}
+		sort.Strings(namespaces)
+		for _, ns := range namespaces {
+			table.Rows = append(table.Rows, []string{r.Context, ns, strconv.Itoa(perNamespace[ns])})
+		}
+		table.Rows = append(table.Rows, []string{r.Context, "(all)", strconv.Itoa(count)})
+	}
+
+	if countAllNamespaces {
+		table.Rows = append(table.Rows, []string{"TOTAL", "", strconv.Itoa(total)})
+	} else {
+		table.Rows = append(table.Rows, []string{"TOTAL", strconv.Itoa(total)})
+	}
+
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+	fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+)
+
+// cpValueFlags are the kubectl cp flags that take a separate value
+var cpValueFlags = map[string]bool{
+	"-c": true, "--container": true,
+	"-n": true, "--namespace": true,
+	"--retries": true,
+}
+
+// copySpec is a parsed kubectl cp command
+type copySpec struct {
+	// remote is the index in args of the pod operand, local of the local one
+	remote, local int
+	namespace     string
+	pod           string
+	path          string
+}
+
+// parseCopyArgs finds the local and pod operands of "cp <src> <dest>".
+// Exactly one of them must refer to a pod ([namespace/]pod:path).
+func parseCopyArgs(args []string) (*copySpec, error) {
+	var operands []int
+	namespace := ""
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			name, value, hasValue := strings.Cut(arg, "=")
+			if cpValueFlags[name] && !hasValue && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			if name == "-n" || name == "--namespace" {
+				namespace = value
+			}
+			continue
+		}
+		operands = append(operands, i)
+	}
+	if len(operands) != 2 {
+		return nil, fmt.Errorf("cp needs a source and a destination")
+	}
+
+	spec := &copySpec{remote: -1}
+	for _, i := range operands {
+		ref, p, ok := strings.Cut(args[i], ":")
+		if !ok || ref == "" {
+			spec.local = i
+			continue
+		}
+		if spec.remote >= 0 {
+			return nil, fmt.Errorf("copying between pods is not supported")
+		}
+		spec.remote = i
+		spec.path = p
+		spec.pod = ref
+		if ns, pod, ok := strings.Cut(ref, "/"); ok {
+			namespace, spec.pod = ns, pod
+		}
+	}
+	if spec.remote < 0 {
+		return nil, fmt.Errorf("one of the cp operands must be a pod path ([namespace/]pod:path)")
+	}
+	spec.namespace = namespace
+	return spec, nil
+}
+
+// download reports whether the pod operand is the source
+func (c *copySpec) download() bool {
+	return c.remote < c.local
+}
+
+// runCopy runs kubectl cp against every target cluster. The pod is matched in
+// each cluster by exact name or, failing that, by name prefix, since pods of the
+// same workload have different names in every cluster. Downloads go to a
+// subdirectory per cluster; uploads send the same local file to each pod.
+func runCopy(sess *session, exec *executor.Executor, args []string) {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	spec, err := parseCopyArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	merger := sess.newMerger()
+
+	// Resolve the pod in each cluster
+	listArgs := []string{"get", "pods", "-o", "name"}
+	if spec.namespace != "" {
+		listArgs = append(listArgs, "-n", spec.namespace)
+	}
+	pods := make(map[string]string)
+	// Clusters where the pod is ambiguous are left out
+	matchErrs := make(map[string]error)
+	var targets []string
+	for _, r := range sess.run(exec, listArgs) {
+		if r.Error == nil {
+			pod, err := matchPod(r.Output, spec.pod)
+			if err != nil {
+				matchErrs[r.Context] = err
+				continue
+			}
+			pods[r.Context] = pod
+		}
+		targets = append(targets, r.Context)
+	}
+
+	// Each cluster's destination for downloads: <dest>/<cluster>/<name of the remote path>
+	localPaths := make(map[string]string)
+	for _, ctx := range sess.Contexts() {
+		localPaths[ctx] = args[spec.local]
+		if spec.download() {
+			dir := filepath.Join(args[spec.local], ctx)
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to create %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+			localPaths[ctx] = filepath.Join(dir, path.Base(spec.path))
+		}
+	}
+
+	exec.SetArgsFunc(func(ctx string, args []string) []string {
+		rewritten := append([]string(nil), args...)
+		pod := pods[ctx]
+		if pod == "" {
+			// Let kubectl report the missing pod
+			pod = spec.pod
+		}
+		remote := pod + ":" + spec.path
+		if spec.namespace != "" {
+			remote = spec.namespace + "/" + remote
+		}
+		rewritten[spec.remote] = remote
+		rewritten[spec.local] = localPaths[ctx]
+		return rewritten
+	})
+	results := make(map[string]executor.Result)
+	if len(targets) > 0 {
+		for _, r := range sess.runOn(exec, targets, args) {
+			results[r.Context] = r
+		}
+	}
+	exec.SetArgsFunc(nil)
+
+	table := &output.Table{Headers: []string{"CLUSTER", "POD", "LOCAL PATH", "STATUS"}}
+	failed := false
+	for _, ctx := range sess.Contexts() {
+		pod := pods[ctx]
+		if pod == "" {
+			pod = "-"
+		}
+		status := "ok"
+		if err, ok := matchErrs[ctx]; ok {
+			failed = true
+			status = err.Error()
+		} else if r, ok := results[ctx]; ok && r.Error != nil {
+			failed = true
+			status = firstLine(r.Error.Error())
+		}
+		table.Rows = append(table.Rows, []string{ctx, pod, localPaths[ctx], status})
+	}
+
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// matchPod returns the pod in "kubectl get pods -o name" output named name,
+// or else the only one whose name starts with name followed by "-", as the
+// pods of a workload do. Returns "" if none match, and an error if several do.
+func matchPod(out, name string) (string, error) {
+	var prefixed []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		pod := strings.TrimPrefix(fields[0], "pod/")
+		if pod == name {
+			return pod, nil
+		}
+		if strings.HasPrefix(pod, name+"-") {
+			prefixed = append(prefixed, pod)
+		}
+	}
+	if len(prefixed) > 1 {
+		return "", fmt.Errorf("%d pods match %s: %s", len(prefixed), name, strings.Join(prefixed, ", "))
+	}
+	if len(prefixed) == 1 {
+		return prefixed[0], nil
+	}
+	return "", nil
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
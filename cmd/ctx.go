@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/multikubectl/pkg/cluster"
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/output"
+	"github.com/spf13/cobra"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var ctxCmd = &cobra.Command{
+	Use:   "ctx",
+	Short: "Manage the contexts in the kubeconfig",
+	Long: `List, rename and delete contexts directly in the kubeconfig file that defines
+them, like kubectx does.
+
+Before a kubeconfig file is modified it is copied next to itself, e.g.
+~/.kube/config.bak-20240102-150405. References to a renamed or deleted
+context in the multikubectl config (configured contexts, groups, verb
+restrictions and context settings) are updated as well.`,
+}
+
+var ctxListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the contexts of the kubeconfig and where they are defined",
+	Args:  cobra.NoArgs,
+	Run:   runCtxList,
+}
+
+var ctxRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a context in the kubeconfig",
+	Args:  cobra.ExactArgs(2),
+	Run:   runCtxRename,
+}
+
+var ctxDeleteCmd = &cobra.Command{
+	Use:   "delete <context> [context...]",
+	Short: "Delete context(s) from the kubeconfig",
+	Long: `Delete contexts from the kubeconfig. Only the contexts are removed; the
+clusters and users they refer to are kept, as other contexts may use them.
+Deleting the current context unsets it.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runCtxDelete,
+}
+
+func init() {
+	ctxCmd.AddCommand(ctxListCmd)
+	ctxCmd.AddCommand(ctxRenameCmd)
+	ctxCmd.AddCommand(ctxDeleteCmd)
+}
+
+// loadContexts loads the kubeconfig with the contexts of extra kubeconfigs
+// and discovery providers, and the multikube config. Exits on error.
+func loadContexts() (*cluster.Manager, *config.MultiKubeConfig) {
+	mgr, err := cluster.NewManager(kubeConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading kubeconfig: %v\n", err)
+		os.Exit(1)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading multikube config: %v\n", err)
+		os.Exit(1)
+	}
+	addExtraContexts(mgr, cfg)
+	return mgr, cfg
+}
+
+func runCtxList(cmd *cobra.Command, args []string) {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	mgr, _ := loadContexts()
+
+	table := &output.Table{Headers: []string{"CURRENT", "NAME", "CLUSTER", "SERVER", "NAMESPACE", "SOURCE"}}
+	for _, name := range mgr.GetContexts() {
+		ctx, _ := mgr.Context(name)
+		current := ""
+		if name == mgr.GetCurrentContext() {
+			current = "*"
+		}
+		source := mgr.DiscoveredBy(name)
+		if source == "" {
+			source = ctx.File
+		}
+		row := []string{current, name, ctx.Cluster, mgr.GetServer(name), ctx.Namespace, source}
+		for i, cell := range row[1:] {
+			if cell == "" {
+				row[i+1] = "-"
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	rendered, err := output.NewMerger().RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+}
+
+func runCtxRename(cmd *cobra.Command, args []string) {
+	old, new := args[0], args[1]
+	mgr, cfg := loadContexts()
+	if _, exists := mgr.Context(new); exists {
+		fmt.Fprintf(os.Stderr, "Error: context '%s' already exists\n", new)
+		os.Exit(1)
+	}
+	path, err := mgr.FileOf(old)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	backup, err := cluster.EditKubeConfig(path, func(kc *clientcmdapi.Config) error {
+		kc.Contexts[new] = kc.Contexts[old]
+		delete(kc.Contexts, old)
+		if kc.CurrentContext == old {
+			kc.CurrentContext = new
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Context '%s' renamed to '%s' in %s (backup: %s)\n", old, new, path, backup)
+
+	if cfg.RenameContext(old, new) {
+		saveContextConfig(cfg)
+	}
+}
+
+func runCtxDelete(cmd *cobra.Command, args []string) {
+	mgr, cfg := loadContexts()
+
+	// Each file is backed up and written once, whatever the number of its contexts
+	var files []string
+	contexts := make(map[string][]string)
+	for _, name := range args {
+		path, err := mgr.FileOf(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if _, seen := contexts[path]; !seen {
+			files = append(files, path)
+		}
+		contexts[path] = append(contexts[path], name)
+	}
+
+	changed := false
+	for _, path := range files {
+		backup, err := cluster.EditKubeConfig(path, func(kc *clientcmdapi.Config) error {
+			for _, name := range contexts[path] {
+				delete(kc.Contexts, name)
+				if kc.CurrentContext == name {
+					kc.CurrentContext = ""
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, name := range contexts[path] {
+			fmt.Printf("Context '%s' deleted from %s\n", name, path)
+			if cfg.DeleteContext(name) {
+				changed = true
+			}
+		}
+		fmt.Printf("Backup: %s\n", backup)
+	}
+
+	if changed {
+		saveContextConfig(cfg)
+	}
+}
+
+// saveContextConfig saves the multikube config after it was updated for
+// renamed or deleted contexts. Exits on error.
+func saveContextConfig(cfg *config.MultiKubeConfig) {
+	if err := config.Save(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Updated references in %s\n", config.GetConfigPath())
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+)
+
+// deleteValueFlags are the delete flags not selecting objects that take a separate value
+var deleteValueFlags = map[string]bool{
+	"-o": true, "--output": true,
+	"--grace-period": true,
+	"--cascade":      true,
+	"--raw":          true,
+}
+
+// deleteListArgs returns the args of a get listing the objects that kubectl
+// delete args would delete
+func deleteListArgs(args []string) []string {
+	getArgs := []string{"get"}
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		name, _, hasValue := strings.Cut(arg, "=")
+		if !strings.HasPrefix(arg, "-") {
+			getArgs = append(getArgs, arg)
+			continue
+		}
+		takesValue, selects := selectFlags[name]
+		if !selects {
+			// Skip --now, --force, --all, ... and the flags with a value
+			if deleteValueFlags[name] && !hasValue {
+				i++
+			}
+			continue
+		}
+		getArgs = append(getArgs, arg)
+		if takesValue && !hasValue && i+1 < len(args) {
+			i++
+			getArgs = append(getArgs, args[i])
+		}
+	}
+	return append(getArgs, "-o", "json")
+}
+
+// previewDelete lists the objects kubectl delete args would delete in each
+// target cluster and asks for confirmation. Returns the clusters having any
+// of the objects, where the delete then runs. Exits if the objects could not
+// be listed in some cluster or the delete is not confirmed.
+func previewDelete(sess *session, exec *executor.Executor, args []string) []string {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	merger := sess.newMerger()
+
+	table := &output.Table{Headers: []string{"CLUSTER", "NAMESPACE", "OBJECT"}}
+	var targets []string
+	failed := false
+	for _, r := range sess.run(exec, deleteListArgs(args)) {
+		var obj map[string]interface{}
+		// kubectl lists the objects it found even when some names are not found
+		parseErr := json.Unmarshal([]byte(r.Output), &obj)
+		switch {
+		case r.Error != nil && !strings.Contains(r.Error.Error(), "NotFound"):
+			failed = true
+			fmt.Fprintf(os.Stderr, "Error from cluster %s: %s\n", r.Context, firstLine(r.Error.Error()))
+			continue
+		case parseErr != nil && r.Error == nil:
+			failed = true
+			fmt.Fprintf(os.Stderr, "Error from cluster %s: invalid output: %v\n", r.Context, parseErr)
+			continue
+		case parseErr != nil:
+			// None of the objects exist here
+			continue
+		}
+		objects := []map[string]interface{}{obj}
+		if _, isList := obj["items"]; isList {
+			objects = listItems(obj)
+		}
+		for _, o := range objects {
+			namespace := objectNamespace(o)
+			if namespace == "" {
+				namespace = "-"
+			}
+			table.Rows = append(table.Rows, []string{r.Context, namespace, objectRef(o)})
+		}
+		if len(objects) > 0 {
+			targets = append(targets, r.Context)
+		}
+	}
+	if failed {
+		fmt.Fprintln(os.Stderr, "Error: failed to list the objects to delete, nothing was deleted")
+		os.Exit(1)
+	}
+	if len(targets) == 0 {
+		fmt.Fprintln(os.Stderr, "No objects to delete in any target cluster.")
+		os.Exit(0)
+	}
+
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "The delete would remove these objects:\n%s\n", rendered)
+	confirmChange(fmt.Sprintf("Delete %d object(s) in %d cluster(s)?", len(table.Rows), len(targets)))
+	return targets
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/multikubectl/pkg/cluster"
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/discovery"
+	"github.com/multikubectl/pkg/runner"
+)
+
+// addExtraContexts merges the extra kubeconfig files from the config and
+// runs the enabled discovery providers, adding their contexts to mgr.
+// It returns the providers that succeeded by name.
+func addExtraContexts(mgr *cluster.Manager, cfg *config.MultiKubeConfig) map[string]discovery.Provider {
+	return runner.AddExtraContexts(mgr, cfg, os.Stderr)
+}
@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	drainSelector    string
+	drainCordonOnly  bool
+	drainMaxParallel int
+)
+
+// drainTimeout replaces the default --timeout for drain, since evicting the
+// pods of a node takes far longer than a read
+const drainTimeout = 10 * time.Minute
+
+var drainCmd = &cobra.Command{
+	Use:   "drain-nodes [node...] [-l selector] [-- kubectl drain flags]",
+	Short: "Cordon and drain matching nodes across clusters with safety checks",
+	Long: `Cordon and drain the nodes matching a label selector, or the named nodes, in
+every target cluster. "multikubectl drain" remains kubectl drain, run in every
+cluster without these checks.
+
+Before anything is changed the matching nodes are listed per cluster with the
+number of pods to evict, along with the PodDisruptionBudgets that allow fewer
+disruptions than the drain needs and would block it, and the plan must be
+confirmed (or --yes given). Clusters are then drained --max-parallel at a
+time (one by default); if a cluster fails, the remaining ones are left alone.
+
+With --cordon-only the nodes are only cordoned. Arguments after "--" are
+passed to kubectl drain, e.g. --ignore-daemonsets. --timeout defaults to 10m
+for drain, or to the config's timeout for drain.
+
+Exits with a non-zero status if any cluster failed or was not drained.
+
+Examples:
+  multikubectl drain-nodes -l pool=legacy -- --ignore-daemonsets --delete-emptydir-data
+  multikubectl drain-nodes -l pool=legacy --cordon-only
+  multikubectl drain-nodes worker-3 --contexts prod-east --yes -- --ignore-daemonsets`,
+	Run: runDrain,
+}
+
+func init() {
+	drainCmd.Flags().StringVarP(&drainSelector, "selector", "l", "", "Label selector of the nodes to drain")
+	drainCmd.Flags().BoolVar(&drainCordonOnly, "cordon-only", false, "Only cordon the nodes, without evicting their pods")
+	drainCmd.Flags().IntVar(&drainMaxParallel, "max-parallel", 1, "Maximum number of clusters drained at the same time")
+}
+
+// pdbBlock is a PodDisruptionBudget allowing fewer disruptions than a drain needs
+type pdbBlock struct {
+	name    string
+	allowed int64
+	evicted int
+}
+
+func runDrain(cmd *cobra.Command, args []string) {
+	names, extra := args, []string(nil)
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		names, extra = args[:dash], args[dash:]
+	}
+	if len(names) == 0 && drainSelector == "" {
+		fmt.Fprintln(os.Stderr, "Error: specify the nodes to drain by name or with -l")
+		os.Exit(1)
+	}
+	if drainMaxParallel < 1 {
+		fmt.Fprintln(os.Stderr, "Error: --max-parallel must be at least 1")
+		os.Exit(1)
+	}
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !rootCmd.PersistentFlags().Changed("timeout") {
+		timeout = drainTimeout
+	}
+
+	verb := "drain"
+	if drainCordonOnly {
+		verb = "cordon"
+	}
+	sess := newSession()
+	sess.enforceVerbRestrictions(verb)
+	sess.enforcePolicy([]string{verb, "nodes"})
+	exec := sess.NewExecutor()
+	if configured, ok := sess.TimeoutFor([]string{verb}); ok && !rootCmd.PersistentFlags().Changed("timeout") {
+		exec.SetTimeout(configured)
+	}
+	// Tunnels and logins stay up across the preview and the drain
+	cleanup, err := sess.Prepare(exec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	merger := sess.newMerger()
+
+	// Find the nodes to drain in each cluster
+	listArgs := []string{"get", "nodes", "-o", "json"}
+	if drainSelector != "" {
+		listArgs = append(listArgs, "-l", drainSelector)
+	}
+	lists, results := sess.fetchObjects(exec, listArgs)
+	if len(lists) < len(results) {
+		fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+		fmt.Fprintln(os.Stderr, "Error: failed to list the nodes of every cluster, nothing was changed")
+		os.Exit(1)
+	}
+	nodes := make(map[string][]string)
+	var targets []string
+	total := 0
+	for _, ctx := range sess.Contexts() {
+		for _, node := range listItems(lists[ctx]) {
+			if name := objectName(node); len(names) == 0 || slices.Contains(names, name) {
+				nodes[ctx] = append(nodes[ctx], name)
+			}
+		}
+		if len(nodes[ctx]) > 0 {
+			targets = append(targets, ctx)
+			total += len(nodes[ctx])
+		}
+	}
+	if total == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no nodes match in any target cluster")
+		os.Exit(1)
+	}
+
+	// Preview the evictions and the PodDisruptionBudgets they would run into
+	plan := &output.Table{Headers: []string{"CLUSTER", "NODE"}}
+	var evictions map[string]map[string][]map[string]interface{}
+	var blocks map[string][]pdbBlock
+	if !drainCordonOnly {
+		plan.Headers = append(plan.Headers, "PODS TO EVICT")
+		evictions, blocks = previewEvictions(exec, targets, nodes)
+	}
+	for _, ctx := range targets {
+		for _, node := range nodes[ctx] {
+			row := []string{ctx, node}
+			if !drainCordonOnly {
+				row = append(row, strconv.Itoa(len(evictions[ctx][node])))
+			}
+			plan.Rows = append(plan.Rows, row)
+		}
+	}
+	rendered, err := merger.RenderTable(plan, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+
+	message := fmt.Sprintf("%s %d node(s) in %d cluster(s)?", strings.ToUpper(verb[:1])+verb[1:], total, len(targets))
+	if !drainCordonOnly {
+		blocking := &output.Table{Headers: []string{"CLUSTER", "PODDISRUPTIONBUDGET", "ALLOWED", "TO EVICT"}}
+		for _, ctx := range targets {
+			for _, b := range blocks[ctx] {
+				blocking.Rows = append(blocking.Rows, []string{ctx, b.name, strconv.FormatInt(b.allowed, 10), strconv.Itoa(b.evicted)})
+			}
+		}
+		if len(blocking.Rows) > 0 {
+			rendered, err := merger.RenderTable(blocking, format)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("\nPodDisruptionBudgets that would block the drain:\n%s", rendered)
+			message = fmt.Sprintf("%d PodDisruptionBudget(s) would block evictions. %s", len(blocking.Rows), message)
+		}
+	}
+	fmt.Println()
+
+	confirmChange(message)
+
+	// "NODES" is replaced by each cluster's nodes
+	exec.SetArgsFunc(func(ctx string, args []string) []string {
+		i := slices.Index(args, "NODES")
+		if i < 0 {
+			return args
+		}
+		return slices.Concat(args[:i], nodes[ctx], args[i+1:])
+	})
+	runArgs := append([]string{verb, "NODES"}, extra...)
+
+	table := &output.Table{Headers: []string{"CLUSTER", "NODES", "STATUS"}}
+	failed := false
+	for _, wave := range splitWaves(targets, drainMaxParallel) {
+		if failed {
+			for _, ctx := range wave {
+				table.Rows = append(table.Rows, []string{ctx, strings.Join(nodes[ctx], ","), "not started"})
+			}
+			continue
+		}
+		for _, r := range sess.runOn(exec, wave, runArgs) {
+			status := verb + "ed"
+			if r.Error != nil {
+				failed = true
+				status = "failed: " + firstLine(r.Error.Error())
+			}
+			table.Rows = append(table.Rows, []string{r.Context, strings.Join(nodes[r.Context], ","), status})
+		}
+	}
+	exec.SetArgsFunc(nil)
+	cleanup()
+
+	rendered, err = merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// previewEvictions lists the pods a drain would evict from each node, and
+// the PodDisruptionBudgets allowing fewer disruptions than the drain would
+// cause. Clusters whose pods or budgets can't be listed are left out. The
+// listings are probes, kept out of the audit log and history.
+func previewEvictions(exec *executor.Executor, contexts []string, nodes map[string][]string) (map[string]map[string][]map[string]interface{}, map[string][]pdbBlock) {
+	pods := exec.Probe(context.Background(), contexts, []string{"get", "pods", "-A", "-o", "json"})
+	pdbs := exec.Probe(context.Background(), contexts, []string{"get", "poddisruptionbudgets", "-A", "-o", "json"})
+
+	evictions := make(map[string]map[string][]map[string]interface{})
+	for _, r := range pods {
+		var list map[string]interface{}
+		if r.Error != nil || json.Unmarshal([]byte(r.Output), &list) != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to list the pods of %s, evictions are not previewed\n", r.Context)
+			continue
+		}
+		evictions[r.Context] = make(map[string][]map[string]interface{})
+		for _, pod := range listItems(list) {
+			spec, _ := pod["spec"].(map[string]interface{})
+			node, _ := spec["nodeName"].(string)
+			if slices.Contains(nodes[r.Context], node) && evictable(pod) {
+				evictions[r.Context][node] = append(evictions[r.Context][node], pod)
+			}
+		}
+	}
+
+	blocks := make(map[string][]pdbBlock)
+	for _, r := range pdbs {
+		var list map[string]interface{}
+		if r.Error != nil || json.Unmarshal([]byte(r.Output), &list) != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to list the PodDisruptionBudgets of %s\n", r.Context)
+			continue
+		}
+		for _, pdb := range listItems(list) {
+			spec, _ := pdb["spec"].(map[string]interface{})
+			selector, _ := spec["selector"].(map[string]interface{})
+			evicted := 0
+			for _, nodePods := range evictions[r.Context] {
+				for _, pod := range nodePods {
+					metadata, _ := pod["metadata"].(map[string]interface{})
+					labels, _ := metadata["labels"].(map[string]interface{})
+					if objectNamespace(pod) == objectNamespace(pdb) && matchesSelector(selector, labels) {
+						evicted++
+					}
+				}
+			}
+			allowed := nestedInt(pdb, "status", "disruptionsAllowed")
+			if evicted > 0 && int64(evicted) > allowed {
+				name := objectNamespace(pdb) + "/" + objectName(pdb)
+				blocks[r.Context] = append(blocks[r.Context], pdbBlock{name: name, allowed: allowed, evicted: evicted})
+			}
+		}
+	}
+	return evictions, blocks
+}
+
+// evictable reports whether kubectl drain would evict pod: running pods not
+// managed by a DaemonSet and not mirroring a static pod
+func evictable(pod map[string]interface{}) bool {
+	if phase := nestedString(pod, "status", "phase"); phase == "Succeeded" || phase == "Failed" {
+		return false
+	}
+	metadata, _ := pod["metadata"].(map[string]interface{})
+	if annotations, _ := metadata["annotations"].(map[string]interface{}); annotations["kubernetes.io/config.mirror"] != nil {
+		return false
+	}
+	owners, _ := metadata["ownerReferences"].([]interface{})
+	for _, o := range owners {
+		if owner, _ := o.(map[string]interface{}); owner["kind"] == "DaemonSet" {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesSelector reports whether labels match a Kubernetes label selector
+// (matchLabels and matchExpressions). A nil selector matches nothing, like
+// the selector of a PodDisruptionBudget.
+func matchesSelector(selector, labels map[string]interface{}) bool {
+	if selector == nil {
+		return false
+	}
+	matchLabels, _ := selector["matchLabels"].(map[string]interface{})
+	for key, value := range matchLabels {
+		if labels[key] != value {
+			return false
+		}
+	}
+	expressions, _ := selector["matchExpressions"].([]interface{})
+	for _, e := range expressions {
+		expr, _ := e.(map[string]interface{})
+		key, _ := expr["key"].(string)
+		values, _ := expr["values"].([]interface{})
+		value, present := labels[key]
+		in := slices.Contains(values, value)
+		switch expr["operator"] {
+		case "In":
+			if !present || !in {
+				return false
+			}
+		case "NotIn":
+			if present && in {
+				return false
+			}
+		case "Exists":
+			if !present {
+				return false
+			}
+		case "DoesNotExist":
+			if present {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/multikubectl/pkg/diff"
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	driftNamespace string
+	driftFile      string
+	driftBaseline  string
+)
+
+var driftCmd = &cobra.Command{
+	Use:   "drift <kind>/<name> | -f manifest.yaml",
+	Short: "Detect configuration drift of an object across clusters",
+	Long: `Fetch an object from every target cluster, normalize it (dropping status and
+server-populated metadata), and report which clusters match a baseline and
+which drift, with field-level differences.
+
+The baseline is the first cluster that returned the object, the context
+named by --baseline, or the manifest given with -f. When comparing against a
+manifest, only the fields present in the manifest are checked.
+
+Exits with a non-zero status if any cluster drifts or fails.
+
+Examples:
+  # Compare a deployment across clusters, using the first cluster as baseline
+  multikubectl drift deployment/nginx -n web
+
+  # Use a specific cluster as the source of truth
+  multikubectl drift configmap/app-config -n web --baseline prod-us
+
+  # Compare every object in a manifest against the live clusters
+  multikubectl drift -f manifests/app.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runDrift,
+}
+
+func init() {
+	driftCmd.Flags().StringVarP(&driftNamespace, "namespace", "n", "", "Namespace of the object")
+	driftCmd.Flags().StringVarP(&driftFile, "filename", "f", "", "Manifest file whose objects are compared (and used as the baseline)")
+	driftCmd.Flags().StringVar(&driftBaseline, "baseline", "", "Context to use as the baseline (default: first cluster, or the manifest with -f)")
+}
+
+// driftTarget is an object to check for drift, with an optional local baseline
+type driftTarget struct {
+	ref       string
+	namespace string
+	manifest  map[string]interface{}
+}
+
+func runDrift(cmd *cobra.Command, args []string) {
+	var targets []driftTarget
+	switch {
+	case driftFile != "" && len(args) > 0:
+		fmt.Fprintln(os.Stderr, "Error: specify either <kind>/<name> or -f, not both")
+		os.Exit(1)
+	case driftFile != "":
+		data, err := os.ReadFile(driftFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading manifest: %v\n", err)
+			os.Exit(1)
+		}
+		objects, err := diff.DecodeDocuments(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, obj := range objects {
+			ref, err := diff.ResourceRef(obj)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			namespace := diff.Namespace(obj)
+			if namespace == "" {
+				namespace = driftNamespace
+			}
+			targets = append(targets, driftTarget{ref: ref, namespace: namespace, manifest: diff.Normalize(obj)})
+		}
+	case len(args) == 1:
+		targets = append(targets, driftTarget{ref: args[0], namespace: driftNamespace})
+	default:
+		cmd.Help()
+		return
+	}
+
+	sess := newSession()
+	exec := sess.NewExecutor()
+	merger := sess.newMerger()
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for i, target := range targets {
+		if i > 0 {
+			fmt.Println()
+		}
+		if !reportDrift(sess, exec, merger, format, target) {
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// reportDrift compares one object across clusters and prints the report.
+// Returns false if any cluster drifted or failed.
+func reportDrift(sess *session, exec *executor.Executor, merger *output.Merger, format output.Format, target driftTarget) bool {
+	getArgs := []string{"get", target.ref, "-o", "json"}
+	if target.namespace != "" {
+		getArgs = append(getArgs, "-n", target.namespace)
+	}
+	objects, results := sess.fetchObjects(exec, getArgs)
+	for _, obj := range objects {
+		diff.Normalize(obj)
+	}
+
+	// Pick the baseline: explicit context, local manifest, or first cluster with the object
+	var baseline map[string]interface{}
+	baselineName := driftBaseline
+	subset := false
+	switch {
+	case driftBaseline != "":
+		baseline = objects[driftBaseline]
+		if baseline == nil {
+			fmt.Fprintf(os.Stderr, "Error: baseline context '%s' did not return %s\n", driftBaseline, target.ref)
+			return false
+		}
+	case target.manifest != nil:
+		baseline = target.manifest
+		baselineName = driftFile
+		subset = true
+	default:
+		for _, r := range results {
+			if obj, ok := objects[r.Context]; ok {
+				baseline = obj
+				baselineName = r.Context
+				break
+			}
+		}
+		if baseline == nil {
+			fmt.Fprintf(os.Stderr, "Error: %s was not found in any cluster\n", target.ref)
+			fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+			return false
+		}
+	}
+
+	ok := true
+	table := &output.Table{Headers: []string{"CLUSTER", "STATUS"}}
+	drifted := make(map[string][]diff.Change)
+	for _, r := range results {
+		obj, found := objects[r.Context]
+		switch {
+		case r.Error != nil:
+			table.Rows = append(table.Rows, []string{r.Context, "error: " + strings.TrimSpace(r.Error.Error())})
+			ok = false
+		case !found:
+			table.Rows = append(table.Rows, []string{r.Context, "error: invalid object"})
+			ok = false
+		case r.Context == baselineName:
+			table.Rows = append(table.Rows, []string{r.Context, "baseline"})
+		default:
+			changes := diff.Compare(baseline, obj, subset)
+			if len(changes) == 0 {
+				table.Rows = append(table.Rows, []string{r.Context, "match"})
+			} else {
+				table.Rows = append(table.Rows, []string{r.Context, fmt.Sprintf("drift: %d field(s)", len(changes))})
+				drifted[r.Context] = changes
+				ok = false
+			}
+		}
+	}
+
+	fmt.Printf("%s (baseline: %s)\n", target.ref, baselineName)
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+
+	for _, r := range results {
+		changes, found := drifted[r.Context]
+		if !found {
+			continue
+		}
+		fmt.Printf("\n=== Cluster: %s ===\n", r.Context)
+		for _, c := range changes {
+			fmt.Printf("  %s: %s -> %s\n", c.Path, c.Baseline, c.Actual)
+		}
+	}
+
+	return ok
+}
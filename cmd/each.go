@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var eachTable bool
+
+var eachCmd = &cobra.Command{
+	Use:   "each -- <command> [args...]",
+	Short: "Run an arbitrary command once per cluster",
+	Long: `Run a command (helm, a script, ...) once for every target context, in
+parallel and with the same timeout, serial mode and output merging as kubectl
+commands.
+
+Each invocation gets these environment variables:
+  KUBECONTEXT       the context name
+  KUBECONFIG        the kubeconfig file holding the context
+  MULTIKUBE_ALIAS   the context's alias from the config (or its name)
+
+KUBECONFIG does not switch the current context, so pass $KUBECONTEXT to the
+tool (e.g. helm --kube-context). Impersonation and other kubectl flags are
+not applied; for a context with an SSH tunnel, KUBECONFIG starts with a
+private file routing the context through the tunnel.
+
+The policy is checked with verb "each": deny and confirm rules that name
+resources or namespaces apply, since the command's objects can't be told.
+
+Output is grouped by cluster, or merged into one table with --table when the
+command prints a table.
+
+Examples:
+  multikubectl each -- sh -c 'helm list -A --kube-context "$KUBECONTEXT"'
+  multikubectl each --table -- sh -c 'helm list --kube-context "$KUBECONTEXT"'
+  multikubectl each --context-selector env=prod -- ./scripts/check.sh`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runEach,
+}
+
+func init() {
+	eachCmd.Flags().BoolVar(&eachTable, "table", false, "Merge the output of every cluster into one table with a CLUSTER column")
+	// Everything after the command name belongs to the command
+	eachCmd.Flags().SetInterspersed(false)
+}
+
+func runEach(cmd *cobra.Command, args []string) {
+	sess := newSession()
+	sess.enforceVerbRestrictions("each")
+	sess.enforceCommandPolicy("each", args)
+	exec := sess.NewExecutor()
+	exec.SetRawCommand(true)
+	for _, ctx := range sess.Contexts() {
+		alias := sess.Config().SettingsFor(ctx).Alias
+		if alias == "" {
+			alias = ctx
+		}
+		exec.AddEnv(ctx, "MULTIKUBE_ALIAS="+alias)
+	}
+	merger := sess.newMerger()
+
+	results := sess.run(exec, args)
+
+	if eachTable {
+		fmt.Print(merger.MergeResults(results, true))
+	} else {
+		fmt.Print(merger.MergeNonTableOutput(results))
+	}
+	if quiet {
+		fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+	}
+	if showStderr {
+		fmt.Fprint(os.Stderr, merger.MergeStderr(results))
+	}
+	if showSummary {
+		fmt.Fprint(os.Stderr, merger.RenderSummary(results))
+	}
+
+	for _, r := range results {
+		if r.Error != nil {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/multikubectl/pkg/diff"
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// editValueFlags are the kubectl edit flags that take a separate value
+var editValueFlags = map[string]bool{
+	"-n": true, "--namespace": true,
+	"-o": true, "--output": true,
+	"--field-manager": true,
+	"--template":      true,
+}
+
+// runEdit edits an object in the first target cluster that has it, then
+// offers the same change, as a merge patch, to each of the other clusters in
+// turn, showing what it would change there and asking before applying it
+func runEdit(sess *session, exec *executor.Executor, args []string) {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	operands, namespace, err := parseEditArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintln(os.Stderr, "Error: edit needs a terminal for the editor")
+		os.Exit(1)
+	}
+	merger := sess.newMerger()
+	ref := strings.Join(operands, "/")
+
+	var nsArgs []string
+	if namespace != "" {
+		nsArgs = []string{"-n", namespace}
+	}
+	getArgs := append(append(append([]string{"get"}, operands...), "-o", "json"), nsArgs...)
+	objects, results := sess.fetchObjects(exec, getArgs)
+
+	// The object is edited in the first cluster (in target order) that has it
+	source := ""
+	for _, ctx := range sess.Contexts() {
+		if _, ok := objects[ctx]; ok {
+			source = ctx
+			break
+		}
+	}
+	if source == "" {
+		fmt.Fprintf(os.Stderr, "Error: %s was not found in any target cluster\n", ref)
+		fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+		os.Exit(1)
+	}
+
+	original := diff.Normalize(objects[source])
+	edited, err := editObject(ref, source, original)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	patch := diff.MergePatch(original, edited)
+	if len(patch) == 0 {
+		fmt.Fprintln(os.Stderr, "Edit cancelled, no changes made.")
+		return
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to encode patch: %v\n", err)
+		os.Exit(1)
+	}
+	patchArgs := append(append(append([]string{"patch"}, operands...), nsArgs...), "--type", "merge", "-p", string(data))
+
+	statuses := make(map[string]string)
+	failed := false
+	apply := func(ctx, status string) bool {
+		r := sess.runOn(exec, []string{ctx}, patchArgs)[0]
+		if r.Error != nil {
+			failed = true
+			statuses[ctx] = firstLine(r.Error.Error())
+			return false
+		}
+		statuses[ctx] = status
+		return true
+	}
+
+	if !apply(source, "edited") {
+		fmt.Fprintf(os.Stderr, "Error: failed to apply the edit to %s: %s\n", source, statuses[source])
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Edited %s in %s.\n", ref, source)
+
+	errs := make(map[string]string)
+	for _, r := range results {
+		if r.Error != nil {
+			errs[r.Context] = firstLine(r.Error.Error())
+		}
+	}
+
+	cancelled := false
+	for _, ctx := range sess.Contexts() {
+		if ctx == source {
+			continue
+		}
+		obj, ok := objects[ctx]
+		switch {
+		case cancelled:
+			statuses[ctx] = "skipped"
+			continue
+		case !ok && errs[ctx] != "":
+			failed = true
+			statuses[ctx] = errs[ctx]
+			continue
+		case !ok:
+			failed = true
+			statuses[ctx] = "not found"
+			continue
+		}
+
+		current := diff.Normalize(obj)
+		patched := diff.ApplyMergePatch(current, patch)
+		if reflect.DeepEqual(current, patched) {
+			statuses[ctx] = "unchanged"
+			continue
+		}
+
+		before, _ := encodeYAML(current)
+		after, _ := encodeYAML(patched)
+		fmt.Fprintf(os.Stderr, "\n=== Cluster: %s ===\n", ctx)
+		fmt.Fprint(os.Stderr, diff.Unified(ctx+"/"+ref, ctx+"/"+ref+" (patched)", string(before), string(after), diff.DefaultContextLines))
+
+		var proceed bool
+		prompt := &survey.Confirm{Message: fmt.Sprintf("Apply the same change to %s?", ctx), Default: false}
+		if err := survey.AskOne(prompt, &proceed, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)); err != nil {
+			// Interrupted: leave this and the remaining clusters alone
+			cancelled = true
+			statuses[ctx] = "skipped"
+			continue
+		}
+		if !proceed {
+			statuses[ctx] = "skipped"
+			continue
+		}
+		apply(ctx, "patched")
+	}
+
+	fmt.Fprintln(os.Stderr)
+	table := &output.Table{Headers: []string{"CLUSTER", "STATUS"}}
+	for _, ctx := range sess.Contexts() {
+		table.Rows = append(table.Rows, []string{ctx, statuses[ctx]})
+	}
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// parseEditArgs returns the object operands of "edit" (TYPE/NAME or TYPE
+// NAME) and the namespace given with -n
+func parseEditArgs(args []string) ([]string, string, error) {
+	var operands []string
+	namespace := ""
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			name, value, hasValue := strings.Cut(arg, "=")
+			switch name {
+			case "-f", "--filename", "-k", "--kustomize", "-l", "--selector":
+				return nil, "", fmt.Errorf("edit across clusters works on a single object; %s is not supported", name)
+			}
+			if editValueFlags[name] && !hasValue && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			if name == "-n" || name == "--namespace" {
+				namespace = value
+			}
+			continue
+		}
+		operands = append(operands, arg)
+	}
+
+	switch {
+	case len(operands) == 1 && strings.Contains(operands[0], "/"):
+	case len(operands) == 2 && !strings.Contains(operands[0], "/"):
+	default:
+		return nil, "", fmt.Errorf("edit needs a single object: TYPE/NAME or TYPE NAME")
+	}
+	return operands, namespace, nil
+}
+
+// editObject opens obj as YAML in $KUBE_EDITOR or $EDITOR (default vi) and
+// returns the edited object
+func editObject(ref, context string, obj map[string]interface{}) (map[string]interface{}, error) {
+	data, err := encodeYAML(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", ref, err)
+	}
+
+	f, err := os.CreateTemp("", "multikubectl-edit-*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	header := fmt.Sprintf("# Editing %s in %s.\n"+
+		"# The change is applied to this cluster, then offered as a patch to the\n"+
+		"# other clusters one at a time. Save the file unchanged to cancel.\n#\n", ref, context)
+	if _, err := f.WriteString(header + string(data)); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	f.Close()
+
+	editor := os.Getenv("KUBE_EDITOR")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edited file: %w", err)
+	}
+	objects, err := diff.DecodeDocuments(edited)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) != 1 {
+		return nil, fmt.Errorf("the edited file must contain exactly one object")
+	}
+	return objects[0], nil
+}
+
+// encodeYAML renders an object as YAML indented the way kubectl does
+func encodeYAML(obj map[string]interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(obj); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/spf13/cobra"
+)
+
+// runWatchEvents watches the Events of every target cluster until
+// interrupted, writing them as one table with a row per event as it
+// happens, tagged with its cluster. --event-type and --event-reason keep only
+// some events. A cluster whose watch ends is watched again.
+func runWatchEvents(cmd *cobra.Command, sess *session, exec *executor.Executor, args []string, settings *outputSettings) {
+	if kubectlOutputFormat(args) != "" {
+		fmt.Fprintln(os.Stderr, "Error: events --watch can't be combined with -o")
+		os.Exit(1)
+	}
+	// Watching runs until stopped unless --timeout is given
+	if !cmd.Flags().Changed("timeout") {
+		exec.SetTimeout(0)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	merger := sess.newMerger()
+	merger.SetFilter(settings.filter)
+	merger.SetAllNamespaces(allNamespaces(args))
+	watcher := merger.NewEventWatcher(os.Stdout, sess.Contexts(), eventTypes, eventReasons)
+	if settings.streaming() {
+		watcher.SetJSONL(merger.NewJSONLWriter(os.Stdout))
+	}
+	pipeline := executor.NewPipeline(watcher.Add)
+	exec.SetLineCallback(pipeline.Send)
+	cleanup, err := sess.Prepare(exec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+
+	watchArgs := append(append([]string(nil), args...), "-o", "json")
+	results := make([]executor.Result, len(sess.Contexts()))
+	var wg sync.WaitGroup
+	for i, cluster := range sess.Contexts() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			note := func(marker string) { pipeline.Send(cluster, marker) }
+			if settings.streaming() {
+				note = stderrNote(cluster)
+			}
+			results[i] = watchCluster(ctx, sess, exec, cluster, watchArgs, note, nil)
+		}()
+	}
+	wg.Wait()
+	exec.SetLineCallback(nil)
+	pipeline.Close()
+
+	failed := false
+	for _, r := range results {
+		if r.Error != nil && ctx.Err() == nil {
+			failed = true
+			fmt.Fprintf(os.Stderr, "Error from cluster %s: %s\n", r.Context, firstLine(r.Error.Error()))
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
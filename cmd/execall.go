@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	execAllSelector  string
+	execAllNamespace string
+	execAllContainer string
+	execAllPods      bool
+)
+
+var execAllCmd = &cobra.Command{
+	Use:   "exec-all -l selector [-n namespace] [-c container] -- <command> [args...]",
+	Short: "Run a command in the pods matching a selector in every cluster",
+	Long: `Find the running pods matching a label selector in every target cluster and
+run a non-interactive command in them with kubectl exec, then print the output
+of each cluster and pod.
+
+By default the command runs in the newest running pod of each cluster; with
+--all-pods it runs in every matching pod. Clusters are run in parallel, the
+pods of one cluster one after the other. Clusters without a matching pod are
+reported on stderr.
+
+Exits with a non-zero status if the command failed in any pod, or no cluster
+has a matching pod.
+
+Examples:
+  multikubectl exec-all -l app=api -n shop -- nginx -T
+  multikubectl exec-all -l app=api -n shop --all-pods -c app -- cat /etc/app/config.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runExecAll,
+}
+
+func init() {
+	execAllCmd.Flags().StringVarP(&execAllSelector, "selector", "l", "", "Label selector of the pods to run the command in (required)")
+	execAllCmd.Flags().StringVarP(&execAllNamespace, "namespace", "n", "", "Namespace of the pods")
+	execAllCmd.Flags().StringVarP(&execAllContainer, "container", "c", "", "Container to run the command in (the pod's default container if empty)")
+	execAllCmd.Flags().BoolVar(&execAllPods, "all-pods", false, "Run the command in every matching pod instead of the newest one")
+}
+
+func runExecAll(cmd *cobra.Command, args []string) {
+	if execAllSelector == "" {
+		fmt.Fprintln(os.Stderr, "Error: specify the pods with -l")
+		os.Exit(1)
+	}
+	if cmd.ArgsLenAtDash() != 0 {
+		fmt.Fprintln(os.Stderr, "Error: put the command after \"--\", e.g. exec-all -l app=api -- nginx -T")
+		os.Exit(1)
+	}
+	var namespace []string
+	if execAllNamespace != "" {
+		namespace = []string{"-n", execAllNamespace}
+	}
+	execArgs := append([]string{"exec", "POD"}, namespace...)
+	if execAllContainer != "" {
+		execArgs = append(execArgs, "-c", execAllContainer)
+	}
+	execArgs = append(append(execArgs, "--"), args...)
+
+	sess := newSession()
+	sess.enforceVerbRestrictions("exec")
+	sess.enforcePolicy(execArgs)
+	exec := sess.NewExecutor()
+	merger := sess.newMerger()
+
+	// Find the pods to run the command in
+	listArgs := append([]string{"get", "pods", "-l", execAllSelector, "-o", "json"}, namespace...)
+	lists, results := sess.fetchObjects(exec, listArgs)
+	failed := false
+	for _, r := range results {
+		if r.Error != nil {
+			failed = true
+			fmt.Fprintf(os.Stderr, "Error from cluster %s: failed to list the pods: %s\n", r.Context, firstLine(r.Error.Error()))
+		}
+	}
+	pods := make(map[string][]string)
+	rounds := 0
+	for _, ctx := range sess.Contexts() {
+		list, ok := lists[ctx]
+		if !ok {
+			continue
+		}
+		for _, pod := range runningPods(list) {
+			pods[ctx] = append(pods[ctx], objectName(pod))
+			if !execAllPods {
+				break
+			}
+		}
+		if len(pods[ctx]) == 0 {
+			fmt.Fprintf(os.Stderr, "Warning: no running pod matches %s in cluster %s\n", execAllSelector, ctx)
+		}
+		rounds = max(rounds, len(pods[ctx]))
+	}
+	if rounds == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no running pod matches in any target cluster")
+		os.Exit(1)
+	}
+
+	// Each round runs the command in the next pod of every cluster having one
+	byCluster := make(map[string][]executor.Result)
+	for round := range rounds {
+		var contexts []string
+		for _, ctx := range sess.Contexts() {
+			if round < len(pods[ctx]) {
+				contexts = append(contexts, ctx)
+			}
+		}
+		exec.SetArgsFunc(func(ctx string, args []string) []string {
+			args = slices.Clone(args)
+			args[slices.Index(args, "POD")] = pods[ctx][round]
+			return args
+		})
+		for _, r := range sess.runOn(exec, contexts, execArgs) {
+			cluster := r.Context
+			r.Context = cluster + "/" + pods[cluster][round]
+			byCluster[cluster] = append(byCluster[cluster], r)
+		}
+	}
+	exec.SetArgsFunc(nil)
+	var runs []executor.Result
+	for _, ctx := range sess.Contexts() {
+		runs = append(runs, byCluster[ctx]...)
+	}
+
+	fmt.Print(merger.MergeNonTableOutput(runs))
+	if quiet || prefixFormat != "" {
+		fmt.Fprint(os.Stderr, merger.MergeErrors(runs))
+	}
+	if showStderr {
+		fmt.Fprint(os.Stderr, merger.MergeStderr(runs))
+	}
+	if showSummary {
+		fmt.Fprint(os.Stderr, merger.RenderSummary(runs))
+	}
+
+	for _, r := range runs {
+		if r.Error != nil {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// execSelector splits the -l/--selector flag off kubectl exec args, looking
+// only at the flags before "--"
+func execSelector(args []string) (string, []string) {
+	for i := 1; i < len(args) && args[i] != "--"; i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "-l" && name != "--selector" {
+			continue
+		}
+		end := i + 1
+		if !hasValue && i+1 < len(args) {
+			value, end = args[i+1], i+2
+		}
+		return value, slices.Concat(args[:i], args[end:])
+	}
+	return "", args
+}
+
+// resolveExecPods lets kubectl exec args select the pod with -l instead of
+// naming it, since pod names differ between clusters: the selector is
+// resolved to the newest running pod of each cluster, which the executor
+// substitutes per cluster. Returns the rewritten args and the contexts having
+// a matching pod; clusters without one are reported on stderr.
+func resolveExecPods(sess *session, exec *executor.Executor, args, contexts []string) ([]string, []string, error) {
+	selector, rest := execSelector(args)
+	if selector == "" {
+		return args, contexts, nil
+	}
+	flags := rest
+	if dash := slices.Index(rest, "--"); dash >= 0 {
+		flags = rest[:dash]
+	}
+	listArgs := append([]string{"get", "pods", "-l", selector, "-o", "json"}, namespaceArgs(flags)...)
+
+	pods := make(map[string]string)
+	for _, r := range sess.runOn(exec, contexts, listArgs) {
+		var list map[string]interface{}
+		if r.Error != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to list the pods of cluster %s: %s\n", r.Context, firstLine(r.Error.Error()))
+			continue
+		}
+		if err := json.Unmarshal([]byte(r.Output), &list); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to parse output from cluster %s: %v\n", r.Context, err)
+			continue
+		}
+		running := runningPods(list)
+		if len(running) == 0 {
+			fmt.Fprintf(os.Stderr, "Warning: no running pod matches %s in cluster %s\n", selector, r.Context)
+			continue
+		}
+		pods[r.Context] = objectName(running[0])
+	}
+	found := slices.DeleteFunc(slices.Clone(contexts), func(ctx string) bool { return pods[ctx] == "" })
+	if len(found) == 0 {
+		return nil, nil, fmt.Errorf("no running pod matches %s in any target cluster", selector)
+	}
+
+	exec.SetArgsFunc(func(ctx string, args []string) []string {
+		args = slices.Clone(args)
+		args[1] = pods[ctx]
+		return args
+	})
+	return slices.Concat([]string{"exec", "POD"}, rest[1:]), found, nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// runFirstSuccess runs kubectl args against contexts until one succeeds,
+// then stops the others: the clusters run in parallel, or one after the
+// other in the configured order with --serial. Returns only the result of
+// the first cluster that succeeded, or every result if none did.
+func runFirstSuccess(sess *session, exec *executor.Executor, contexts []string, args []string) []executor.Result {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var winner *executor.Result
+	onResult := exec.ResultCallback()
+	exec.SetResultCallback(func(r executor.Result) {
+		mu.Lock()
+		first := r.Error == nil && winner == nil
+		if first {
+			winner = &r
+			cancel()
+		}
+		mu.Unlock()
+		if first && onResult != nil {
+			onResult(r)
+		}
+	})
+	results, err := sess.execute(ctx, exec, contexts, args)
+	exec.SetResultCallback(onResult)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if winner == nil {
+		if onResult != nil {
+			for _, r := range results {
+				onResult(r)
+			}
+		}
+		return results
+	}
+	return []executor.Result{*winner}
+}
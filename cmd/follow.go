@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+)
+
+// Delays between attempts to resume following a cluster's logs
+const (
+	reconnectDelay    = 2 * time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// logsValueFlags are the kubectl logs flags that take a separate value
+var logsValueFlags = map[string]bool{
+	"-c": true, "--container": true,
+	"-n": true, "--namespace": true,
+	"-l": true, "--selector": true,
+	"--since": true, "--since-time": true, "--tail": true,
+	"--limit-bytes": true, "--max-log-requests": true, "--pod-running-timeout": true,
+}
+
+// podLabels are the labels unique to one pod, left out of the selector that
+// finds the pod replacing it
+var podLabels = []string{"pod-template-hash", "controller-revision-hash", "statefulset.kubernetes.io/pod-name", "apps.kubernetes.io/pod-index"}
+
+// logsPodArg returns the index in logs args of the pod named without a
+// resource type (or as pod/name), or -1 if the logs follow a selector or a
+// workload, which kubectl resolves to a pod again on every run
+func logsPodArg(args []string) int {
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			name, _, hasValue := strings.Cut(arg, "=")
+			if name == "-l" || name == "--selector" {
+				return -1
+			}
+			if logsValueFlags[name] && !hasValue {
+				i++
+			}
+			continue
+		}
+		kind, _, typed := strings.Cut(arg, "/")
+		if typed && kind != "pod" && kind != "pods" && kind != "po" {
+			return -1
+		}
+		return i
+	}
+	return -1
+}
+
+// namespaceArgs returns the -n/--namespace flags of kubectl args
+func namespaceArgs(args []string) []string {
+	var ns []string
+	for i := 0; i < len(args); i++ {
+		name, _, hasValue := strings.Cut(args[i], "=")
+		if name != "-n" && name != "--namespace" {
+			continue
+		}
+		ns = append(ns, args[i])
+		if !hasValue && i+1 < len(args) {
+			i++
+			ns = append(ns, args[i])
+		}
+	}
+	return ns
+}
+
+// podSelector returns a label selector matching the pods that replace pod,
+// e.g. the other pods of its Deployment, or "" if the pod has no labels
+func podSelector(pod map[string]interface{}) string {
+	metadata, _ := pod["metadata"].(map[string]interface{})
+	labels, _ := metadata["labels"].(map[string]interface{})
+	var terms []string
+	for _, key := range sortedKeys(labels) {
+		if !slices.Contains(podLabels, key) {
+			terms = append(terms, fmt.Sprintf("%s=%v", key, labels[key]))
+		}
+	}
+	return strings.Join(terms, ",")
+}
+
+// resolvePod returns the newest running pod matching selector in a cluster
+func resolvePod(ctx context.Context, exec *executor.Executor, cluster, selector string, namespace []string) (string, error) {
+	args := append([]string{"get", "pods", "-l", selector, "-o", "json"}, namespace...)
+	r := exec.Probe(ctx, []string{cluster}, args)[0]
+	if r.Error != nil {
+		return "", fmt.Errorf("failed to find a pod matching %s: %s", selector, firstLine(r.Error.Error()))
+	}
+	var list map[string]interface{}
+	if err := json.Unmarshal([]byte(r.Output), &list); err != nil {
+		return "", fmt.Errorf("failed to find a pod matching %s: %w", selector, err)
+	}
+	running := runningPods(list)
+	if len(running) == 0 {
+		return "", fmt.Errorf("no running pod matches %s", selector)
+	}
+	return objectName(running[0]), nil
+}
+
+// runningPods returns the running pods of a pod list that are not being
+// deleted, newest first
+func runningPods(list map[string]interface{}) []map[string]interface{} {
+	var running []map[string]interface{}
+	for _, pod := range listItems(list) {
+		metadata, _ := pod["metadata"].(map[string]interface{})
+		if nestedString(pod, "status", "phase") == "Running" && metadata["deletionTimestamp"] == nil {
+			running = append(running, pod)
+		}
+	}
+	sort.Slice(running, func(i, j int) bool {
+		return nestedString(running[i], "metadata", "creationTimestamp") > nestedString(running[j], "metadata", "creationTimestamp")
+	})
+	return running
+}
+
+// followState is the position in a cluster's followed log stream, from the
+// timestamps of its lines. --since-time only has the precision of a second,
+// so a stream resumed at the last line repeats the lines before it in that
+// second; those are dropped. Timestamps are removed again unless asked for.
+type followState struct {
+	mu         sync.Mutex
+	timestamps bool
+	last       time.Time
+	// seen are the lines logged at last
+	seen     map[string]bool
+	resuming bool
+	// dropping is set after a dropped line, to drop its continuation lines
+	dropping bool
+}
+
+func newFollowState(timestamps bool) *followState {
+	return &followState{timestamps: timestamps, seen: make(map[string]bool)}
+}
+
+// resume returns the time to resume the stream at, the last line's or
+// fallback if there was none. Repeated lines are only dropped if the stream
+// resumes on the same pod.
+func (s *followState) resume(fallback time.Time, samePod bool) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resuming = samePod && !s.last.IsZero()
+	if s.last.IsZero() {
+		return fallback
+	}
+	return s.last
+}
+
+// started reports whether a line with a timestamp was seen
+func (s *followState) started() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.last.IsZero()
+}
+
+// line returns a line of the stream as written out, or false if it repeats
+// a line written before the stream was resumed
+func (s *followState) line(line string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, stamped := output.LogTimestamp(line)
+	if !stamped {
+		return line, !s.dropping
+	}
+	if s.resuming {
+		if t.Before(s.last) || (t.Equal(s.last) && s.seen[line]) {
+			s.dropping = true
+			return "", false
+		}
+		s.resuming = !t.After(s.last)
+	}
+	s.dropping = false
+	if !t.Equal(s.last) {
+		s.last = t
+		clear(s.seen)
+	}
+	s.seen[line] = true
+	if !s.timestamps {
+		line = output.StripLogTimestamp(line)
+	}
+	return line, true
+}
+
+// podRemains reports whether a pod still exists and is not being deleted
+func podRemains(ctx context.Context, exec *executor.Executor, cluster, pod string, namespace []string) bool {
+	args := append([]string{"get", "pod", pod, "-o", "json"}, namespace...)
+	r := exec.Probe(ctx, []string{cluster}, args)[0]
+	var obj map[string]interface{}
+	if r.Error != nil || json.Unmarshal([]byte(r.Output), &obj) != nil {
+		return false
+	}
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	return metadata["deletionTimestamp"] == nil
+}
+
+// resumeArgs returns logs args resuming at since, instead of the --tail,
+// --since or --since-time of the first run
+func resumeArgs(args []string, since time.Time) []string {
+	var resumed []string
+	for i := 0; i < len(args); i++ {
+		name, _, hasValue := strings.Cut(args[i], "=")
+		switch name {
+		case "--tail", "--since", "--since-time":
+			if !hasValue {
+				i++
+			}
+			continue
+		}
+		resumed = append(resumed, args[i])
+	}
+	return append(resumed, "--since-time="+since.UTC().Format(time.RFC3339Nano))
+}
+
+// followCluster follows the logs of one cluster, with kubectl's timestamps,
+// until ctx is done or the stream ends for good. When kubectl fails, e.g.
+// because the connection dropped, or the pod followed by name is deleted,
+// following resumes where it stopped, at the last line state saw, finding
+// the replacing pod by the labels of the deleted one. A stream ending
+// because the container exited is not resumed. note writes a marker line
+// into the cluster's stream. Returns the result of the first run if it
+// failed before logging a line, otherwise of the last one.
+func followCluster(ctx context.Context, sess *session, exec *executor.Executor, cluster string, args []string, state *followState, note func(string)) executor.Result {
+	podArg := logsPodArg(args)
+	selector, pod := "", ""
+	if podArg >= 0 {
+		pod = args[podArg]
+		if _, name, typed := strings.Cut(pod, "/"); typed {
+			pod = name
+		}
+		getArgs := append([]string{"get", "pod", pod, "-o", "json"}, namespaceArgs(args)...)
+		if r := exec.Probe(ctx, []string{cluster}, getArgs)[0]; r.Error == nil {
+			var obj map[string]interface{}
+			if json.Unmarshal([]byte(r.Output), &obj) == nil {
+				selector = podSelector(obj)
+			}
+		}
+	}
+
+	runArgs := args
+	delay := reconnectDelay
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		results, err := sess.ExecuteOn(ctx, exec, []string{cluster}, runArgs)
+		if err != nil {
+			return executor.Result{Context: cluster, Error: err, ExitCode: -1}
+		}
+		r := results[0]
+		if ctx.Err() != nil || (attempt == 0 && r.Error != nil && !state.started()) {
+			return r
+		}
+
+		var reason string
+		switch {
+		case r.Error != nil:
+			reason = firstLine(r.Error.Error())
+		case selector != "" && !podRemains(ctx, exec, cluster, pod, namespaceArgs(args)):
+			reason = fmt.Sprintf("pod %s was deleted", pod)
+		default:
+			// The container exited, or the selector's pods are gone
+			return r
+		}
+		if time.Since(start) > time.Minute {
+			delay = reconnectDelay
+		}
+		note(fmt.Sprintf("--- disconnected: %s; reconnecting in %s ---", reason, delay))
+		select {
+		case <-ctx.Done():
+			return r
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+
+		replacement := pod
+		if selector != "" {
+			var err error
+			if replacement, err = resolvePod(ctx, exec, cluster, selector, namespaceArgs(args)); err != nil {
+				note(fmt.Sprintf("--- %v ---", err))
+				continue
+			}
+		}
+		runArgs = resumeArgs(args, state.resume(r.EndTime, replacement == pod))
+		if selector != "" {
+			runArgs[logsPodArg(runArgs)] = replacement
+			pod = replacement
+		}
+		note(fmt.Sprintf("--- reconnected to %s ---", strings.Join(runArgs[1:], " ")))
+	}
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/multikubectl/pkg/cluster"
+	"github.com/multikubectl/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var configGroupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Manage groups of contexts",
+	Long: `Groups name a set of contexts, or of other groups, so a fleet can be
+organized by region and environment:
+
+  groups:
+    prod: [prod-us, prod-eu]
+    prod-us: [us-east, us-west]
+    prod-eu: [eu-central]
+
+A group name can be used anywhere a context name can, e.g. --contexts prod.`,
+}
+
+var configGroupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List groups with their members and resolved contexts",
+	Args:  cobra.NoArgs,
+	Run:   runConfigGroupList,
+}
+
+var configGroupEditCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Interactively select the members of a group",
+	Long: `Interactively select the contexts and groups that make up a group, with a
+multi-select interface like "config select". The group's current members
+are pre-selected, and the group is created if it doesn't exist.
+
+Selecting nothing offers to delete the group.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runConfigGroupEdit,
+}
+
+func init() {
+	configGroupCmd.AddCommand(configGroupListCmd)
+	configGroupCmd.AddCommand(configGroupEditCmd)
+	configCmd.AddCommand(configGroupCmd)
+}
+
+func runConfigGroupList(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(cfg.Groups) == 0 {
+		fmt.Println("No groups configured.")
+		return
+	}
+
+	names := make([]string, 0, len(cfg.Groups))
+	for name := range cfg.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("%s: %s\n", name, strings.Join(cfg.Groups[name], ", "))
+		resolved, err := cfg.ExpandContexts([]string{name})
+		if err != nil {
+			fmt.Printf("  error: %v\n", err)
+			continue
+		}
+		fmt.Printf("  contexts: %s\n", strings.Join(resolved, ", "))
+	}
+}
+
+func runConfigGroupEdit(cmd *cobra.Command, args []string) {
+	name := args[0]
+
+	// Load kubeconfig to get all available contexts
+	mgr, err := cluster.NewManager("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading kubeconfig: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	addExtraContexts(mgr, cfg)
+
+	// Offer other groups first, then contexts
+	var options []string
+	isGroup := make(map[string]bool)
+	for group := range cfg.Groups {
+		if group != name {
+			options = append(options, group)
+			isGroup[group] = true
+		}
+	}
+	sort.Strings(options)
+	for _, ctx := range mgr.GetContexts() {
+		if !isGroup[ctx] {
+			options = append(options, ctx)
+		}
+	}
+	if len(options) == 0 {
+		fmt.Fprintln(os.Stderr, "No contexts found in kubeconfig")
+		os.Exit(1)
+	}
+
+	// Pre-select current members that are still available
+	var defaultSelected []string
+	for _, member := range cfg.Groups[name] {
+		if slices.Contains(options, member) {
+			defaultSelected = append(defaultSelected, member)
+		}
+	}
+
+	var selected []string
+	prompt := &survey.MultiSelect{
+		Message:  fmt.Sprintf("Select members of group '%s' (space to select, enter to confirm):", name),
+		Options:  options,
+		Default:  defaultSelected,
+		PageSize: 15,
+		Description: func(value string, index int) string {
+			if isGroup[value] {
+				return "group"
+			}
+			return ""
+		},
+	}
+
+	err = survey.AskOne(prompt, &selected, survey.WithKeepFilter(true))
+	if err != nil {
+		if err.Error() == "interrupt" {
+			fmt.Println("\nCancelled.")
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(selected) == 0 {
+		if _, exists := cfg.Groups[name]; !exists {
+			fmt.Println("No changes made.")
+			return
+		}
+		var deleteGroup bool
+		confirmPrompt := &survey.Confirm{
+			Message: fmt.Sprintf("No members selected. Delete group '%s'?", name),
+			Default: false,
+		}
+		survey.AskOne(confirmPrompt, &deleteGroup)
+		if !deleteGroup {
+			fmt.Println("No changes made.")
+			return
+		}
+		delete(cfg.Groups, name)
+		if err := config.Save(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Group '%s' deleted.\n", name)
+		return
+	}
+
+	if cfg.Groups == nil {
+		cfg.Groups = make(map[string][]string)
+	}
+	cfg.Groups[name] = selected
+	resolved, err := cfg.ExpandContexts([]string{name})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := config.Save(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\nGroup '%s' has %d member(s):\n", name, len(selected))
+	for _, member := range selected {
+		fmt.Printf("  - %s\n", member)
+	}
+	fmt.Printf("Resolves to %d context(s): %s\n", len(resolved), strings.Join(resolved, ", "))
+	fmt.Printf("\nConfiguration saved to %s\n", config.GetConfigPath())
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var healthChecks bool
+
+var healthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "Sweep the API server /readyz and /livez checks across clusters",
+	Long: `Query the verbose /readyz and /livez endpoints of every target cluster and
+merge the individual checks (etcd, informer-sync, poststarthooks, ...) into
+one table, listing the failing checks of each cluster.
+
+With --checks, every check is shown on its own row instead.
+
+Exits with a non-zero status if any cluster has a failing check or could
+not be reached.
+
+Examples:
+  multikubectl health
+  multikubectl health --checks --context-selector env=prod`,
+	Args: cobra.NoArgs,
+	Run:  runHealth,
+}
+
+func init() {
+	healthCmd.Flags().BoolVar(&healthChecks, "checks", false, "Show one row per check instead of one row per cluster")
+}
+
+// healthEndpoints are the API server health endpoints swept, in column order
+var healthEndpoints = []string{"readyz", "livez"}
+
+// healthCheckLine matches a verbose health check line like "[+]etcd ok" or
+// "[-]etcd failed: reason withheld"
+var healthCheckLine = regexp.MustCompile(`\[([+-])\](\S+) (ok|failed.*)`)
+
+// healthCheck is the result of one named check of a health endpoint
+type healthCheck struct {
+	name   string
+	ok     bool
+	status string
+}
+
+func runHealth(cmd *cobra.Command, args []string) {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sess := newSession()
+	exec := sess.NewExecutor()
+	merger := sess.newMerger()
+
+	// checks[endpoint][context] holds the parsed checks; errs the clusters that gave none
+	checks := make(map[string]map[string][]healthCheck)
+	errs := make(map[string]map[string]string)
+	for _, endpoint := range healthEndpoints {
+		checks[endpoint] = make(map[string][]healthCheck)
+		errs[endpoint] = make(map[string]string)
+		for _, r := range sess.run(exec, []string{"get", "--raw", "/" + endpoint + "?verbose"}) {
+			parsed := parseHealthChecks(r)
+			if len(parsed) == 0 {
+				msg := "no checks reported"
+				if r.Error != nil {
+					msg = firstLine(r.Error.Error())
+				}
+				errs[endpoint][r.Context] = msg
+				continue
+			}
+			checks[endpoint][r.Context] = parsed
+		}
+	}
+
+	failed := false
+	var table *output.Table
+	if healthChecks {
+		table = &output.Table{Headers: []string{"CLUSTER", "ENDPOINT", "CHECK", "STATUS"}}
+		for _, ctx := range sess.Contexts() {
+			for _, endpoint := range healthEndpoints {
+				if msg, ok := errs[endpoint][ctx]; ok {
+					failed = true
+					table.Rows = append(table.Rows, []string{ctx, endpoint, "-", msg})
+					continue
+				}
+				for _, c := range checks[endpoint][ctx] {
+					if !c.ok {
+						failed = true
+					}
+					table.Rows = append(table.Rows, []string{ctx, endpoint, c.name, c.status})
+				}
+			}
+		}
+	} else {
+		table = &output.Table{Headers: []string{"CLUSTER", "READYZ", "LIVEZ", "PASSED", "FAILING"}}
+		for _, ctx := range sess.Contexts() {
+			row := []string{ctx}
+			passed, total := 0, 0
+			var failing []string
+			for _, endpoint := range healthEndpoints {
+				if msg, ok := errs[endpoint][ctx]; ok {
+					failed = true
+					row = append(row, "error")
+					// An unreachable cluster fails both endpoints the same way
+					if !slices.Contains(failing, msg) {
+						failing = append(failing, msg)
+					}
+					continue
+				}
+				status := "ok"
+				for _, c := range checks[endpoint][ctx] {
+					total++
+					if c.ok {
+						passed++
+						continue
+					}
+					failed = true
+					status = "failing"
+					failing = append(failing, endpoint+":"+c.name)
+				}
+				row = append(row, status)
+			}
+			failingCell := strings.Join(failing, ", ")
+			if failingCell == "" {
+				failingCell = "-"
+			}
+			passedCell := "-"
+			if total > 0 {
+				passedCell = strconv.Itoa(passed) + "/" + strconv.Itoa(total)
+			}
+			row = append(row, passedCell, failingCell)
+			table.Rows = append(table.Rows, row)
+		}
+	}
+
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// parseHealthChecks extracts the checks from a verbose health endpoint
+// response. When a check fails the API server answers with an error whose
+// message embeds the response body with escaped newlines, so both the output
+// and the error are searched.
+func parseHealthChecks(r executor.Result) []healthCheck {
+	text := r.Output
+	if r.Error != nil {
+		text += "\n" + r.Error.Error()
+	}
+	text = strings.ReplaceAll(text, `\n`, "\n")
+
+	var checks []healthCheck
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(text, "\n") {
+		m := healthCheckLine.FindStringSubmatch(line)
+		if m == nil || seen[m[2]] {
+			continue
+		}
+		seen[m[2]] = true
+		// Drop what kubectl appends after the embedded body, e.g. `") has prevented the request...`
+		status, _, _ := strings.Cut(m[3], `")`)
+		checks = append(checks, healthCheck{name: m[2], ok: m[1] == "+", status: strings.TrimSpace(status)})
+	}
+	return checks
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/rancher"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var (
+	rancherURL      string
+	rancherToken    string
+	rancherPrefix   string
+	rancherInsecure bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import contexts from a cluster management system",
+}
+
+var importRancherCmd = &cobra.Command{
+	Use:   "rancher",
+	Short: "Generate contexts for every cluster managed by a Rancher server",
+	Long: `Enumerate the clusters of a Rancher server and generate a context for each
+one, reaching the cluster through the Rancher API proxy with the given token.
+
+Contexts are written to ~/.multikube/imports/ and merged with your kubeconfig.
+Re-running the import keeps them in sync with the Rancher inventory: new
+clusters are added and removed clusters are dropped, including from the
+configured context list.
+
+Examples:
+  multikubectl import rancher --url https://rancher.example.com --token token-abc:xyz
+  RANCHER_TOKEN=token-abc:xyz multikubectl import rancher --url https://rancher.example.com`,
+	Args: cobra.NoArgs,
+	Run:  runImportRancher,
+}
+
+func init() {
+	importRancherCmd.Flags().StringVar(&rancherURL, "url", "", "Rancher server URL")
+	importRancherCmd.Flags().StringVar(&rancherToken, "token", "", "Rancher API token (default: $RANCHER_TOKEN)")
+	importRancherCmd.Flags().StringVar(&rancherPrefix, "prefix", rancher.DefaultPrefix, "Prefix for generated context names")
+	importRancherCmd.Flags().BoolVar(&rancherInsecure, "insecure-skip-tls-verify", false, "Don't verify the Rancher server certificate")
+	importRancherCmd.MarkFlagRequired("url")
+
+	importCmd.AddCommand(importRancherCmd)
+}
+
+func runImportRancher(cmd *cobra.Command, args []string) {
+	token := rancherToken
+	if token == "" {
+		token = os.Getenv("RANCHER_TOKEN")
+	}
+	if token == "" {
+		fmt.Fprintln(os.Stderr, "Error: --token or $RANCHER_TOKEN is required")
+		os.Exit(1)
+	}
+
+	client := rancher.NewClient(rancherURL, token, rancherInsecure)
+	clusters, err := client.ListClusters()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	path := filepath.Join(config.GetConfigDir(), "imports", "rancher-"+client.Hostname()+".kubeconfig")
+	var previous []string
+	if old, err := clientcmd.LoadFromFile(path); err == nil {
+		for name := range old.Contexts {
+			previous = append(previous, name)
+		}
+	}
+
+	kubeconfig := client.KubeConfig(clusters, rancherPrefix, rancherInsecure)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create imports directory: %v\n", err)
+		os.Exit(1)
+	}
+	// WriteToFile creates the file with mode 0600 since it holds the token
+	if err := clientcmd.WriteToFile(*kubeconfig, path); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write kubeconfig: %v\n", err)
+		os.Exit(1)
+	}
+
+	var added, removed []string
+	for name := range kubeconfig.Contexts {
+		if !slices.Contains(previous, name) {
+			added = append(added, name)
+		}
+	}
+	for _, name := range previous {
+		if _, ok := kubeconfig.Contexts[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.AddKubeConfig(path)
+	// An empty context list means all contexts, which already includes the import
+	if len(cfg.Contexts) > 0 {
+		for _, name := range removed {
+			cfg.RemoveContext(name)
+		}
+		for _, name := range added {
+			cfg.AddContext(name)
+		}
+	}
+	if err := config.Save(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Imported %d cluster(s) from %s\n", len(clusters), rancherURL)
+	for _, c := range clusters {
+		marker := "  "
+		if slices.Contains(added, rancherPrefix+c.Name) {
+			marker = "+ "
+		}
+		fmt.Printf("%s%s%s (%s)\n", marker, rancherPrefix, c.Name, c.State)
+	}
+	for _, name := range removed {
+		fmt.Printf("- %s (no longer in Rancher)\n", name)
+	}
+	fmt.Printf("\nContexts saved to %s\n", path)
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/multikubectl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show a merged cluster-info overview across clusters",
+	Long: `Run "kubectl cluster-info" and "kubectl version" against every target cluster
+and normalize the results into one table: context, API endpoint, DNS
+endpoint and server version.
+
+Use "multikubectl cluster-info" for the raw per-cluster output.
+
+Examples:
+  multikubectl info
+  multikubectl info --contexts prod-east,prod-west --output-format csv`,
+	Args: cobra.NoArgs,
+	Run:  runInfo,
+}
+
+// ansiEscape matches the color codes kubectl cluster-info prints
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// runningAt matches cluster-info lines like "CoreDNS is running at https://..."
+var runningAt = regexp.MustCompile(`^(.+?) is running at (\S+)`)
+
+func runInfo(cmd *cobra.Command, args []string) {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sess := newSession()
+	exec := sess.NewExecutor()
+	merger := sess.newMerger()
+
+	infoResults := sess.run(exec, []string{"cluster-info"})
+	versionResults := sess.run(exec, []string{"version", "-o", "json"})
+
+	versions := make(map[string]string)
+	for _, r := range versionResults {
+		// kubectl version exits non-zero when the server is unreachable but
+		// still prints the client version, so only the output matters here
+		var v struct {
+			ServerVersion struct {
+				GitVersion string `json:"gitVersion"`
+			} `json:"serverVersion"`
+		}
+		if json.Unmarshal([]byte(r.Output), &v) == nil && v.ServerVersion.GitVersion != "" {
+			versions[r.Context] = v.ServerVersion.GitVersion
+		}
+	}
+
+	table := &output.Table{Headers: []string{"CONTEXT", "API ENDPOINT", "DNS", "VERSION"}}
+	for _, r := range infoResults {
+		if r.Error != nil {
+			continue
+		}
+		api, dns := parseClusterInfo(r.Output)
+		version := versions[r.Context]
+		if version == "" {
+			version = "-"
+		}
+		table.Rows = append(table.Rows, []string{r.Context, api, dns, version})
+	}
+
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+
+	fmt.Fprint(os.Stderr, merger.MergeErrors(infoResults))
+	for _, r := range infoResults {
+		if r.Error != nil {
+			os.Exit(1)
+		}
+	}
+}
+
+// parseClusterInfo extracts the control-plane and DNS endpoints from
+// kubectl cluster-info output, returning "-" for any that are missing
+func parseClusterInfo(out string) (api, dns string) {
+	api, dns = "-", "-"
+	for _, line := range strings.Split(ansiEscape.ReplaceAllString(out, ""), "\n") {
+		m := runningAt.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		name := strings.ToLower(m[1])
+		switch {
+		case strings.Contains(name, "control plane"), strings.Contains(name, "master"):
+			api = m[2]
+		case strings.Contains(name, "dns"):
+			dns = m[2]
+		}
+	}
+	return api, dns
+}
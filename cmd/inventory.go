@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/multikubectl/pkg/inventory"
+	"github.com/spf13/cobra"
+)
+
+var inventoryOutput string
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Export the resolved cluster set for GitOps tooling",
+	Long: `Print the target contexts, resolved from flags and config exactly as for
+any other command, in a stable schema (apiVersion multikubectl/v1) that
+ApplicationSet generators, Terraform and scripts can consume.
+
+Contexts skipped by the circuit breaker are still included.
+
+Examples:
+  multikubectl inventory
+  multikubectl inventory -o json --all-contexts`,
+	Args: cobra.NoArgs,
+	Run:  runInventory,
+}
+
+func init() {
+	inventoryCmd.Flags().StringVarP(&inventoryOutput, "output", "o", "yaml", "Output format: json or yaml")
+}
+
+func runInventory(cmd *cobra.Command, args []string) {
+	sess := resolveSession()
+
+	inv := inventory.New()
+	for _, ctx := range sess.Contexts() {
+		settings := sess.Config().SettingsFor(ctx)
+		entry := inventory.Cluster{
+			Name:   ctx,
+			Alias:  settings.Alias,
+			Server: sess.Manager().GetServer(ctx),
+			Source: sess.Source(ctx),
+			Labels: settings.Labels,
+			Groups: sess.Config().GroupsOf(ctx),
+		}
+		if entry.Labels == nil {
+			entry.Labels = map[string]string{}
+		}
+		if entry.Groups == nil {
+			entry.Groups = []string{}
+		}
+		if c, ok := sess.Manager().Context(ctx); ok {
+			entry.Cluster = c.Cluster
+			entry.Namespace = c.Namespace
+		}
+		inv.Clusters = append(inv.Clusters, entry)
+	}
+
+	data, err := inv.Encode(inventoryOutput)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(data)
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/logfile"
+	"github.com/multikubectl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// interleaveWindow is how long followed log lines are held to be put in
+// timestamp order with the lines of other clusters
+const interleaveWindow = 2 * time.Second
+
+// boolFlag reports whether a kubectl boolean flag is set in args, e.g. -f,
+// --follow or --follow=true
+func boolFlag(args []string, names ...string) bool {
+	for _, arg := range args {
+		for _, name := range names {
+			if arg == name || arg == name+"=true" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// logTimestamps reports whether logs args print timestamps, which lets the
+// lines of every cluster be interleaved in order
+func logTimestamps(args []string) bool {
+	return args[0] == "logs" && boolFlag(args, "--timestamps")
+}
+
+// logLimitArgs adds the configured --tail and --since to logs args that set
+// no limit of their own
+func logLimitArgs(cfg *config.MultiKubeConfig, args []string) []string {
+	if args[0] != "logs" {
+		return args
+	}
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, "=")
+		switch name {
+		case "--tail", "--since", "--since-time":
+			return args
+		}
+	}
+	tail, since := config.DefaultLogTail, ""
+	if cfg.Logs != nil {
+		if cfg.Logs.Tail != 0 {
+			tail = cfg.Logs.Tail
+		}
+		since = cfg.Logs.Since
+	}
+	var limits []string
+	if tail >= 0 {
+		limits = append(limits, fmt.Sprintf("--tail=%d", tail))
+	}
+	if since != "" {
+		limits = append(limits, "--since="+since)
+	}
+	if len(limits) == 0 {
+		return args
+	}
+	if !quiet && output.StderrIsTerminal() {
+		fmt.Fprintf(os.Stderr, "Note: limiting logs to %s, pass --tail or --since to change it\n", strings.Join(limits, " "))
+	}
+	return slices.Concat(args, limits)
+}
+
+// runFollowLogs follows logs in every target cluster until interrupted.
+// With --timestamps the lines of all clusters are written as one stream
+// ordered by timestamp, otherwise each line is written as soon as it arrives,
+// prefixed with its cluster. With --log-dir each cluster's lines are also
+// written to <dir>/<context>.log. A cluster whose stream fails is reconnected.
+func runFollowLogs(cmd *cobra.Command, sess *session, exec *executor.Executor, args []string, settings *outputSettings) {
+	// Following runs until stopped unless --timeout or a timeout for logs in
+	// the config is given
+	if _, configured := sess.TimeoutFor(args); !configured && !cmd.Flags().Changed("timeout") {
+		exec.SetTimeout(0)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	merger := sess.newMerger()
+	// Lines come one at a time through the pipeline below
+	write := func(context, line string) {
+		fmt.Print(merger.LogLine(context, line))
+	}
+	closeOutput := func() {}
+	if settings.streaming() {
+		write = merger.NewJSONLWriter(os.Stdout).Line
+	} else if logTimestamps(args) {
+		// Lines are held at least as long as the dedupe window to find their duplicates
+		interleaver := merger.NewLogInterleaver(os.Stdout, max(interleaveWindow, dedupeWindow))
+		write, closeOutput = interleaver.Add, interleaver.Close
+	}
+
+	files := make(map[string]*logfile.File)
+	if logDir != "" {
+		for _, ctx := range sess.Contexts() {
+			f, err := logfile.Open(filepath.Join(logDir, executor.ContextFileName(ctx)+".log"), logMaxSize*1024*1024, logfile.DefaultMaxFiles)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			files[ctx] = f
+		}
+	}
+	onLine := func(context, line string) {
+		if f := files[context]; f != nil {
+			if err := f.WriteLine(line); err != nil {
+				// Keep following on the terminal
+				fmt.Fprintf(os.Stderr, "Warning: %v, no longer writing %s to a file\n", err, context)
+				delete(files, context)
+			}
+		}
+		write(context, line)
+	}
+	pipeline := executor.NewPipeline(onLine)
+	// kubectl's timestamps tell where to resume a stream, and which lines
+	// the resumed stream repeats
+	states := make(map[string]*followState)
+	for _, cluster := range sess.Contexts() {
+		states[cluster] = newFollowState(logTimestamps(args))
+	}
+	followArgs := args
+	if !logTimestamps(args) {
+		followArgs = append(slices.Clone(args), "--timestamps")
+	}
+	exec.SetLineCallback(func(context, line string) {
+		if state := states[context]; state != nil {
+			var ok bool
+			if line, ok = state.line(line); !ok {
+				return
+			}
+		}
+		pipeline.Send(context, line)
+	})
+	// Tunnels and logins stay up across reconnections
+	cleanup, err := sess.Prepare(exec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+	results := make([]executor.Result, len(sess.Contexts()))
+	var wg sync.WaitGroup
+	for i, cluster := range sess.Contexts() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			note := func(marker string) { pipeline.Send(cluster, marker) }
+			if settings.streaming() {
+				note = stderrNote(cluster)
+			}
+			results[i] = followCluster(ctx, sess, exec, cluster, followArgs, states[cluster], note)
+		}()
+	}
+	wg.Wait()
+	exec.SetLineCallback(nil)
+	pipeline.Close()
+	closeOutput()
+
+	failed := false
+	for _, r := range results {
+		if r.Error != nil && ctx.Err() == nil {
+			failed = true
+			fmt.Fprintf(os.Stderr, "Error from cluster %s: %s\n", r.Context, firstLine(r.Error.Error()))
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
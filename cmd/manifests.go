@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/manifest"
+)
+
+// kustomizeDir returns the directory of the -k/--kustomize flag in args and
+// the args without the flag, or "" if there is none
+func kustomizeDir(args []string) (string, []string) {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if name != "-k" && name != "--kustomize" {
+			continue
+		}
+		rest := append([]string(nil), args[:i]...)
+		if hasValue {
+			return value, append(rest, args[i+1:]...)
+		}
+		if i+1 < len(args) {
+			return args[i+1], append(rest, args[i+2:]...)
+		}
+	}
+	return "", args
+}
+
+// manifestFiles returns the -f/--filename values in args and the args
+// without them. Stdin ("-") and URLs can't be templated and are left out.
+func manifestFiles(args []string) ([]string, []string) {
+	var files, rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		if name != "-f" && name != "--filename" {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				rest = append(rest, arg)
+				continue
+			}
+			i++
+			value = args[i]
+		}
+		files = append(files, value)
+	}
+	return files, rest
+}
+
+// manifestSet is the content of manifest files read for rendering
+type manifestSet struct {
+	names    []string
+	contents map[string][]byte
+	// encrypted is set when any file was decrypted with SOPS
+	encrypted bool
+}
+
+// readManifests reads manifest files, and the .yaml, .yml and .json files
+// of directories, and of their subdirectories if recursive, decrypting the
+// files encrypted with SOPS in memory
+func readManifests(paths []string, recursive bool) (*manifestSet, error) {
+	set := &manifestSet{contents: make(map[string][]byte)}
+	for _, path := range paths {
+		if path == "-" || strings.Contains(path, "://") {
+			return nil, fmt.Errorf("can't render %s, only local manifest files", path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifests: %w", err)
+		}
+		files := []string{path}
+		if info.IsDir() {
+			files = nil
+			err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if entry.IsDir() {
+					if file != path && !recursive {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				switch filepath.Ext(file) {
+				case ".yaml", ".yml", ".json":
+					files = append(files, file)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read manifests: %w", err)
+			}
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read manifests: %w", err)
+			}
+			if manifest.Encrypted(data) {
+				if planOnly {
+					return nil, errPlanEncrypted
+				}
+				if data, err = manifest.Decrypt(file); err != nil {
+					return nil, err
+				}
+				set.encrypted = true
+			}
+			set.contents[file] = data
+			set.names = append(set.names, file)
+		}
+	}
+	return set, nil
+}
+
+// join concatenates the manifests into one multi-document stream
+func (set *manifestSet) join(render func(name string, data []byte) ([]byte, error)) ([]byte, error) {
+	var stream []byte
+	for _, name := range set.names {
+		data := set.contents[name]
+		if render != nil {
+			var err error
+			if data, err = render(name, data); err != nil {
+				return nil, err
+			}
+		}
+		stream = append(stream, "---\n"...)
+		stream = append(stream, data...)
+		if !bytes.HasSuffix(stream, []byte("\n")) {
+			stream = append(stream, '\n')
+		}
+	}
+	return stream, nil
+}
+
+// errPlanEncrypted refuses --plan for SOPS encrypted manifests, whose
+// plaintext it would print as the stdin of each command
+var errPlanEncrypted = errors.New("--plan can't be used with SOPS encrypted manifests, since it would print them decrypted")
+
+// decryptManifests decrypts the SOPS encrypted manifests of an apply -f in
+// memory and feeds them to every cluster on stdin, so no plaintext is written
+// to disk. Returns the apply args reading the manifests from stdin, or nil if
+// no manifest is encrypted. Manifests piped on stdin are decrypted by
+// pipeStdin; those from URLs can't be checked.
+func decryptManifests(sess *session, exec *executor.Executor, args []string) ([]string, error) {
+	paths, rest := manifestFiles(args)
+	var local []string
+	for _, path := range paths {
+		if path != "-" && !strings.Contains(path, "://") {
+			local = append(local, path)
+		}
+	}
+	if len(local) == 0 {
+		return nil, nil
+	}
+	recursive := recursiveFlag(rest)
+	set, err := readManifests(local, recursive)
+	if err != nil || !set.encrypted {
+		return nil, err
+	}
+	if len(local) < len(paths) {
+		return nil, fmt.Errorf("encrypted manifests can't be applied together with manifests from stdin or URLs")
+	}
+	rest = slices.DeleteFunc(rest, func(arg string) bool {
+		return arg == "-R" || strings.HasPrefix(arg, "--recursive")
+	})
+	stream, err := set.join(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, ctx := range sess.Contexts() {
+		exec.SetStdin(ctx, stream)
+	}
+	return append(rest, "-f", "-"), nil
+}
+
+// recursiveFlag reports whether kubectl args read -f directories recursively
+func recursiveFlag(args []string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool {
+		return arg == "-R" || arg == "--recursive" || arg == "--recursive=true"
+	})
+}
+
+// valuesFor returns the values file of a context: <dir>/<context>.yaml (or
+// <dir>/<alias>.yaml) when --values is a directory, otherwise the path with
+// {context} replaced by the context
+func valuesFor(ctx, alias, path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return strings.ReplaceAll(path, "{context}", ctx), nil
+	}
+	for _, name := range []string{ctx, alias} {
+		for _, ext := range []string{".yaml", ".yml"} {
+			file := filepath.Join(path, name+ext)
+			if _, err := os.Stat(file); err == nil {
+				return file, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no values file for %s in %s", ctx, path)
+}
+
+// renderValues renders the manifests of an apply -f as Go templates with
+// each cluster's values from --values, and feeds every cluster its own
+// manifests on stdin. Nothing is applied unless every cluster's manifests
+// render. Returns the apply args reading the manifests from stdin.
+func renderValues(sess *session, exec *executor.Executor, args []string) ([]string, error) {
+	paths, rest := manifestFiles(args)
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("--values needs the manifest templates given with -f")
+	}
+	set, err := readManifests(paths, recursiveFlag(rest))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ctx := range sess.Contexts() {
+		alias := sess.Config().SettingsFor(ctx).Alias
+		if alias == "" {
+			alias = ctx
+		}
+		file, err := valuesFor(ctx, alias, valuesPath)
+		if err != nil {
+			return nil, err
+		}
+		values, err := manifest.ReadValues(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ctx, err)
+		}
+		data := manifest.TemplateData{Context: ctx, Alias: alias, Values: values}
+		rendered, err := set.join(func(name string, text []byte) ([]byte, error) {
+			out, err := manifest.Render(filepath.Base(name), text, data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", ctx, name, err)
+			}
+			return out, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		exec.SetStdin(ctx, rendered)
+	}
+	return append(rest, "-f", "-"), nil
+}
+
+// readsStdin reports whether kubectl args read manifests from stdin (-f -)
+func readsStdin(args []string) bool {
+	if i := slices.Index(args, "--"); i >= 0 {
+		args = args[:i]
+	}
+	files, _ := manifestFiles(args)
+	return slices.Contains(files, "-")
+}
+
+// pipeStdin reads the manifests piped to multikubectl and feeds them to every
+// cluster's kubectl, with the cluster's vars from the config substituted for
+// ${NAME}. References to a var that only some clusters define are reported.
+func pipeStdin(sess *session, exec *executor.Executor) error {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read stdin: %w", err)
+	}
+	if manifest.Encrypted(data) {
+		if planOnly {
+			return errPlanEncrypted
+		}
+		if data, err = manifest.DecryptData("stdin", data); err != nil {
+			return err
+		}
+	}
+	defined := make(map[string]bool)
+	for _, ctx := range sess.Contexts() {
+		for name := range sess.Config().SettingsFor(ctx).Vars {
+			defined[name] = true
+		}
+	}
+	for _, ctx := range sess.Contexts() {
+		out, undefined := manifest.Substitute(data, sess.Config().SettingsFor(ctx).Vars)
+		for _, name := range undefined {
+			if defined[name] {
+				fmt.Fprintf(os.Stderr, "Warning: ${%s} is not defined for %s and is left as is\n", name, ctx)
+			}
+		}
+		exec.SetStdin(ctx, out)
+	}
+	return nil
+}
+
+// overlayFor returns the kustomization directory a context is built from:
+// dir/<context>, or dir/<alias> when the context has an alias, if either
+// exists, and base otherwise
+func overlayFor(sess *session, ctx, dir, base string) string {
+	names := []string{ctx}
+	if alias := sess.Config().SettingsFor(ctx).Alias; alias != "" {
+		names = append(names, alias)
+	}
+	for _, name := range names {
+		overlay := filepath.Join(dir, name)
+		if info, err := os.Stat(overlay); err == nil && info.IsDir() {
+			return overlay
+		}
+	}
+	return base
+}
+
+// renderOverlays builds the kustomization of an apply -k once per overlay,
+// picking each cluster's overlay from --overlay-dir, and feeds every cluster
+// its own manifests on stdin. Returns the apply args reading the manifests
+// from stdin instead of -k.
+func renderOverlays(sess *session, exec *executor.Executor, args []string) ([]string, error) {
+	base, rest := kustomizeDir(args)
+	if base == "" {
+		return nil, fmt.Errorf("--overlay-dir needs the base kustomization given with -k")
+	}
+	if info, err := os.Stat(overlayDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("overlay directory %s not found", overlayDir)
+	}
+
+	rendered := make(map[string][]byte)
+	for _, ctx := range sess.Contexts() {
+		dir := overlayFor(sess, ctx, overlayDir, base)
+		data, ok := rendered[dir]
+		if !ok {
+			var err error
+			if data, err = manifest.Kustomize(dir); err != nil {
+				return nil, fmt.Errorf("failed to render manifests for %s: %w", ctx, err)
+			}
+			rendered[dir] = data
+		}
+		if dir == base {
+			fmt.Fprintf(os.Stderr, "%s: no overlay, using %s\n", ctx, base)
+		}
+		exec.SetStdin(ctx, data)
+	}
+	return append(rest, "-f", "-"), nil
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/multikubectl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	missingNamespace     string
+	missingAllNamespaces bool
+	missingSelector      string
+)
+
+var missingCmd = &cobra.Command{
+	Use:   "missing <resource>",
+	Short: "List resources present in only some clusters",
+	Long: `List the resources that exist in some target clusters but not in others,
+with the clusters that have each one and those that don't.
+
+Objects are matched by name (and namespace with -A). Clusters where the
+lookup fails are left out of the comparison and reported on stderr.
+
+Examples:
+  multikubectl missing deployments -n shop
+  multikubectl missing crds
+  multikubectl missing configmaps -A -l team=payments`,
+	Args: cobra.ExactArgs(1),
+	Run:  runMissing,
+}
+
+func init() {
+	missingCmd.Flags().StringVarP(&missingNamespace, "namespace", "n", "", "Namespace to compare")
+	missingCmd.Flags().BoolVarP(&missingAllNamespaces, "all-namespaces", "A", false, "Compare across all namespaces, matching objects by namespace and name")
+	missingCmd.Flags().StringVarP(&missingSelector, "selector", "l", "", "Label selector to filter on")
+}
+
+func runMissing(cmd *cobra.Command, args []string) {
+	resource := args[0]
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	getArgs := []string{"get", resource, "--no-headers"}
+	if missingAllNamespaces {
+		getArgs = append(getArgs, "-A", "-o", "custom-columns=NAMESPACE:.metadata.namespace,NAME:.metadata.name")
+	} else {
+		getArgs = append(getArgs, "-o", "custom-columns=NAME:.metadata.name")
+		if missingNamespace != "" {
+			getArgs = append(getArgs, "-n", missingNamespace)
+		}
+	}
+	if missingSelector != "" {
+		getArgs = append(getArgs, "-l", missingSelector)
+	}
+
+	sess := newSession()
+	exec := sess.NewExecutor()
+	merger := sess.newMerger()
+	results := sess.run(exec, getArgs)
+
+	// The clusters holding each object, keyed by its name or "namespace name"
+	var clusters []string
+	present := make(map[string]map[string]bool)
+	failed := false
+	for _, r := range results {
+		if r.Error != nil {
+			failed = true
+			continue
+		}
+		clusters = append(clusters, r.Context)
+		for _, line := range strings.Split(r.Output, "\n") {
+			object := strings.Join(strings.Fields(line), " ")
+			if object == "" {
+				continue
+			}
+			if present[object] == nil {
+				present[object] = make(map[string]bool)
+			}
+			present[object][r.Context] = true
+		}
+	}
+
+	objects := make([]string, 0, len(present))
+	for object, in := range present {
+		if len(in) < len(clusters) {
+			objects = append(objects, object)
+		}
+	}
+	sort.Strings(objects)
+
+	if len(objects) == 0 {
+		fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+		if len(clusters) > 0 {
+			fmt.Fprintf(os.Stderr, "All %d %s exist in every cluster (%s)\n", len(present), resource, strings.Join(clusters, ", "))
+		}
+		if failed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	table := &output.Table{Headers: []string{"NAME", "PRESENT", "MISSING"}}
+	if missingAllNamespaces {
+		table.Headers = []string{"NAMESPACE", "NAME", "PRESENT", "MISSING"}
+	}
+	for _, object := range objects {
+		var have, lack []string
+		for _, ctx := range clusters {
+			if present[object][ctx] {
+				have = append(have, ctx)
+			} else {
+				lack = append(lack, ctx)
+			}
+		}
+		row := append(strings.Fields(object), strings.Join(have, ","), strings.Join(lack, ","))
+		table.Rows = append(table.Rows, row)
+	}
+
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+	fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/multikubectl/pkg/cluster"
+	"github.com/multikubectl/pkg/output"
+	"github.com/spf13/cobra"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var nsShow bool
+
+var nsCmd = &cobra.Command{
+	Use:   "ns <namespace> | --show",
+	Short: "Set the default namespace of every target context",
+	Long: `Set the default namespace of every target context in the kubeconfig, like
+kubens does for one context.
+
+The namespace is first looked up in every target cluster, and nothing is
+changed unless it exists in all of them. Each modified kubeconfig file is
+copied next to itself first, as with "multikubectl ctx".
+
+With --show, print each target context's current default namespace instead.
+
+Examples:
+  multikubectl ns shop
+  multikubectl --contexts prod-eu,prod-us ns shop
+  multikubectl ns --show`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runNs,
+}
+
+func init() {
+	nsCmd.Flags().BoolVar(&nsShow, "show", false, "Show the default namespace of each target context")
+}
+
+func runNs(cmd *cobra.Command, args []string) {
+	if nsShow {
+		showNamespaces()
+		return
+	}
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Error: specify the namespace, or --show to list the current ones")
+		os.Exit(1)
+	}
+	namespace := args[0]
+
+	sess := newSession()
+	contexts := sess.Contexts()
+
+	// Only contexts defined in a kubeconfig file can be changed
+	var files []string
+	byFile := make(map[string][]string)
+	for _, ctx := range contexts {
+		path, err := sess.Manager().FileOf(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if _, seen := byFile[path]; !seen {
+			files = append(files, path)
+		}
+		byFile[path] = append(byFile[path], ctx)
+	}
+
+	exec := sess.NewExecutor()
+	failed := false
+	for _, r := range sess.runOn(exec, contexts, []string{"get", "namespace", namespace, "-o", "name"}) {
+		if r.Error != nil {
+			failed = true
+			fmt.Fprintf(os.Stderr, "Error from cluster %s: %s\n", r.Context, firstLine(r.Error.Error()))
+		}
+	}
+	if failed {
+		fmt.Fprintf(os.Stderr, "Error: namespace '%s' could not be found in every target cluster; no context was changed\n", namespace)
+		os.Exit(1)
+	}
+
+	for _, path := range files {
+		backup, err := cluster.EditKubeConfig(path, func(kc *clientcmdapi.Config) error {
+			for _, ctx := range byFile[path] {
+				c, ok := kc.Contexts[ctx]
+				if !ok {
+					return fmt.Errorf("context '%s' not found in %s", ctx, path)
+				}
+				c.Namespace = namespace
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, ctx := range byFile[path] {
+			fmt.Printf("Context '%s' now defaults to namespace '%s'\n", ctx, namespace)
+		}
+		fmt.Printf("Backup: %s\n", backup)
+	}
+}
+
+// showNamespaces prints the default namespace of each target context
+func showNamespaces() {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	sess := resolveSession()
+
+	table := &output.Table{Headers: []string{"CLUSTER", "NAMESPACE"}}
+	for _, ctx := range sess.Contexts() {
+		table.Rows = append(table.Rows, []string{ctx, sess.Effective(ctx).Namespace})
+	}
+	rendered, err := sess.newMerger().RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+}
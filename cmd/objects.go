@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Helpers for reading fields out of objects decoded from kubectl's JSON output
+
+// namespacedListArgs builds a "get <resource> -o json" command for one namespace, or all if namespace is empty
+func namespacedListArgs(resource, namespace string) []string {
+	args := []string{"get", resource, "-o", "json"}
+	if namespace != "" {
+		return append(args, "-n", namespace)
+	}
+	return append(args, "-A")
+}
+
+// listItems returns the items of a List object, or nil
+func listItems(obj map[string]interface{}) []map[string]interface{} {
+	raw, _ := obj["items"].([]interface{})
+	items := make([]map[string]interface{}, 0, len(raw))
+	for _, item := range raw {
+		if m, ok := item.(map[string]interface{}); ok {
+			items = append(items, m)
+		}
+	}
+	return items
+}
+
+func objectName(obj map[string]interface{}) string {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	return name
+}
+
+func objectNamespace(obj map[string]interface{}) string {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	namespace, _ := metadata["namespace"].(string)
+	return namespace
+}
+
+// nestedString returns obj[field][key] rendered as a string, or "-" if absent
+func nestedString(obj map[string]interface{}, field, key string) string {
+	m, _ := obj[field].(map[string]interface{})
+	if v, ok := m[key]; ok {
+		return fmt.Sprint(v)
+	}
+	return "-"
+}
+
+// nestedInt returns obj[field][key] as an integer, or 0 if absent
+func nestedInt(obj map[string]interface{}, field, key string) int64 {
+	m, _ := obj[field].(map[string]interface{})
+	n, _ := m[key].(float64)
+	return int64(n)
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/tmux"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var panesSync bool
+
+var panesCmd = &cobra.Command{
+	Use:   "panes [command]",
+	Short: "Open a tmux window with one pane per cluster",
+	Long: `Open a tmux window with a shell per target context, tiled side by side.
+Inside tmux the window is added to the current session; otherwise a new
+session is started and attached.
+
+In each pane kubectl (and any tool reading the kubeconfig) defaults to that
+pane's context, and KUBECONTEXT and MULTIKUBE_ALIAS are set as for "each".
+If a command is given it runs in every pane before the shell starts.
+
+Examples:
+  multikubectl panes
+  multikubectl panes --context-selector env=prod -- kubectl get pods -w
+  multikubectl panes --sync`,
+	Run: runPanes,
+}
+
+func init() {
+	panesCmd.Flags().BoolVar(&panesSync, "sync", false, "Send keystrokes to every pane at once")
+	// Everything after the command name belongs to the command
+	panesCmd.Flags().SetInterspersed(false)
+}
+
+func runPanes(cmd *cobra.Command, args []string) {
+	sess := newSession()
+	command := strings.Join(args, " ")
+
+	// Log into discovered clusters and open tunnels as for a run
+	exec := sess.NewExecutor()
+	cleanup, err := sess.Prepare(exec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+
+	var panes []tmux.Pane
+	for _, ctx := range sess.Contexts() {
+		if slices.Contains(sess.Manager().EphemeralContexts(), ctx) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: ephemeral contexts only exist while multikubectl runs\n", ctx)
+			continue
+		}
+		kubeConfig, err := paneKubeConfig(sess, exec, ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		alias := sess.Config().SettingsFor(ctx).Alias
+		if alias == "" {
+			alias = ctx
+		}
+		panes = append(panes, tmux.Pane{
+			Title:   ctx,
+			Env:     []string{"KUBECONFIG=" + kubeConfig, "KUBECONTEXT=" + ctx, "MULTIKUBE_ALIAS=" + alias},
+			Command: command,
+		})
+	}
+
+	if err := tmux.OpenWindow("multikubectl", panes, panesSync); err != nil {
+		cleanup()
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The tunnels close when multikubectl exits
+	tunneled := 0
+	for _, ctx := range sess.Contexts() {
+		if server, _ := tunnelServer(exec, ctx); server != "" {
+			tunneled++
+		}
+	}
+	if tunneled > 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		fmt.Fprintf(os.Stderr, "Keeping the SSH tunnels of %d pane(s) open, press Ctrl-C to close them\n", tunneled)
+		<-ctx.Done()
+	}
+}
+
+// tunnelServer returns the local server and the TLS server name of the
+// tunnel the executor routes a context through, or "" without a tunnel
+func tunnelServer(exec *executor.Executor, ctx string) (string, string) {
+	var server, serverName string
+	args := exec.ExtraArgs(ctx)
+	for i := 0; i+1 < len(args); i++ {
+		switch args[i] {
+		case "--server":
+			server = args[i+1]
+		case "--tls-server-name":
+			serverName = args[i+1]
+		}
+	}
+	return server, serverName
+}
+
+// paneKubeConfig returns the KUBECONFIG value for a pane of ctx: a file
+// setting only current-context, followed by the kubeconfig kubectl reads
+// ctx from, e.g. a discovery provider's. kubectl takes the current context
+// from the first file that sets one, so the pane defaults to ctx without
+// copying any credentials. For a tunneled context the file also defines the
+// context's cluster with the tunnel's server, which takes precedence over
+// the original definition.
+func paneKubeConfig(sess *session, exec *executor.Executor, ctx string) (string, error) {
+	dir := filepath.Join(config.GetConfigDir(), "panes")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create panes directory: %w", err)
+	}
+
+	pinnedConfig := clientcmdapi.NewConfig()
+	pinnedConfig.CurrentContext = ctx
+	if server, serverName := tunnelServer(exec, ctx); server != "" {
+		name, cluster, err := sess.Manager().ClusterWithServer(ctx, server, serverName)
+		if err != nil {
+			return "", err
+		}
+		pinnedConfig.Clusters[name] = cluster
+	}
+	data, err := clientcmd.Write(*pinnedConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the kubeconfig of %s: %w", ctx, err)
+	}
+
+	// ':' would split KUBECONFIG, '/' the path
+	name := strings.NewReplacer("/", "_", ":", "_").Replace(ctx) + ".yaml"
+	pinned := filepath.Join(dir, name)
+	if err := os.WriteFile(pinned, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", pinned, err)
+	}
+
+	base := exec.KubeConfigFor(ctx)
+	if base == "" {
+		base = strings.Join(sess.Manager().GetKubeConfigPaths(), string(filepath.ListSeparator))
+	}
+	return pinned + string(filepath.ListSeparator) + base, nil
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// printPlan prints the command each target cluster would run for args,
+// with the environment and stdin set for it, instead of running it
+func printPlan(sess *session, exec *executor.Executor, contexts []string, args []string) {
+	for i, p := range sess.Plan(exec, contexts, args) {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("# %s\n", p.Context)
+		if tunnel := sess.Effective(p.Context).Tunnel; tunnel != "" {
+			fmt.Printf("# API server reached through an SSH tunnel via %s\n", tunnel)
+		}
+		for _, env := range p.Env {
+			fmt.Printf("export %s\n", env)
+		}
+		if p.Stdin != nil {
+			delimiter := heredocDelimiter(p.Stdin)
+			fmt.Printf("%s <<'%s'\n%s", p, delimiter, p.Stdin)
+			if len(p.Stdin) > 0 && p.Stdin[len(p.Stdin)-1] != '\n' {
+				fmt.Println()
+			}
+			fmt.Println(delimiter)
+			continue
+		}
+		fmt.Println(p)
+	}
+}
+
+// heredocDelimiter returns a here-document delimiter that is not a line of data
+func heredocDelimiter(data []byte) string {
+	lines := strings.Split(string(data), "\n")
+	delimiter := "EOF"
+	for i := 2; slices.Contains(lines, delimiter); i++ {
+		delimiter = fmt.Sprintf("EOF%d", i)
+	}
+	return delimiter
+}
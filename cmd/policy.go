@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"golang.org/x/term"
+)
+
+// confirmPolicy asks on the terminal whether to run a command that a policy
+// rule requires confirmation for. It refuses when stdin is not a terminal.
+func confirmPolicy(message string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+
+	var proceed bool
+	prompt := &survey.Confirm{Message: message + " Continue?", Default: false}
+	if err := survey.AskOne(prompt, &proceed, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)); err != nil {
+		return false
+	}
+	return proceed
+}
+
+// confirmChange asks on the terminal whether to go ahead with a change,
+// unless --yes is set. Exits if the change is declined or stdin is not a
+// terminal.
+func confirmChange(message string) {
+	if assumeYes {
+		return
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintln(os.Stderr, "Error: refusing to continue without confirmation; use --yes")
+		os.Exit(1)
+	}
+
+	var proceed bool
+	prompt := &survey.Confirm{Message: message, Default: false}
+	if err := survey.AskOne(prompt, &proceed, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)); err != nil || !proceed {
+		fmt.Fprintln(os.Stderr, "Cancelled, nothing was changed.")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+)
+
+// pruning reports whether apply args prune objects missing from the manifests
+func pruning(args []string) bool {
+	for _, arg := range args {
+		if arg == "--prune" || arg == "--prune=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// previewPrune runs an apply --prune as a server-side dry run in every
+// target cluster and lists the objects each cluster would prune, then asks
+// for confirmation before the real apply. Exits if the dry run fails in any
+// cluster or the apply is not confirmed.
+func previewPrune(sess *session, exec *executor.Executor, args []string) {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	merger := sess.newMerger()
+
+	results := sess.run(exec, append(append([]string(nil), args...), "--dry-run=server"))
+	table := &output.Table{Headers: []string{"CLUSTER", "WOULD PRUNE"}}
+	failed := false
+	clusters := 0
+	for _, r := range results {
+		if r.Error != nil {
+			failed = true
+			continue
+		}
+		pruned := prunedObjects(r.Output)
+		for _, obj := range pruned {
+			table.Rows = append(table.Rows, []string{r.Context, obj})
+		}
+		if len(pruned) > 0 {
+			clusters++
+		}
+	}
+	if failed {
+		fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+		fmt.Fprintln(os.Stderr, "Error: the prune dry run failed, nothing was applied")
+		os.Exit(1)
+	}
+	if len(table.Rows) == 0 {
+		fmt.Fprintln(os.Stderr, "Dry run: nothing would be pruned.")
+		return
+	}
+
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Dry run: the apply would prune these objects:\n%s\n", rendered)
+	confirmChange(fmt.Sprintf("Apply and prune %d object(s) in %d cluster(s)?", len(table.Rows), clusters))
+}
+
+// prunedObjects returns the objects a kubectl apply output reports as pruned,
+// e.g. "configmap/old" from "configmap/old pruned (server dry run)"
+func prunedObjects(out string) []string {
+	var objects []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[1] == "pruned" {
+			objects = append(objects, fields[0])
+		}
+	}
+	return objects
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/multikubectl/pkg/output"
+	"github.com/multikubectl/pkg/quantity"
+	"github.com/spf13/cobra"
+)
+
+var (
+	quotaNamespace   string
+	quotaThreshold   float64
+	quotaLimitRanges bool
+)
+
+var quotaCmd = &cobra.Command{
+	Use:   "quota",
+	Short: "Report ResourceQuota usage per namespace across clusters",
+	Long: `Aggregate ResourceQuota usage (used/hard) for every namespace in every target
+cluster into one table. Resources at or above --threshold percent utilization
+are marked with "!" and the affected namespaces are listed at the end.
+
+With --limit-ranges, the LimitRange defaults and bounds of each namespace
+are listed as well.
+
+Examples:
+  multikubectl quota
+  multikubectl quota -n payments --threshold 90
+  multikubectl quota --limit-ranges`,
+	Args: cobra.NoArgs,
+	Run:  runQuota,
+}
+
+func init() {
+	quotaCmd.Flags().StringVarP(&quotaNamespace, "namespace", "n", "", "Only report this namespace (default: all namespaces)")
+	quotaCmd.Flags().Float64Var(&quotaThreshold, "threshold", 80, "Utilization percentage at which a quota is highlighted")
+	quotaCmd.Flags().BoolVar(&quotaLimitRanges, "limit-ranges", false, "Also list LimitRange defaults and bounds")
+}
+
+func runQuota(cmd *cobra.Command, args []string) {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sess := newSession()
+	exec := sess.NewExecutor()
+	merger := sess.newMerger()
+
+	objects, results := sess.fetchObjects(exec, namespacedListArgs("resourcequota", quotaNamespace))
+
+	table := &output.Table{Headers: []string{"CLUSTER", "NAMESPACE", "QUOTA", "RESOURCE", "USED", "HARD", "USAGE"}}
+	// hot[namespace] lists "cluster: resource NN%" entries above the threshold
+	hot := make(map[string][]string)
+	for _, r := range results {
+		for _, item := range listItems(objects[r.Context]) {
+			namespace, name := objectNamespace(item), objectName(item)
+			status, _ := item["status"].(map[string]interface{})
+			hard, _ := status["hard"].(map[string]interface{})
+			used, _ := status["used"].(map[string]interface{})
+
+			for _, resource := range sortedKeys(hard) {
+				hardStr, _ := hard[resource].(string)
+				usedStr, _ := used[resource].(string)
+				hardVal, err1 := quantity.Parse(hardStr)
+				usedVal, err2 := quantity.Parse(usedStr)
+
+				usage := "-"
+				if err1 == nil && err2 == nil && hardVal > 0 {
+					pct := usedVal / hardVal * 100
+					usage = fmt.Sprintf("%.0f%%", pct)
+					if pct >= quotaThreshold {
+						usage += " !"
+						hot[namespace] = append(hot[namespace], fmt.Sprintf("%s: %s %.0f%%", r.Context, resource, pct))
+					}
+				}
+				if usedStr == "" {
+					usedStr = "-"
+				}
+				table.Rows = append(table.Rows, []string{r.Context, namespace, name, resource, usedStr, hardStr, usage})
+			}
+		}
+	}
+
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+
+	if len(hot) > 0 {
+		fmt.Printf("\nNamespaces at or above %.0f%% in any cluster:\n", quotaThreshold)
+		namespaces := make([]string, 0, len(hot))
+		for ns := range hot {
+			namespaces = append(namespaces, ns)
+		}
+		sort.Strings(namespaces)
+		for _, ns := range namespaces {
+			fmt.Printf("  %s (%s)\n", ns, strings.Join(hot[ns], ", "))
+		}
+	}
+
+	if quotaLimitRanges {
+		lrObjects, lrResults := sess.fetchObjects(exec, namespacedListArgs("limitrange", quotaNamespace))
+		results = append(results, lrResults...)
+
+		lrTable := &output.Table{Headers: []string{"CLUSTER", "NAMESPACE", "LIMITRANGE", "TYPE", "RESOURCE", "MIN", "MAX", "DEFAULT REQUEST", "DEFAULT LIMIT"}}
+		for _, r := range lrResults {
+			for _, item := range listItems(lrObjects[r.Context]) {
+				spec, _ := item["spec"].(map[string]interface{})
+				limits, _ := spec["limits"].([]interface{})
+				for _, l := range limits {
+					limit, _ := l.(map[string]interface{})
+					limitType, _ := limit["type"].(string)
+					for _, resource := range limitRangeResources(limit) {
+						lrTable.Rows = append(lrTable.Rows, []string{
+							r.Context, objectNamespace(item), objectName(item), limitType, resource,
+							nestedString(limit, "min", resource), nestedString(limit, "max", resource),
+							nestedString(limit, "defaultRequest", resource), nestedString(limit, "default", resource),
+						})
+					}
+				}
+			}
+		}
+
+		rendered, err := merger.RenderTable(lrTable, format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println()
+		fmt.Print(rendered)
+	}
+
+	fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+	for _, r := range results {
+		if r.Error != nil {
+			os.Exit(1)
+		}
+	}
+}
+
+// limitRangeResources returns the resources mentioned in any field of a LimitRange item
+func limitRangeResources(limit map[string]interface{}) []string {
+	seen := make(map[string]interface{})
+	for _, field := range []string{"min", "max", "default", "defaultRequest", "maxLimitRequestRatio"} {
+		if m, ok := limit[field].(map[string]interface{}); ok {
+			for resource := range m {
+				seen[resource] = true
+			}
+		}
+	}
+	return sortedKeys(seen)
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/multikubectl/pkg/audit"
+	"github.com/multikubectl/pkg/diff"
+	"github.com/multikubectl/pkg/history"
+	"github.com/multikubectl/pkg/output"
+	"github.com/multikubectl/pkg/policy"
+	"github.com/spf13/cobra"
+)
+
+var replayBaseline string
+
+var replayCmd = &cobra.Command{
+	Use:   "replay [history-id]",
+	Short: "Re-run a recorded command and diff the output against the recorded run",
+	Long: `Re-execute a command from the run history and print a unified diff per
+cluster between the recorded output and the new output.
+
+The command runs against the recorded contexts unless --contexts,
+--all-contexts or --context-selector pick a different set. Contexts that were
+not part of the recorded run are compared with the recorded output of the
+--baseline context (default: the first recorded context).
+
+Without an id, lists the recorded runs. Ids are also written to the audit log.
+Exits with a non-zero status if any cluster differs or fails.
+
+Examples:
+  multikubectl replay
+  multikubectl replay 3f2a9c1e
+  multikubectl replay 3f2a9c1e --contexts staging-east,staging-west`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runReplay,
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&replayBaseline, "baseline", "", "Recorded context to compare new contexts against (default: first recorded context)")
+}
+
+func runReplay(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		listHistory()
+		return
+	}
+
+	rec, err := history.Load(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if audit.IsRedacted(rec.Args) {
+		fmt.Fprintf(os.Stderr, "Error: recorded run %s passed credentials, which were not recorded; run it again instead\n", rec.ID)
+		os.Exit(1)
+	}
+
+	baseline := replayBaseline
+	if baseline == "" && len(rec.Results) > 0 {
+		baseline = rec.Results[0].Context
+	}
+	if _, ok := rec.Find(baseline); !ok && replayBaseline != "" {
+		fmt.Fprintf(os.Stderr, "Error: context %q is not part of recorded run %s\n", baseline, rec.ID)
+		os.Exit(1)
+	}
+
+	if len(contexts) == 0 && !allContexts && contextSelector == "" {
+		contexts = rec.Contexts
+	}
+
+	sess := newSession()
+	verb, _, _ := policy.ParseArgs(rec.Args)
+	sess.enforceVerbRestrictions(verb)
+	sess.enforcePolicy(rec.Args)
+	exec := sess.NewExecutor()
+	merger := sess.newMerger()
+	results := sess.run(exec, rec.Args)
+
+	fmt.Printf("replay %s: %s (recorded %s)\n", rec.ID, strings.Join(rec.Args, " "), rec.Time.Local().Format(time.RFC3339))
+	failed := false
+	for _, r := range results {
+		if r.Error != nil {
+			failed = true
+			continue
+		}
+
+		recorded, ok := rec.Find(r.Context)
+		aName := "recorded/" + r.Context
+		if !ok {
+			recorded, ok = rec.Find(baseline)
+			aName = "recorded/" + baseline
+		}
+		if !ok {
+			fmt.Printf("\n=== Cluster: %s === (no recorded output)\n", r.Context)
+			failed = true
+			continue
+		}
+		if recorded.OmittedSize > 0 {
+			fmt.Printf("\n=== Cluster: %s === (recorded output of %d bytes was too large to keep)\n", r.Context, recorded.OmittedSize)
+			failed = true
+			continue
+		}
+
+		if recorded.OutputHash != "" {
+			// Secrets are only recorded as hashes, which tell no more than whether they changed
+			if history.HashOutput(r.Output) == recorded.OutputHash {
+				fmt.Printf("\n=== Cluster: %s === (identical)\n", r.Context)
+			} else {
+				failed = true
+				fmt.Printf("\n=== Cluster: %s === (changed; secret output is only recorded as a hash)\n", r.Context)
+			}
+			continue
+		}
+
+		patch := diff.Unified(aName, "replay/"+r.Context, recorded.Output, r.Output, diff.DefaultContextLines)
+		if patch == "" {
+			fmt.Printf("\n=== Cluster: %s === (identical)\n", r.Context)
+			continue
+		}
+		failed = true
+		fmt.Printf("\n=== Cluster: %s === (changed)\n", r.Context)
+		fmt.Print(patch)
+	}
+
+	fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// listHistory prints the recorded runs, newest first
+func listHistory() {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	records, err := history.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(records) == 0 {
+		fmt.Println("No recorded runs")
+		return
+	}
+
+	table := &output.Table{Headers: []string{"ID", "TIME", "CONTEXTS", "FAILED", "COMMAND"}}
+	for _, rec := range records {
+		failures := 0
+		for _, r := range rec.Results {
+			if r.ExitCode != 0 {
+				failures++
+			}
+		}
+		table.Rows = append(table.Rows, []string{
+			rec.ID,
+			rec.Time.Local().Format("2006-01-02 15:04:05"),
+			fmt.Sprint(len(rec.Contexts)),
+			fmt.Sprint(failures),
+			strings.Join(rec.Args, " "),
+		})
+	}
+
+	rendered, err := output.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+}
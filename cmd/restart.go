@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restartNamespace   string
+	restartWaveSize    int
+	restartWaitTimeout time.Duration
+	restartNoWait      bool
+)
+
+// restartPollInterval is how often the workload is re-read while waiting
+const restartPollInterval = 2 * time.Second
+
+var restartCmd = &cobra.Command{
+	Use:   "restart <kind>/<name>",
+	Short: "Restart a workload across clusters and wait for the rollouts to converge",
+	Long: `Run "kubectl rollout restart" on a deployment, statefulset or daemonset in
+every target cluster, then follow each cluster's rollout until it completes,
+fails or --wait-timeout passes. A status line per cluster is shown on stderr
+while waiting, followed by a report of which clusters converged.
+
+With --wave-size, clusters are restarted a few at a time in the configured
+order: each wave is restarted and waited for before the next one starts, and
+the remaining waves are not started if a cluster of a wave did not converge.
+
+Exits with a non-zero status unless every cluster converged.
+
+Examples:
+  multikubectl restart deployment/web -n shop
+  multikubectl restart statefulset/db -n shop --wave-size 1
+  multikubectl restart daemonset/agent -n monitoring --no-wait`,
+	Args: cobra.ExactArgs(1),
+	Run:  runRestart,
+}
+
+func init() {
+	restartCmd.Flags().StringVarP(&restartNamespace, "namespace", "n", "", "Namespace of the workload")
+	restartCmd.Flags().IntVar(&restartWaveSize, "wave-size", 0, "Number of clusters restarted at a time (0 restarts all at once)")
+	restartCmd.Flags().DurationVar(&restartWaitTimeout, "wait-timeout", 5*time.Minute, "How long to wait for each wave's rollouts to converge")
+	restartCmd.Flags().BoolVar(&restartNoWait, "no-wait", false, "Don't wait for the rollouts to converge")
+}
+
+// rolloutState is the progress of a workload's rollout in one cluster
+type rolloutState struct {
+	done   bool
+	failed bool
+	detail string
+}
+
+func runRestart(cmd *cobra.Command, args []string) {
+	if restartWaveSize < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --wave-size must not be negative")
+		os.Exit(1)
+	}
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ref := args[0]
+	restartArgs := []string{"rollout", "restart", ref}
+	getArgs := []string{"get", ref, "-o", "json"}
+	if restartNamespace != "" {
+		restartArgs = append(restartArgs, "-n", restartNamespace)
+		getArgs = append(getArgs, "-n", restartNamespace)
+	}
+
+	sess := newSession()
+	sess.enforceVerbRestrictions("rollout")
+	sess.enforcePolicy(restartArgs)
+	exec := sess.NewExecutor()
+	// Tunnels and logins stay up across the restart and the polling
+	cleanup, err := sess.Prepare(exec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	merger := sess.newMerger()
+
+	waves := splitWaves(sess.Contexts(), restartWaveSize)
+	table := &output.Table{Headers: []string{"CLUSTER", "STATUS", "DETAIL"}}
+	failed := false
+	for i, wave := range waves {
+		if failed {
+			for _, ctx := range wave {
+				table.Rows = append(table.Rows, []string{ctx, "not started", "an earlier wave did not converge"})
+			}
+			continue
+		}
+		if len(waves) > 1 {
+			fmt.Fprintf(os.Stderr, "Wave %d/%d: %s\n", i+1, len(waves), strings.Join(wave, ", "))
+		}
+
+		var restarted []string
+		for _, r := range sess.runOn(exec, wave, restartArgs) {
+			if r.Error != nil {
+				failed = true
+				table.Rows = append(table.Rows, []string{r.Context, "restart failed", firstLine(r.Error.Error())})
+				continue
+			}
+			restarted = append(restarted, r.Context)
+		}
+		if restartNoWait {
+			for _, ctx := range restarted {
+				table.Rows = append(table.Rows, []string{ctx, "restarted", "-"})
+			}
+			continue
+		}
+
+		states := waitRollouts(exec, restarted, getArgs)
+		for _, ctx := range restarted {
+			state := states[ctx]
+			status := "converged"
+			if !state.done {
+				failed = true
+				status = "not converged"
+			}
+			table.Rows = append(table.Rows, []string{ctx, status, state.detail})
+		}
+	}
+	cleanup()
+
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// splitWaves splits contexts into waves of size contexts (one wave if size is 0)
+func splitWaves(contexts []string, size int) [][]string {
+	if size == 0 || size >= len(contexts) {
+		return [][]string{contexts}
+	}
+	var waves [][]string
+	for start := 0; start < len(contexts); start += size {
+		waves = append(waves, contexts[start:min(start+size, len(contexts))])
+	}
+	return waves
+}
+
+// waitRollouts re-reads the workload in contexts until every rollout has
+// completed or failed, or --wait-timeout passes. The polling is not recorded
+// in the audit log or history.
+func waitRollouts(exec *executor.Executor, contexts []string, getArgs []string) map[string]rolloutState {
+	states := make(map[string]rolloutState)
+	for _, ctx := range contexts {
+		states[ctx] = rolloutState{detail: "waiting"}
+	}
+	board := newStatusBoard(contexts)
+	deadline := time.Now().Add(restartWaitTimeout)
+	polled := false
+	for {
+		var pending []string
+		for _, ctx := range contexts {
+			if s := states[ctx]; !s.done && !s.failed {
+				pending = append(pending, ctx)
+			}
+		}
+		if len(pending) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			for _, ctx := range pending {
+				s := states[ctx]
+				s.detail = fmt.Sprintf("timed out after %s: %s", restartWaitTimeout, s.detail)
+				states[ctx] = s
+			}
+			break
+		}
+
+		if polled {
+			time.Sleep(min(restartPollInterval, time.Until(deadline)))
+		}
+		polled = true
+		for _, r := range exec.Execute(pending, getArgs) {
+			// Errors are retried until the deadline, the cluster may be briefly unreachable
+			if r.Error != nil {
+				states[r.Context] = rolloutState{detail: "error: " + firstLine(r.Error.Error())}
+				continue
+			}
+			var obj map[string]interface{}
+			if err := json.Unmarshal([]byte(r.Output), &obj); err != nil {
+				states[r.Context] = rolloutState{failed: true, detail: fmt.Sprintf("invalid output: %v", err)}
+				continue
+			}
+			states[r.Context] = workloadRollout(obj)
+		}
+		lines := make(map[string]string)
+		for ctx, state := range states {
+			lines[ctx] = rolloutLine(state)
+		}
+		board.update(lines)
+	}
+	return states
+}
+
+// workloadRollout computes the rollout state of a deployment, statefulset or
+// daemonset from its status, following the checks of "kubectl rollout status"
+func workloadRollout(obj map[string]interface{}) rolloutState {
+	if nestedInt(obj, "status", "observedGeneration") < nestedInt(obj, "metadata", "generation") {
+		return rolloutState{detail: "waiting for the controller to observe the restart"}
+	}
+	replicas := int64(1)
+	if spec, _ := obj["spec"].(map[string]interface{}); spec != nil {
+		if n, ok := spec["replicas"].(float64); ok {
+			replicas = int64(n)
+		}
+	}
+
+	switch kind, _ := obj["kind"].(string); kind {
+	case "Deployment":
+		if deploymentDeadlineExceeded(obj) {
+			return rolloutState{failed: true, detail: "progress deadline exceeded"}
+		}
+		updated := nestedInt(obj, "status", "updatedReplicas")
+		total := nestedInt(obj, "status", "replicas")
+		available := nestedInt(obj, "status", "availableReplicas")
+		return rolloutState{
+			done:   updated >= replicas && total == updated && available >= updated,
+			detail: fmt.Sprintf("%d/%d updated, %d/%d available", updated, replicas, available, replicas),
+		}
+	case "StatefulSet":
+		updated := nestedInt(obj, "status", "updatedReplicas")
+		ready := nestedInt(obj, "status", "readyReplicas")
+		return rolloutState{
+			done:   ready >= replicas && nestedString(obj, "status", "updateRevision") == nestedString(obj, "status", "currentRevision"),
+			detail: fmt.Sprintf("%d/%d updated, %d/%d ready", updated, replicas, ready, replicas),
+		}
+	case "DaemonSet":
+		desired := nestedInt(obj, "status", "desiredNumberScheduled")
+		updated := nestedInt(obj, "status", "updatedNumberScheduled")
+		available := nestedInt(obj, "status", "numberAvailable")
+		return rolloutState{
+			done:   updated >= desired && available >= desired,
+			detail: fmt.Sprintf("%d/%d updated, %d/%d available", updated, desired, available, desired),
+		}
+	default:
+		return rolloutState{failed: true, detail: fmt.Sprintf("cannot follow the rollout of a %s", kind)}
+	}
+}
+
+// deploymentDeadlineExceeded reports whether a deployment's Progressing
+// condition says its progress deadline passed
+func deploymentDeadlineExceeded(obj map[string]interface{}) bool {
+	status, _ := obj["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+	for _, c := range conditions {
+		condition, _ := c.(map[string]interface{})
+		if condition["type"] == "Progressing" && condition["reason"] == "ProgressDeadlineExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
+// rolloutLine describes a rollout state on the status board
+func rolloutLine(state rolloutState) string {
+	switch {
+	case state.done:
+		return "converged (" + state.detail + ")"
+	case state.failed:
+		return "failed: " + state.detail
+	}
+	return state.detail
+}
@@ -1,24 +1,97 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
+	"text/template"
 	"time"
+	"unicode"
 
-	"github.com/multikubectl/pkg/cluster"
+	"github.com/multikubectl/pkg/breaker"
 	"github.com/multikubectl/pkg/config"
 	"github.com/multikubectl/pkg/executor"
 	"github.com/multikubectl/pkg/output"
+	"github.com/multikubectl/pkg/policy"
+	"github.com/multikubectl/pkg/runner"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	kubeConfig      string
-	contexts        []string
-	allContexts     bool
-	timeout         time.Duration
-	nonTableCommands = []string{"logs", "describe", "explain", "edit", "exec", "attach", "port-forward", "proxy", "cp"}
+	kubeConfig       string
+	clusterSpecs     []string
+	clustersFile     string
+	contexts         []string
+	allContexts      bool
+	currentOnly      bool
+	contextSelector  string
+	forceTargets     bool
+	timeout          time.Duration
+	includeFailing   bool
+	breakerThreshold int
+	serial           bool
+	firstSuccess     bool
+	planOnly         bool
+	stepMode         bool
+	failover         bool
+	serialDelay      time.Duration
+	order            string
+	quiet            bool
+	grepPattern      string
+	fieldFilters     []string
+	query            string
+	compareColumns   string
+	pivot            bool
+	matrixColumn     string
+	countRows        bool
+	aggregate        []string
+	tableFormat      string
+	outputTemplate   string
+	noTruncate       bool
+	noColor          bool
+	noProgress       bool
+	heartbeatEvery   string
+	showSummary      bool
+	showStderr       bool
+	hideWarnings     bool
+	checkNamespace   string
+	checkExists      string
+	verify           bool
+	assumeYes        bool
+	confirmDelete    bool
+	overlayDir       string
+	nameFormat       string
+	bannerFormat     string
+	prefixFormat     string
+	colorLevels      bool
+	dedupeWindow     time.Duration
+	logDir           string
+	logMaxSize       int64
+	eventTypes       []string
+	eventReasons     []string
+	valuesPath       string
+	qps              float64
+	burst            int
+	asUser           string
+	asGroups         []string
+	recordDir        string
+	replayDir        string
+	nonTableCommands = []string{"logs", "describe", "explain", "exec", "port-forward", "proxy"}
+	// defaultHeartbeat is the heartbeat interval of long-running commands in logs
+	defaultHeartbeat = time.Minute
+	// compareAll is the --compare value comparing every column
+	compareAll = "*"
+	// matrixPresence is the --matrix value showing only whether objects exist
+	matrixPresence = "*"
+	// unplannedCommands run interactively or several kubectl commands per
+	// cluster, which --plan can't show and --step can't gate
+	unplannedCommands = []string{"attach", "cp", "edit", "wait"}
 )
 
 // rootCmd represents the base command
@@ -47,33 +120,102 @@ Examples:
 }
 
 func init() {
-	rootCmd.Flags().StringVar(&kubeConfig, "kubeconfig", "", "Path to the kubeconfig file")
-	rootCmd.Flags().StringSliceVar(&contexts, "contexts", nil, "Comma-separated list of contexts to use (overrides config)")
-	rootCmd.Flags().BoolVar(&allContexts, "all-contexts", false, "Use all available contexts (ignores config)")
-	rootCmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Timeout for kubectl commands")
+	rootCmd.PersistentFlags().StringVar(&kubeConfig, "kubeconfig", "", "Path to the kubeconfig file")
+	rootCmd.PersistentFlags().BoolVar(&forceTargets, "force-targets", false, "Ignore the verbRestrictions in the config")
+	rootCmd.PersistentFlags().StringArrayVar(&clusterSpecs, "extra-cluster", nil, "Target a cluster not in the kubeconfig: name=https://server[,token-file=file][,certificate-authority=file][,insecure-skip-tls-verify=true] (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&clustersFile, "clusters-file", "", "YAML file listing extra clusters to target (like --extra-cluster)")
+	rootCmd.PersistentFlags().StringSliceVar(&contexts, "contexts", nil, "Comma-separated list of contexts or groups to use (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&contextSelector, "context-selector", "", "Only target contexts whose config labels match this selector, e.g. env=prod,region!=eu")
+	rootCmd.PersistentFlags().BoolVar(&allContexts, "all-contexts", false, "Use all available contexts (ignores config)")
+	rootCmd.PersistentFlags().BoolVar(&currentOnly, "current-only", false, "Only use the kubeconfig's current-context and print kubectl's output as-is (--contexts . keeps the merged output)")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", runner.DefaultTimeout, "Timeout for kubectl commands (default: the config's timeout for the verb, or 30s)")
+	rootCmd.PersistentFlags().BoolVar(&includeFailing, "include-failing", false, "Include contexts skipped by the circuit breaker after repeated failures")
+	rootCmd.PersistentFlags().BoolVar(&serial, "serial", false, "Run contexts one at a time in the configured order instead of in parallel")
+	rootCmd.PersistentFlags().BoolVar(&firstSuccess, "first-success", false, "Stop at the first cluster where the command succeeds and print only its output")
+	rootCmd.PersistentFlags().BoolVar(&planOnly, "plan", false, "Print the kubectl command line each cluster would run, with its environment, instead of running it")
+	rootCmd.PersistentFlags().BoolVar(&stepMode, "step", false, "Run one cluster at a time, showing its command and asking whether to run it, skip the cluster or abort")
+	rootCmd.PersistentFlags().BoolVar(&failover, "failover", false, "Run only on primary contexts, and on a primary's configured secondary when it can't be reached")
+	rootCmd.PersistentFlags().DurationVar(&serialDelay, "serial-delay", 0, "Delay between contexts in serial mode")
+	rootCmd.PersistentFlags().StringVar(&order, "order", string(output.OrderConfig), "Order of cluster results: name, config, or duration (fastest first)")
+	rootCmd.PersistentFlags().BoolVar(&quiet, "quiet", false, "Only print data lines: no headers, banners, or inline errors (errors go to stderr); after exec, run or attach it goes to kubectl")
+	rootCmd.PersistentFlags().StringVar(&grepPattern, "grep", "", "Only show rows matching this regular expression (keeps the merged header)")
+	rootCmd.PersistentFlags().StringSliceVar(&fieldFilters, "field-filter", nil, "Only show rows matching column expressions, e.g. STATUS!=Running,CLUSTER=prod")
+	rootCmd.PersistentFlags().StringVar(&query, "query", "", "jq expression applied to the merged JSON result (implies -o json)")
+	rootCmd.PersistentFlags().StringVar(&compareColumns, "compare", "", "Show each object as one row with a column per cluster holding its values of these columns (all but NAME and AGE if given without a value), e.g. --compare=READY")
+	rootCmd.PersistentFlags().Lookup("compare").NoOptDefVal = compareAll
+	rootCmd.PersistentFlags().StringVar(&matrixColumn, "matrix", "", "Show each object as one row with a column per cluster marking whether it exists there (✓/✗), or holding its value of this column, e.g. --matrix=STATUS")
+	rootCmd.PersistentFlags().Lookup("matrix").NoOptDefVal = matrixPresence
+	rootCmd.PersistentFlags().BoolVar(&pivot, "pivot", false, "Print output holding one value per cluster, e.g. from -o jsonpath, as a single row with a column per cluster")
+	rootCmd.PersistentFlags().StringVar(&tableFormat, "output-format", string(output.FormatTable), "Format for merged table output: table, csv, tsv, markdown, html, or jsonl")
+	rootCmd.PersistentFlags().StringVar(&outputTemplate, "output-template", "", "Render results with this Go template file instead of the merged output")
+	rootCmd.PersistentFlags().BoolVar(&noTruncate, "no-truncate", false, "Don't truncate long cells to fit the terminal width")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also honors the NO_COLOR environment variable)")
+	rootCmd.PersistentFlags().BoolVar(&noProgress, "no-progress", false, "Don't show the progress indicator on stderr while clusters are executing")
+	rootCmd.PersistentFlags().StringSliceVar(&aggregate, "aggregate", nil, "Append TOTAL and/or AVG rows summing or averaging the numeric columns of the merged table (READY, RESTARTS, CPU, MEMORY...): sum, avg, or both (default sum)")
+	rootCmd.PersistentFlags().Lookup("aggregate").NoOptDefVal = output.AggregateSum
+	rootCmd.PersistentFlags().StringVar(&heartbeatEvery, "heartbeat", "", "Print a line per cluster still running at this interval, e.g. 30s (default: the config's heartbeat, or 1m for wait, rollout status, apply and drain when stderr is not a terminal; 0 disables)")
+	rootCmd.PersistentFlags().BoolVar(&countRows, "count", false, "After get, print the number of objects per cluster and their total to stderr")
+	rootCmd.PersistentFlags().BoolVar(&showSummary, "summary", false, "Print a per-cluster status and duration summary to stderr")
+	rootCmd.PersistentFlags().BoolVar(&showStderr, "show-stderr", false, "Show stderr from clusters that succeeded, prefixed with the cluster name")
+	rootCmd.PersistentFlags().BoolVar(&hideWarnings, "hide-warnings", false, "Hide kubectl warnings (deprecation notices, throttling messages)")
+	rootCmd.PersistentFlags().StringVar(&checkNamespace, "check-namespace", "", "Check that the -n namespace exists in each cluster first: warn, or skip clusters without it")
+	rootCmd.PersistentFlags().Lookup("check-namespace").NoOptDefVal = runner.NamespaceWarn
+	rootCmd.PersistentFlags().StringVar(&checkExists, "check-exists", "", "Before patch, scale, label, ... check that the named objects exist in each cluster: warn, or skip clusters without them")
+	rootCmd.PersistentFlags().Lookup("check-exists").NoOptDefVal = runner.ExistsWarn
+	rootCmd.PersistentFlags().BoolVar(&verify, "verify", false, "After label or annotate, re-read the objects and check each cluster has the new values")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Don't ask for confirmation before changes that need it (drain, apply --prune, --confirm-delete)")
+	rootCmd.PersistentFlags().StringVar(&bannerFormat, "banner", "", "Go template of the banner above each cluster's logs, describe, ... output, with .Context, .Alias and .Error")
+	rootCmd.PersistentFlags().StringVar(&prefixFormat, "line-prefix", "", "Go template prefixed to each line of logs, describe, ... output instead of banners, e.g. '[{{.Alias}}] '")
+	rootCmd.PersistentFlags().BoolVar(&colorLevels, "color-levels", false, "Color error and warning lines of prefixed and interleaved logs")
+	rootCmd.PersistentFlags().DurationVar(&dedupeWindow, "dedupe", 0, "Collapse identical lines of interleaved logs from different clusters within this window (1s if given without a value)")
+	rootCmd.PersistentFlags().Lookup("dedupe").NoOptDefVal = "1s"
+	rootCmd.PersistentFlags().StringVar(&logDir, "log-dir", "", "With logs -f, also write each cluster's lines to <dir>/<context>.log")
+	rootCmd.PersistentFlags().Int64Var(&logMaxSize, "log-max-size", 100, "Size in MB at which --log-dir files are rotated (0 disables rotation)")
+	rootCmd.PersistentFlags().StringSliceVar(&eventTypes, "event-type", nil, "With events --watch, only show events of these types, e.g. Warning")
+	rootCmd.PersistentFlags().StringSliceVar(&eventReasons, "event-reason", nil, "With events --watch, only show events with these reasons, e.g. BackOff,FailedScheduling")
+	rootCmd.PersistentFlags().StringVar(&nameFormat, "name-format", output.DefaultNameFormat, "Format of merged -o name output, with {context}, {namespace}, {kind} and {name}")
+	rootCmd.PersistentFlags().StringVar(&overlayDir, "overlay-dir", "", "With apply -k, build each cluster from the kustomization in <dir>/<context> when present")
+	rootCmd.PersistentFlags().StringVar(&valuesPath, "values", "", "With apply -f, render the manifests as Go templates with each cluster's values: <dir>/<context>.yaml, or a path with {context}")
+	rootCmd.PersistentFlags().BoolVar(&confirmDelete, "confirm-delete", false, "Before delete, list the objects it would remove in each cluster and ask for confirmation")
+	rootCmd.PersistentFlags().Float64Var(&qps, "qps", 0, "Maximum kubectl requests per second per context (0 means unlimited, overrides config)")
+	rootCmd.PersistentFlags().IntVar(&burst, "burst", 0, "Maximum burst of kubectl requests per context (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&asUser, "as", "", "Username to impersonate in every context (per-context config overrides)")
+	rootCmd.PersistentFlags().StringArrayVar(&asGroups, "as-group", nil, "Group to impersonate in every context, repeatable (per-context config overrides)")
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record-fixtures", "", "Save each cluster's raw output as fixtures in this directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "Serve results from fixtures in this directory instead of querying clusters")
+	rootCmd.PersistentFlags().IntVar(&breakerThreshold, "breaker-threshold", breaker.DefaultThreshold, "Consecutive failures before a context is skipped (0 disables)")
 
 	// Allow unknown flags to pass through to kubectl
 	rootCmd.FParseErrWhitelist.UnknownFlags = true
 
-	// Add config subcommand
+	// Add subcommands
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(ctxCmd)
+	rootCmd.AddCommand(nsCmd)
+	rootCmd.AddCommand(driftCmd)
+	rootCmd.AddCommand(secretDiffCmd)
+	rootCmd.AddCommand(cmDiffCmd)
+	rootCmd.AddCommand(countCmd)
+	rootCmd.AddCommand(missingCmd)
+	rootCmd.AddCommand(quotaCmd)
+	rootCmd.AddCommand(capacityCmd)
+	rootCmd.AddCommand(infoCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(inventoryCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(eachCmd)
+	rootCmd.AddCommand(panesCmd)
+	rootCmd.AddCommand(shellCmd)
+	rootCmd.AddCommand(benchCmd)
+	rootCmd.AddCommand(healthCmd)
+	rootCmd.AddCommand(restartCmd)
+	rootCmd.AddCommand(drainCmd)
+	rootCmd.AddCommand(execAllCmd)
 }
 
 func Execute() {
 	args := os.Args[1:]
 
-	// Check if first arg is a subcommand (like "config") or help
-	if len(args) > 0 {
-		switch args[0] {
-		case "config", "help", "completion", "--help", "-h":
-			// Let cobra handle subcommands normally
-			if err := rootCmd.Execute(); err != nil {
-				os.Exit(1)
-			}
-			return
-		}
-	}
-
 	// For kubectl passthrough commands, handle manually
 	if len(args) == 0 {
 		rootCmd.Help()
@@ -81,7 +223,20 @@ func Execute() {
 	}
 
 	// Separate our flags from kubectl flags
-	ourArgs, kubectlArgs := separateArgs(args)
+	ourArgs, kubectlArgs, err := separateArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Check if the command is a subcommand (like "config") or help
+	if len(kubectlArgs) > 0 && isSubcommand(kubectlArgs[0]) {
+		// Let cobra handle subcommands normally
+		if err := rootCmd.Execute(); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Parse our flags manually
 	if err := rootCmd.ParseFlags(ourArgs); err != nil {
@@ -93,42 +248,102 @@ func Execute() {
 	runMultiKubectl(rootCmd, kubectlArgs)
 }
 
-// separateArgs separates multikubectl-specific flags from kubectl flags
-func separateArgs(args []string) (ourArgs []string, kubectlArgs []string) {
-	ourFlags := map[string]bool{
-		"--kubeconfig":   true,
-		"--contexts":     true,
-		"--all-contexts": true,
-		"--timeout":      true,
+// isSubcommand reports whether name is handled by a multikubectl subcommand instead of kubectl
+func isSubcommand(name string) bool {
+	switch name {
+	case "help", "completion", "--help", "-h":
+		return true
 	}
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
 
+// optionalValues recognizes the values of flags that also work without one.
+// pflag only takes their value after '=', so such a value given as the next
+// argument would silently go to kubectl instead.
+var optionalValues = map[string]func(string) bool{
+	"check-namespace": func(v string) bool { return v == runner.NamespaceWarn || v == runner.NamespaceSkip },
+	"check-exists":    func(v string) bool { return v == runner.ExistsWarn || v == runner.ExistsSkip },
+	"dedupe": func(v string) bool {
+		_, err := time.ParseDuration(v)
+		return err == nil
+	},
+	"compare": isColumnList,
+	"matrix":  isColumnList,
+}
+
+// isColumnList reports whether v looks like table column names, e.g.
+// READY,STATUS, rather than a kubectl resource or object name
+func isColumnList(v string) bool {
+	return v != "" && strings.ToUpper(v) == v && strings.ContainsFunc(v, unicode.IsLetter) &&
+		!strings.ContainsFunc(v, func(r rune) bool { return r == '/' || r == '.' || unicode.IsSpace(r) })
+}
+
+// separateArgs separates multikubectl-specific flags from kubectl flags
+func separateArgs(args []string) (ourArgs []string, kubectlArgs []string, err error) {
 	i := 0
 	for i < len(args) {
 		arg := args[i]
 
-		// Check if it's one of our flags
-		isOurFlag := false
-		for flag := range ourFlags {
-			if arg == flag || strings.HasPrefix(arg, flag+"=") {
-				isOurFlag = true
-				break
-			}
+		// Everything after "--" belongs to kubectl (e.g. exec commands)
+		if arg == "--" {
+			kubectlArgs = append(kubectlArgs, args[i:]...)
+			break
 		}
 
-		if isOurFlag {
+		// Check if it's one of our flags
+		if isKubectlQuiet(arg, kubectlArgs) {
+			kubectlArgs = append(kubectlArgs, arg)
+		} else if flag := lookupOurFlag(arg); flag != nil {
 			ourArgs = append(ourArgs, arg)
-			// If it doesn't contain '=', the next arg is the value
-			if !strings.Contains(arg, "=") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			// If it doesn't contain '=' and the flag takes a value, the next arg is the value
+			takesValue := flag.NoOptDefVal == ""
+			if takesValue && !strings.Contains(arg, "=") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
 				i++
 				ourArgs = append(ourArgs, args[i])
 			}
+			if isValue, ok := optionalValues[flag.Name]; ok && arg == "--"+flag.Name && i+1 < len(args) && isValue(args[i+1]) {
+				return nil, nil, fmt.Errorf("%s takes its value after '=': use %s=%s", arg, arg, args[i+1])
+			}
 		} else {
 			kubectlArgs = append(kubectlArgs, arg)
 		}
 		i++
 	}
 
-	return ourArgs, kubectlArgs
+	return ourArgs, kubectlArgs, nil
+}
+
+// quietVerbs are the kubectl verbs with a --quiet flag of their own
+var quietVerbs = []string{"exec", "run", "attach"}
+
+// isKubectlQuiet reports whether arg is kubectl's --quiet, given after one of
+// quietVerbs in kubectlArgs. Before the verb, --quiet is ours.
+func isKubectlQuiet(arg string, kubectlArgs []string) bool {
+	if arg != "--quiet" && !strings.HasPrefix(arg, "--quiet=") {
+		return false
+	}
+	positional := policy.Positional(kubectlArgs)
+	return len(positional) > 0 && slices.Contains(quietVerbs, positional[0])
+}
+
+// lookupOurFlag returns the multikubectl flag matching arg, or nil if arg belongs to kubectl
+func lookupOurFlag(arg string) *pflag.Flag {
+	if len(arg) == 2 && arg[0] == '-' && arg[1] != '-' {
+		return rootCmd.PersistentFlags().ShorthandLookup(arg[1:])
+	}
+	if !strings.HasPrefix(arg, "--") {
+		return nil
+	}
+	name := strings.TrimPrefix(arg, "--")
+	if idx := strings.Index(name, "="); idx >= 0 {
+		name = name[:idx]
+	}
+	return rootCmd.PersistentFlags().Lookup(name)
 }
 
 func runMultiKubectl(cmd *cobra.Command, args []string) {
@@ -136,54 +351,349 @@ func runMultiKubectl(cmd *cobra.Command, args []string) {
 		cmd.Help()
 		return
 	}
+	// Everything below finds the verb first, also when kubectl flags precede it
+	args = policy.VerbFirst(args)
 
-	// Initialize cluster manager
-	mgr, err := cluster.NewManager(kubeConfig)
+	settings, err := parseOutputSettings()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading kubeconfig: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	args, err = queryArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	args, settings.names = nameArgs(args)
+	if err := settings.checkTableModes(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Determine which contexts to use
-	// Priority: 1. --contexts flag  2. --all-contexts flag  3. ~/.multikube/config  4. all contexts
-	var targetContexts []string
-
-	if len(contexts) > 0 {
-		// Command line --contexts takes highest priority
-		targetContexts = mgr.FilterContexts(contexts)
-	} else if allContexts {
-		// --all-contexts flag ignores config file
-		targetContexts = mgr.GetContexts()
-	} else if config.Exists() {
-		// Use ~/.multikube/config if it exists
-		cfg, err := config.Load()
+	sess := newSession()
+	sess.enforceVerbRestrictions(args[0])
+	sess.enforcePolicy(args)
+	exec := sess.NewExecutor()
+	if configured, ok := sess.TimeoutFor(args); ok && !cmd.Flags().Changed("timeout") {
+		timeout = configured
+		exec.SetTimeout(timeout)
+	}
+	targets := sess.Contexts()
+	args = logLimitArgs(sess.Config(), args)
+	if readsStdin(args) {
+		if err := pipeStdin(sess, exec); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if (planOnly || stepMode) && slices.Contains(unplannedCommands, args[0]) {
+		fmt.Fprintf(os.Stderr, "Error: --plan and --step don't support %s\n", args[0])
+		os.Exit(1)
+	}
+	if stepMode && (firstSuccess || (args[0] == "logs" && boolFlag(args, "-f", "--follow")) || boolFlag(args, "-w", "--watch", "--watch-only")) {
+		fmt.Fprintln(os.Stderr, "Error: --step can't be combined with --first-success or commands that keep running (-f, -w)")
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "cp":
+		runCopy(sess, exec, args)
+		return
+	case "attach":
+		runAttach(sess, exec, args)
+		return
+	case "edit":
+		runEdit(sess, exec, args)
+		return
+	case "apply":
+		var rendered []string
+		switch {
+		case overlayDir != "" && valuesPath != "":
+			err = fmt.Errorf("--overlay-dir and --values can't be combined")
+		case overlayDir != "":
+			rendered, err = renderOverlays(sess, exec, args)
+		case valuesPath != "":
+			rendered, err = renderValues(sess, exec, args)
+		default:
+			rendered, err = decryptManifests(sess, exec, args)
+		}
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading multikube config: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-		if len(cfg.Contexts) > 0 {
-			targetContexts = mgr.FilterContexts(cfg.Contexts)
-		} else {
-			targetContexts = mgr.GetContexts()
+		if rendered != nil {
+			args = rendered
+		}
+		if pruning(args) && !dryRun(args) && !planOnly {
+			previewPrune(sess, exec, args)
+		}
+	case "delete":
+		if confirmDelete && !dryRun(args) && !planOnly {
+			targets = previewDelete(sess, exec, args)
+		}
+	case "wait":
+		runWait(sess, exec, args)
+		return
+	case "logs":
+		if dedupeWindow > 0 && !logTimestamps(args) {
+			fmt.Fprintln(os.Stderr, "Error: --dedupe needs logs --timestamps to tell when lines were logged")
+			os.Exit(1)
+		}
+		if dedupeWindow > 0 && settings.streaming() {
+			fmt.Fprintln(os.Stderr, "Error: --dedupe can't be combined with --output-format=jsonl")
+			os.Exit(1)
+		}
+		if logDir != "" && !boolFlag(args, "-f", "--follow") {
+			fmt.Fprintln(os.Stderr, "Error: --log-dir needs logs -f")
+			os.Exit(1)
+		}
+		if boolFlag(args, "-f", "--follow") && !planOnly {
+			runFollowLogs(cmd, sess, exec, args, settings)
+			return
+		}
+	case "exec":
+		args, targets, err = resolveExecPods(sess, exec, args, targets)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "get":
+		if watchesTable(args) && !planOnly {
+			runWatchGet(cmd, sess, exec, args, settings)
+			return
+		}
+	case "events":
+		if boolFlag(args, "-w", "--watch") && !planOnly {
+			runWatchEvents(cmd, sess, exec, args, settings)
+			return
+		}
+	}
+	if planOnly {
+		printPlan(sess, exec, targets, args)
+		return
+	}
+	merger := sess.newMerger()
+	merger.SetFilter(settings.filter)
+	merger.SetAllNamespaces(allNamespaces(args))
+	merger.SetHeaderMode(headerMode(args))
+
+	// JSON Lines are written as each cluster completes instead of merged at
+	// the end, and the lines of watches as they arrive
+	streaming := settings.streaming()
+	if streaming {
+		jsonl := merger.NewJSONLWriter(os.Stdout)
+		exec.SetResultCallback(jsonl.Write)
+		if boolFlag(args, "-w", "--watch", "--watch-only") {
+			exec.SetLineCallback(jsonl.Line)
 		}
-	} else {
-		// Default: use all contexts
-		targetContexts = mgr.GetContexts()
 	}
 
-	if len(targetContexts) == 0 {
-		fmt.Fprintln(os.Stderr, "No valid contexts found")
+	// Outputs merged one object at a time are kept in files rather than in
+	// memory, however large they are
+	spoolDir := ""
+	if settings.spooling(args) {
+		if spoolDir, err = os.MkdirTemp("", "multikubectl-"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		exec.SetSpoolDir(spoolDir)
+	}
+	removeSpool := removeOnExit(spoolDir)
+	defer removeSpool()
+
+	var results []executor.Result
+	aborted := false
+	switch {
+	case firstSuccess:
+		results = runFirstSuccess(sess, exec, targets, args)
+	case stepMode:
+		results, aborted = runSteps(sess, exec, targets, args)
+	default:
+		results = sess.runOn(exec, targets, args)
+	}
+	if currentOnly {
+		os.Exit(printPlain(results))
+	}
+	ok := printResults(merger, settings, args, results, streaming) && !aborted
+	removeSpool()
+	if _, verifiable := verifyVerbs[args[0]]; verify && verifiable {
+		ok = verifyMetadata(sess, exec, merger, settings.format, args, results) && ok
+	}
+	if !ok {
 		os.Exit(1)
 	}
+}
+
+// outputSettings are the merged output options parsed from the global flags
+type outputSettings struct {
+	order  output.Order
+	filter *output.RowFilter
+	format output.Format
+	tmpl   *template.Template
+	// names is set for -o name, merged with --name-format
+	names bool
+	// compare is set for --compare, with the columns compared (nil for all)
+	compare        bool
+	compareColumns []string
+	// matrix is set for --matrix, with the column shown ("" for presence only)
+	matrix       bool
+	matrixColumn string
+	// aggregate holds the --aggregate rows to append to the merged table
+	aggregate []string
+}
+
+// parseOutputSettings parses the --order, --grep, --field-filter,
+// --output-format, --compare, --aggregate and --output-template flags
+func parseOutputSettings() (*outputSettings, error) {
+	resultOrder, err := output.ParseOrder(order)
+	if err != nil {
+		return nil, err
+	}
+	rowFilter, err := output.NewRowFilter(grepPattern, fieldFilters)
+	if err != nil {
+		return nil, err
+	}
+	mergeFormat, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		return nil, err
+	}
+	settings := &outputSettings{order: resultOrder, filter: rowFilter, format: mergeFormat}
+	if compareColumns != "" {
+		settings.compare = true
+		if compareColumns != compareAll {
+			settings.compareColumns = strings.Split(compareColumns, ",")
+		}
+	}
+	if matrixColumn != "" {
+		settings.matrix = true
+		if matrixColumn != matrixPresence {
+			settings.matrixColumn = matrixColumn
+		}
+	}
+	if settings.aggregate, err = output.ParseAggregations(aggregate); err != nil {
+		return nil, err
+	}
+	if outputTemplate != "" {
+		if settings.tmpl, err = output.ParseTemplate(outputTemplate); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+// checkTableModes checks that at most one of --compare, --matrix, --pivot
+// and --aggregate is given, and that kubectl args print the table the ones
+// reshaping a table need
+func (o *outputSettings) checkTableModes(args []string) error {
+	modes := []struct {
+		flag       string
+		set, table bool
+	}{
+		{"--compare", o.compare, true},
+		{"--matrix", o.matrix, true},
+		{"--pivot", pivot, false},
+		{"--aggregate", o.aggregate != nil, true},
+	}
+	format := kubectlOutputFormat(args)
+	if o.names {
+		format = "name"
+	}
+	var set []string
+	for _, mode := range modes {
+		if !mode.set {
+			continue
+		}
+		set = append(set, mode.flag)
+		if mode.table && (format == "name" || format == "json" || format == "yaml") {
+			return fmt.Errorf("%s needs table output, not -o %s", mode.flag, format)
+		}
+	}
+	if len(set) > 1 {
+		return fmt.Errorf("%s can't be combined", strings.Join(set, " and "))
+	}
+	return nil
+}
+
+// streaming reports whether results are written as JSON Lines as each cluster completes
+func (o *outputSettings) streaming() bool {
+	return o.format == output.FormatJSONL && o.tmpl == nil && query == ""
+}
+
+// spooling reports whether the output of kubectl args is written to files
+// while kubectl runs, for printResults to stream it from there: -o json
+// without --query and -o yaml, when nothing else needs the output in memory
+func (o *outputSettings) spooling(args []string) bool {
+	format := kubectlOutputFormat(args)
+	if format != "json" && format != "yaml" {
+		return false
+	}
+	return query == "" && o.tmpl == nil && !o.names && !o.streaming() && !countRows && !currentOnly && !verify && recordDir == "" && replayDir == ""
+}
+
+// removeOnExit removes dir, if set, when the returned function is called or
+// on Ctrl-C, which would otherwise leave the spooled outputs behind
+func removeOnExit(dir string) func() {
+	if dir == "" {
+		return func() {}
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if _, ok := <-signals; ok {
+			os.RemoveAll(dir)
+			os.Exit(130)
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(signals)
+			os.RemoveAll(dir)
+		})
+	}
+}
 
-	// Create executor
-	exec := executor.NewExecutor(mgr.GetKubeConfigPath(), timeout)
+// queryArgs adds "-o json" to args when --query is set, which needs JSON output
+func queryArgs(args []string) ([]string, error) {
+	if query == "" {
+		return args, nil
+	}
+	switch kubectlOutputFormat(args) {
+	case "":
+		return append(args, "-o", "json"), nil
+	case "json":
+		return args, nil
+	}
+	return nil, fmt.Errorf("--query requires -o json")
+}
 
-	// Execute kubectl command across all contexts
-	results := exec.Execute(targetContexts, args)
+// nameArgs reports whether kubectl args ask for -o name. A get is run with
+// -o json instead, so the merged names can include the namespace.
+func nameArgs(args []string) ([]string, bool) {
+	if kubectlOutputFormat(args) != "name" {
+		return args, false
+	}
+	if args[0] != "get" {
+		return args, true
+	}
+	rewritten := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "-o", "--output":
+			i++
+		case "-oname", "-o=name", "--output=name":
+		default:
+			rewritten = append(rewritten, arg)
+		}
+	}
+	return append(rewritten, "-o", "json"), true
+}
 
-	// Merge and print results
-	merger := output.NewMerger()
+// printResults merges and prints the results of kubectl args (unless they
+// were already streamed) and the errors, stderr and summary requested by the
+// flags. Returns false if any cluster failed or the output could not be rendered.
+func printResults(merger *output.Merger, settings *outputSettings, args []string, results []executor.Result, streaming bool) bool {
+	output.SortResults(results, settings.order)
 
 	// Check if this is a non-table command
 	isNonTableCmd := false
@@ -194,19 +704,188 @@ func runMultiKubectl(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	outputFormat := kubectlOutputFormat(args)
 	var mergedOutput string
-	if isNonTableCmd {
+	var err error
+	if streaming {
+		// Already written
+	} else if settings.tmpl != nil {
+		mergedOutput, err = merger.RenderTemplate(settings.tmpl, args, results)
+	} else if logTimestamps(args) {
+		mergedOutput = merger.InterleaveLogs(results)
+	} else if isNonTableCmd {
 		mergedOutput = merger.MergeNonTableOutput(results)
+	} else if settings.names {
+		mergedOutput, err = merger.MergeNames(results, nameFormat)
+	} else if outputFormat == "json" && query != "" {
+		mergedOutput, err = mergeJSON(merger, results)
+	} else if outputFormat == "json" {
+		// Written as it is merged, one object at a time
+		err = merger.WriteJSON(os.Stdout, results)
+	} else if outputFormat == "yaml" {
+		err = merger.WriteYAML(os.Stdout, results)
+	} else if pivot {
+		mergedOutput, err = merger.RenderTable(merger.PivotTable(results), settings.format)
+	} else if settings.matrix {
+		var table *output.Table
+		if table, err = merger.MatrixTable(results, settings.matrixColumn); err == nil {
+			mergedOutput, err = merger.RenderTable(table, settings.format)
+		}
+	} else if settings.compare {
+		var table *output.Table
+		if table, err = merger.CompareTable(results, settings.compareColumns); err == nil {
+			mergedOutput, err = merger.RenderTable(table, settings.format)
+		}
+	} else if settings.aggregate != nil {
+		table := merger.BuildTable(results)
+		if !table.HasHeader() {
+			err = errors.New("--aggregate needs table output, e.g. from get or top")
+		} else {
+			table.Aggregate(settings.aggregate)
+			mergedOutput, err = merger.RenderTable(table, settings.format)
+		}
+	} else if settings.format != output.FormatTable {
+		mergedOutput, err = output.RenderTable(merger.BuildTable(results), settings.format)
 	} else {
 		mergedOutput = merger.MergeResults(results, true)
 	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return false
+	}
 
 	fmt.Print(mergedOutput)
+	if quiet || (isNonTableCmd && prefixFormat != "") || logTimestamps(args) || settings.tmpl != nil || settings.names || settings.compare || settings.matrix || pivot || settings.aggregate != nil || outputFormat == "json" || outputFormat == "yaml" || settings.format != output.FormatTable {
+		fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+	}
+	if showStderr {
+		fmt.Fprint(os.Stderr, merger.MergeStderr(results))
+	}
+	if countRows && len(args) > 0 && args[0] == "get" {
+		fmt.Fprint(os.Stderr, merger.RenderCounts(results, outputFormat, countNoun(args)))
+	}
+	if showSummary {
+		fmt.Fprint(os.Stderr, merger.RenderSummary(results))
+	}
 
-	// Check for any errors and set exit code
+	// Check for any errors
 	for _, r := range results {
 		if r.Error != nil {
-			os.Exit(1)
+			return false
+		}
+	}
+	return true
+}
+
+// printPlain prints a single cluster's result as kubectl itself would and
+// returns kubectl's exit code
+func printPlain(results []executor.Result) int {
+	code := 0
+	for _, r := range results {
+		fmt.Print(r.Output)
+		fmt.Fprint(os.Stderr, r.Stderr)
+		if r.Error == nil {
+			continue
 		}
+		if strings.TrimSpace(r.Stderr) == "" {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", r.Error)
+		}
+		code = max(code, r.ExitCode, 1)
+	}
+	return code
+}
+
+// countNoun names the objects a get command lists for --count: its resource,
+// or "object" for several resources such as "get pods,svc" or "get all"
+func countNoun(args []string) string {
+	_, resource, _ := policy.ParseArgs(args)
+	if resource == "" || resource == "all" || strings.Contains(resource, ",") {
+		return "object"
+	}
+	return resource
+}
+
+// kubectlOutputFormat returns the value of kubectl's -o/--output flag, or "" if not set
+func kubectlOutputFormat(args []string) string {
+	for i, arg := range args {
+		switch {
+		case arg == "-o" || arg == "--output":
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+		case strings.HasPrefix(arg, "--output="):
+			return strings.TrimPrefix(arg, "--output=")
+		case strings.HasPrefix(arg, "-o="):
+			return strings.TrimPrefix(arg, "-o=")
+		case strings.HasPrefix(arg, "-o"):
+			return strings.TrimPrefix(arg, "-o")
+		}
+	}
+	return ""
+}
+
+// allNamespaces reports whether kubectl args list resources across all namespaces
+func allNamespaces(args []string) bool {
+	_, _, namespace := policy.ParseArgs(args)
+	return namespace == policy.AllNamespaces
+}
+
+// headerVerbs are the kubectl verbs whose table output starts with a header line
+var headerVerbs = []string{"get", "top", "events", "api-resources"}
+
+// headerMode tells the merger where kubectl puts the header for args: table
+// output of headerVerbs starts with one unless --no-headers is given, other
+// output formats have none, and the output of any other verb is guessed.
+func headerMode(args []string) output.HeaderMode {
+	verb, _, _ := policy.ParseArgs(args)
+	if !slices.Contains(headerVerbs, verb) {
+		return output.HeaderDetect
+	}
+	for _, arg := range args {
+		if arg == "--no-headers" || arg == "--no-headers=true" {
+			return output.HeaderNone
+		}
+	}
+	format := kubectlOutputFormat(args)
+	if format == "" || format == "wide" || strings.HasPrefix(format, "custom-columns") {
+		return output.HeaderFirstLine
+	}
+	return output.HeaderNone
+}
+
+// dryRun reports whether kubectl args only simulate the change (--dry-run)
+func dryRun(args []string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool {
+		return strings.HasPrefix(arg, "--dry-run") && arg != "--dry-run=none"
+	})
+}
+
+// mergeJSON merges JSON results into a single List and applies --query if set
+func mergeJSON(merger *output.Merger, results []executor.Result) (string, error) {
+	doc, err := merger.MergeJSON(results)
+	if err != nil {
+		return "", err
+	}
+	if query != "" {
+		return output.ApplyQuery(doc, query)
+	}
+	return output.FormatJSON(doc)
+}
+
+// buildPalette resolves the output colors from the theme config.
+// Colors are only used when writing to a terminal and neither --no-color nor NO_COLOR is set.
+func buildPalette(cfg *config.MultiKubeConfig) (*output.Palette, error) {
+	if noColor || os.Getenv("NO_COLOR") != "" || !output.IsTerminal() {
+		return nil, nil
+	}
+	if cfg.Theme == nil {
+		return output.NewPalette("", output.Palette{})
 	}
+	return output.NewPalette(cfg.Theme.Preset, output.Palette{
+		Cluster:  cfg.Theme.Cluster,
+		Header:   cfg.Theme.Header,
+		Error:    cfg.Theme.Error,
+		Warning:  cfg.Theme.Warning,
+		Clusters: cfg.Theme.Clusters,
+	})
 }
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/multikubectl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var secretDiffNamespace string
+
+var secretDiffCmd = &cobra.Command{
+	Use:   "secret-diff <name>",
+	Short: "Compare a Secret across clusters without revealing its values",
+	Long: `Compare the keys of a Secret with the same name across clusters.
+
+Each key's value is hashed with a random per-run key, and identical values
+are labeled with the same letter (A, B, ...) per key. Secret material and
+its hashes are never printed, so the output is safe to share.
+
+Exits with a non-zero status if any key differs or is missing.
+
+Examples:
+  multikubectl secret-diff db-credentials -n app`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSecretDiff,
+}
+
+func init() {
+	secretDiffCmd.Flags().StringVarP(&secretDiffNamespace, "namespace", "n", "", "Namespace of the secret")
+}
+
+func runSecretDiff(cmd *cobra.Command, args []string) {
+	name := args[0]
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// A random HMAC key makes the hashes useless outside this run
+	hashKey := make([]byte, 32)
+	if _, err := rand.Read(hashKey); err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating hash key: %v\n", err)
+		os.Exit(1)
+	}
+
+	getArgs := []string{"get", "secret", name, "-o", "json"}
+	if secretDiffNamespace != "" {
+		getArgs = append(getArgs, "-n", secretDiffNamespace)
+	}
+
+	sess := newSession()
+	// The secret's values must not end up in the history
+	sess.DisableHistory()
+	exec := sess.NewExecutor()
+	merger := sess.newMerger()
+	objects, results := sess.fetchObjects(exec, getArgs)
+
+	// hashes[key][cluster] is the keyed hash of the value
+	hashes := make(map[string]map[string]string)
+	var clusters []string
+	for _, r := range results {
+		obj, ok := objects[r.Context]
+		if !ok {
+			continue
+		}
+		clusters = append(clusters, r.Context)
+		data, _ := obj["data"].(map[string]interface{})
+		for key, value := range data {
+			encoded, _ := value.(string)
+			mac := hmac.New(sha256.New, hashKey)
+			mac.Write([]byte(encoded))
+			if hashes[key] == nil {
+				hashes[key] = make(map[string]string)
+			}
+			hashes[key][r.Context] = string(mac.Sum(nil))
+		}
+	}
+
+	if len(clusters) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: secret %s was not found in any cluster\n", name)
+		fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+		os.Exit(1)
+	}
+
+	keys := make([]string, 0, len(hashes))
+	for key := range hashes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	table := &output.Table{Headers: append(append([]string{"KEY"}, clusters...), "STATUS")}
+	differs := 0
+	for _, key := range keys {
+		row := []string{key}
+		labels := make(map[string]string)
+		missing := false
+		for _, cluster := range clusters {
+			hash, ok := hashes[key][cluster]
+			if !ok {
+				row = append(row, "-")
+				missing = true
+				continue
+			}
+			if _, seen := labels[hash]; !seen {
+				labels[hash] = string(rune('A' + len(labels)))
+			}
+			row = append(row, labels[hash])
+		}
+
+		status := "same"
+		switch {
+		case missing:
+			status = "missing"
+		case len(labels) > 1:
+			status = "differs"
+		}
+		if status != "same" {
+			differs++
+		}
+		table.Rows = append(table.Rows, append(row, status))
+	}
+
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+	fmt.Fprint(os.Stderr, merger.MergeErrors(results))
+
+	if differs > 0 || len(clusters) < len(results) {
+		os.Exit(1)
+	}
+}
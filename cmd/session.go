@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/multikubectl/pkg/cluster"
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+	"github.com/multikubectl/pkg/policy"
+	"github.com/multikubectl/pkg/runner"
+)
+
+// session holds the state shared by every command that fans out across clusters
+type session struct {
+	*runner.Runner
+}
+
+// runnerOptions builds the runner options from the global flags. Exits on error.
+func runnerOptions() runner.Options {
+	clusters, err := ephemeralClusters()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	targets := contexts
+	if currentOnly {
+		if len(contexts) > 0 || allContexts || contextSelector != "" {
+			fmt.Fprintln(os.Stderr, "Error: --current-only can't be combined with --contexts, --all-contexts or --context-selector")
+			os.Exit(1)
+		}
+		targets = []string{runner.CurrentContext}
+	}
+
+	return runner.Options{
+		KubeConfig:       kubeConfig,
+		Contexts:         targets,
+		AllContexts:      allContexts,
+		ContextSelector:  contextSelector,
+		Clusters:         clusters,
+		ForceTargets:     forceTargets,
+		Confirm:          confirmPolicy,
+		Timeout:          timeout,
+		Serial:           serial,
+		SerialDelay:      serialDelay,
+		QPS:              qps,
+		Burst:            burst,
+		As:               asUser,
+		AsGroups:         asGroups,
+		BreakerThreshold: breakerThreshold,
+		IncludeFailing:   includeFailing,
+		HideWarnings:     hideWarnings,
+		Failover:         failover,
+		NamespaceCheck:   checkNamespace,
+		ExistsCheck:      checkExists,
+		RecordDir:        recordDir,
+		ReplayDir:        replayDir,
+		Stderr:           os.Stderr,
+	}
+}
+
+// newSession loads the kubeconfig and multikube config and resolves the
+// target contexts from flags and config, skipping contexts held open by the
+// circuit breaker. Exits on error.
+func newSession() *session {
+	r, err := runner.New(runnerOptions())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return &session{r}
+}
+
+// resolveSession loads the kubeconfig and multikube config and resolves the
+// target contexts from flags and config, without consulting the circuit
+// breaker. Exits on error.
+func resolveSession() *session {
+	r, err := runner.Resolve(runnerOptions())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return &session{r}
+}
+
+// enforceVerbRestrictions exits if verb is restricted in the config and any
+// target context is outside the allowed contexts, unless --force-targets is set
+func (s *session) enforceVerbRestrictions(verb string) {
+	if err := s.CheckVerbRestrictions(verb); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// enforcePolicy exits if the policy file denies the command on any target
+// context, or a rule requiring confirmation is not confirmed
+func (s *session) enforcePolicy(args []string) {
+	if err := s.CheckPolicy(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// enforceCommandPolicy is like enforcePolicy for an arbitrary command run
+// under verb
+func (s *session) enforceCommandPolicy(verb string, command []string) {
+	if err := s.CheckCommandPolicy(verb, command); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// ephemeralClusters parses the --extra-cluster and --clusters-file flags
+func ephemeralClusters() ([]cluster.Ephemeral, error) {
+	var ephemeral []cluster.Ephemeral
+	if clustersFile != "" {
+		fromFile, err := cluster.LoadEphemeralFile(clustersFile)
+		if err != nil {
+			return nil, err
+		}
+		ephemeral = append(ephemeral, fromFile...)
+	}
+	for _, spec := range clusterSpecs {
+		e, err := cluster.ParseEphemeral(spec)
+		if err != nil {
+			return nil, err
+		}
+		ephemeral = append(ephemeral, e)
+	}
+	return ephemeral, nil
+}
+
+// run executes kubectl args against every target context, showing progress
+// on stderr. Exits if the run could not be set up.
+func (s *session) run(exec *executor.Executor, args []string) []executor.Result {
+	return s.runOn(exec, s.Contexts(), args)
+}
+
+// runOn is like run, but executes against a subset of the target contexts
+func (s *session) runOn(exec *executor.Executor, contexts []string, args []string) []executor.Result {
+	results, err := s.execute(context.Background(), exec, contexts, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return results
+}
+
+// execute runs kubectl args against contexts, showing progress on stderr.
+// kubectl processes still running when ctx is done are killed.
+func (s *session) execute(ctx context.Context, exec *executor.Executor, contexts []string, args []string) ([]executor.Result, error) {
+	// Show progress on stderr for slow clusters: a heartbeat line per cluster
+	// if asked for or when not interactive, else the progress line
+	var progress *output.Progress
+	var heartbeat *output.Heartbeat
+	if interval := s.heartbeatInterval(args); interval > 0 && !quiet {
+		heartbeat = output.NewHeartbeat(contexts, interval)
+		heartbeat.Start()
+	} else if !noProgress && !quiet && output.StderrIsTerminal() {
+		progress = output.NewProgress(contexts)
+		progress.Start()
+	}
+	onResult := exec.ResultCallback()
+	if progress != nil || heartbeat != nil {
+		exec.SetResultCallback(func(r executor.Result) {
+			if progress != nil {
+				progress.Done(r.Context)
+			}
+			if heartbeat != nil {
+				heartbeat.Done(r.Context)
+			}
+			if onResult != nil {
+				onResult(r)
+			}
+		})
+	}
+
+	// Execute kubectl command across the contexts
+	results, err := s.ExecuteOn(ctx, exec, contexts, args)
+	if progress != nil {
+		progress.Stop()
+	}
+	if heartbeat != nil {
+		heartbeat.Stop()
+	}
+	exec.SetResultCallback(onResult)
+	return results, err
+}
+
+// heartbeatInterval returns the interval of the heartbeat printed while
+// kubectl args run: --heartbeat, else the config's heartbeat, else
+// defaultHeartbeat for long-running commands when stderr is not a terminal.
+// Exits if the interval is invalid.
+func (s *session) heartbeatInterval(args []string) time.Duration {
+	configured := heartbeatEvery
+	if configured == "" {
+		configured = s.Config().Heartbeat
+	}
+	if configured == "" {
+		if !output.StderrIsTerminal() && longRunning(args) {
+			return defaultHeartbeat
+		}
+		return 0
+	}
+	interval, err := time.ParseDuration(configured)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid heartbeat %q: %v\n", configured, err)
+		os.Exit(1)
+	}
+	return interval
+}
+
+// longRunning reports whether kubectl args are a command expected to run
+// long, which gets a heartbeat in logs
+func longRunning(args []string) bool {
+	positional := policy.Positional(args)
+	if len(positional) == 0 {
+		return false
+	}
+	switch positional[0] {
+	case "wait", "apply", "drain":
+		return true
+	case "rollout":
+		return len(positional) > 1 && positional[1] == "status"
+	}
+	return false
+}
+
+// newMerger creates a merger configured from the output flags. Exits on error.
+func (s *session) newMerger() *output.Merger {
+	merger := output.NewMerger()
+	merger.SetQuiet(quiet)
+	if !noTruncate {
+		merger.SetMaxWidth(output.TerminalWidth())
+	}
+	palette, err := buildPalette(s.Config())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	merger.SetPalette(palette)
+
+	aliases := make(map[string]string)
+	for _, ctx := range s.Contexts() {
+		aliases[ctx] = s.Config().SettingsFor(ctx).Alias
+	}
+	merger.SetAliases(aliases)
+	merger.SetContexts(s.Contexts())
+	merger.SetLevelColors(colorLevels)
+	merger.SetDedupe(dedupeWindow)
+	if bannerFormat != "" {
+		tmpl, err := output.ParsePrefix("banner", bannerFormat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		merger.SetBanner(tmpl)
+	}
+	if prefixFormat != "" {
+		tmpl, err := output.ParsePrefix("prefix", prefixFormat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		merger.SetPrefix(tmpl)
+	}
+	return merger
+}
+
+// fetchObjects runs a kubectl command returning JSON (e.g. "get ... -o json")
+// against every target context and parses each cluster's object.
+// Clusters that failed or returned invalid JSON are absent from the map.
+func (s *session) fetchObjects(exec *executor.Executor, args []string) (map[string]map[string]interface{}, []executor.Result) {
+	results := s.run(exec, args)
+
+	objects := make(map[string]map[string]interface{})
+	for _, r := range results {
+		if r.Error != nil {
+			continue
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(r.Output), &obj); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to parse output from cluster %s: %v\n", r.Context, err)
+			continue
+		}
+		objects[r.Context] = obj
+	}
+	return objects, results
+}
@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+	"github.com/multikubectl/pkg/policy"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Run successive kubectl commands against the target clusters at a prompt",
+	Long: `Start an interactive prompt where each line is a kubectl command run
+against the target contexts, merged as usual. The contexts are resolved,
+discovered clusters logged into and SSH tunnels opened once, when the shell
+starts, instead of for every command.
+
+The target contexts and output flags (--contexts, --output-format, --grep,
+...) are those given to "shell". Up/down recall earlier commands, Tab
+completes verbs, resource types and namespaces, and Ctrl-C interrupts the
+running command. A leading "kubectl" is ignored.
+
+Built-in commands:
+  contexts   list the target contexts
+  help       show this help
+  exit       leave the shell (also quit, or Ctrl-D)
+
+When stdin is not a terminal, commands are read one per line, so a script
+can be piped in; the exit status is non-zero if any command failed.
+
+Examples:
+  multikubectl shell
+  multikubectl --context-selector env=prod shell
+  multikubectl shell < checks.txt`,
+	Args: cobra.NoArgs,
+	Run:  runShell,
+}
+
+// shellHelp is printed by the help built-in
+const shellHelp = `Each line is a kubectl command run against the target contexts, e.g.
+"get pods -n web". A leading "kubectl" is ignored.
+
+Built-in commands:
+  contexts   list the target contexts
+  help       show this help
+  exit       leave the shell (also quit, or Ctrl-D)
+
+Up/down recall earlier commands, Tab completes verbs, resource types and
+namespaces, and Ctrl-C interrupts the running command.
+`
+
+// shellVerbs are the kubectl verbs offered by completion
+var shellVerbs = []string{
+	"annotate", "api-resources", "api-versions", "apply", "auth", "cluster-info",
+	"create", "delete", "describe", "diff", "events", "exec", "explain", "get",
+	"label", "logs", "patch", "port-forward", "rollout", "scale", "set", "top",
+	"version", "wait",
+}
+
+// shellBuiltins are the commands handled by the shell itself
+var shellBuiltins = []string{"contexts", "exit", "help", "quit"}
+
+// shellResources are the resource types offered by completion
+var shellResources = []string{
+	"configmaps", "cronjobs", "daemonsets", "deployments", "endpoints", "events",
+	"horizontalpodautoscalers", "ingresses", "jobs", "namespaces", "networkpolicies",
+	"nodes", "persistentvolumeclaims", "persistentvolumes", "poddisruptionbudgets",
+	"pods", "replicasets", "rolebindings", "roles", "secrets", "serviceaccounts",
+	"services", "statefulsets", "storageclasses",
+}
+
+// unsupportedInShell are the commands that take over the terminal or process
+// and can only run as "multikubectl <command>"
+var unsupportedInShell = []string{"attach", "cp", "edit"}
+
+// shell is an interactive multikubectl session
+type shell struct {
+	sess     *session
+	exec     *executor.Executor
+	merger   *output.Merger
+	settings *outputSettings
+	// namespaces caches the namespaces of every cluster for completion
+	namespaces []string
+	failed     bool
+}
+
+func runShell(cmd *cobra.Command, args []string) {
+	settings, err := parseOutputSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sess := newSession()
+	exec := sess.NewExecutor()
+	cleanup, err := sess.Prepare(exec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	merger := sess.newMerger()
+	merger.SetFilter(settings.filter)
+
+	sh := &shell{sess: sess, exec: exec, merger: merger, settings: settings}
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	if interactive {
+		sh.interactive()
+	} else {
+		sh.script(os.Stdin)
+	}
+	cleanup()
+
+	if sh.failed && !interactive {
+		os.Exit(1)
+	}
+}
+
+// interactive reads commands at a prompt with history and completion until
+// the user exits
+func (sh *shell) interactive() {
+	fd := int(os.Stdin.Fd())
+	prompt := fmt.Sprintf("multikubectl (%d clusters)> ", len(sh.sess.Contexts()))
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, prompt)
+	t.AutoCompleteCallback = sh.complete
+
+	fmt.Printf("Targets: %s\nType \"help\" for help, \"exit\" or Ctrl-D to leave.\n", strings.Join(sh.sess.Contexts(), ", "))
+	for {
+		// The terminal is raw only while reading the line, so commands print normally
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+		if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+			t.SetSize(width, height)
+		}
+		line, err := t.ReadLine()
+		term.Restore(fd, state)
+		if err == io.EOF {
+			fmt.Println()
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+		if !sh.handle(line) {
+			return
+		}
+	}
+}
+
+// script runs the commands read from r, one per line
+func (sh *shell) script(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if !sh.handle(scanner.Text()) {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read commands: %v\n", err)
+		sh.failed = true
+	}
+}
+
+// handle runs one command line. Returns false when the shell should exit.
+func (sh *shell) handle(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return true
+	}
+	args, err := shellquote.Split(line)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		sh.failed = true
+		return true
+	}
+	if args[0] == "kubectl" {
+		args = args[1:]
+		if len(args) == 0 {
+			return true
+		}
+	}
+	args = policy.VerbFirst(args)
+
+	switch args[0] {
+	case "exit", "quit":
+		return false
+	case "help":
+		fmt.Print(shellHelp)
+	case "contexts":
+		for _, ctx := range sh.sess.Contexts() {
+			fmt.Println(ctx)
+		}
+	default:
+		if !sh.execute(args) {
+			sh.failed = true
+		}
+	}
+	return true
+}
+
+// execute runs kubectl args against the target contexts and prints the merged
+// output. Ctrl-C kills the running kubectl processes. Returns false if the
+// command failed on any cluster.
+func (sh *shell) execute(args []string) bool {
+	if slices.Contains(unsupportedInShell, args[0]) {
+		fmt.Fprintf(os.Stderr, "Error: %s is not available in the shell; run it as \"multikubectl %s ...\"\n", args[0], args[0])
+		return false
+	}
+	args, err := queryArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return false
+	}
+	args, sh.settings.names = nameArgs(args)
+	if err := sh.sess.CheckVerbRestrictions(args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return false
+	}
+	if err := sh.sess.CheckPolicy(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return false
+	}
+	args = logLimitArgs(sh.sess.Config(), args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	sh.merger.SetAllNamespaces(allNamespaces(args))
+	sh.merger.SetHeaderMode(headerMode(args))
+	streaming := sh.settings.streaming()
+	if streaming {
+		sh.exec.SetResultCallback(sh.merger.NewJSONLWriter(os.Stdout).Write)
+		defer sh.exec.SetResultCallback(nil)
+	}
+	contexts := sh.sess.Contexts()
+	if args[0] == "exec" {
+		if args, contexts, err = resolveExecPods(sh.sess, sh.exec, args, contexts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return false
+		}
+		defer sh.exec.SetArgsFunc(nil)
+	}
+	results, err := sh.sess.execute(ctx, sh.exec, contexts, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return false
+	}
+	return printResults(sh.merger, sh.settings, args, results, streaming)
+}
+
+// complete is the terminal's completion callback: on Tab it completes the
+// word before the cursor to a verb, resource type or namespace
+func (sh *shell) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	before := line[:pos]
+	start := strings.LastIndexAny(before, " \t") + 1
+	word := before[start:]
+	previous := strings.Fields(before[:start])
+	if len(previous) > 0 && previous[0] == "kubectl" {
+		previous = previous[1:]
+	}
+
+	var candidates []string
+	switch {
+	case len(previous) == 0:
+		candidates = append(slices.Clone(shellVerbs), shellBuiltins...)
+	case previous[len(previous)-1] == "-n" || previous[len(previous)-1] == "--namespace":
+		candidates = sh.namespaceNames()
+	case len(previous) == 1:
+		candidates = shellResources
+	default:
+		return "", 0, false
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+	completion := matches[0]
+	if len(matches) == 1 {
+		completion += " "
+	} else {
+		for _, m := range matches[1:] {
+			for !strings.HasPrefix(m, completion) {
+				completion = completion[:len(completion)-1]
+			}
+		}
+	}
+	if completion == word {
+		return "", 0, false
+	}
+	return before[:start] + completion + line[pos:], start + len(completion), true
+}
+
+// namespaceNames returns the namespaces of every target cluster, listing them
+// on first use. The listing is not recorded in the audit log or history.
+func (sh *shell) namespaceNames() []string {
+	if sh.namespaces != nil {
+		return sh.namespaces
+	}
+	seen := make(map[string]bool)
+	sh.namespaces = []string{}
+	for _, r := range sh.exec.Execute(sh.sess.Contexts(), []string{"get", "namespaces", "-o", "name"}) {
+		if r.Error != nil {
+			continue
+		}
+		for _, line := range strings.Split(r.Output, "\n") {
+			name := strings.TrimPrefix(strings.TrimSpace(line), "namespace/")
+			if name != "" && !seen[name] {
+				seen[name] = true
+				sh.namespaces = append(sh.namespaces, name)
+			}
+		}
+	}
+	sort.Strings(sh.namespaces)
+	return sh.namespaces
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/multikubectl/pkg/executor"
+	"golang.org/x/term"
+)
+
+// Choices of the --step prompt
+const (
+	stepApprove = "approve"
+	stepSkip    = "skip"
+	stepAbort   = "abort"
+)
+
+// runSteps runs kubectl args against contexts one at a time, showing each
+// cluster's resolved command and asking whether to run it, skip the cluster
+// or abort, leaving the remaining clusters alone. Returns the results of the
+// clusters that ran and whether the run was aborted.
+func runSteps(sess *session, exec *executor.Executor, contexts []string, args []string) ([]executor.Result, bool) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintln(os.Stderr, "Error: --step needs a terminal to ask on")
+		os.Exit(1)
+	}
+
+	var results []executor.Result
+	var skipped []string
+	aborted := false
+	for i, ctx := range contexts {
+		plan := sess.Plan(exec, []string{ctx}, args)[0]
+		fmt.Fprintf(os.Stderr, "\n=== Cluster: %s (%d/%d) ===\n", ctx, i+1, len(contexts))
+		for _, env := range plan.Env {
+			fmt.Fprintf(os.Stderr, "export %s\n", env)
+		}
+		fmt.Fprintln(os.Stderr, plan)
+
+		var choice string
+		prompt := &survey.Select{
+			Message: fmt.Sprintf("Run on %s?", ctx),
+			Options: []string{stepApprove, stepSkip, stepAbort},
+		}
+		if err := survey.AskOne(prompt, &choice, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)); err != nil {
+			// Interrupted: leave this and the remaining clusters alone
+			choice = stepAbort
+		}
+		if choice == stepAbort {
+			aborted = true
+			skipped = append(skipped, contexts[i:]...)
+			break
+		}
+		if choice == stepSkip {
+			skipped = append(skipped, ctx)
+			continue
+		}
+
+		r := sess.runOn(exec, []string{ctx}, args)[0]
+		if r.Error != nil {
+			fmt.Fprintf(os.Stderr, "%s failed: %s\n", ctx, firstLine(r.Error.Error()))
+		} else {
+			fmt.Fprintf(os.Stderr, "%s succeeded in %s\n", ctx, r.Duration.Round(100*time.Millisecond))
+		}
+		results = append(results, r)
+	}
+
+	fmt.Fprintln(os.Stderr)
+	if aborted {
+		fmt.Fprintln(os.Stderr, "Aborted.")
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "Not run on: %s\n", strings.Join(skipped, ", "))
+	}
+	return results, aborted
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+)
+
+// verifyVerbs are the commands --verify checks, with the metadata field they change
+var verifyVerbs = map[string]string{"label": "labels", "annotate": "annotations"}
+
+// selectFlags are the flags of commands changing objects (label, annotate,
+// delete) that select the objects and are passed on to a get listing the
+// same objects, mapped to whether they take a separate value
+var selectFlags = map[string]bool{
+	"-n": true, "--namespace": true,
+	"-l": true, "--selector": true,
+	"-f": true, "--filename": true,
+	"-k": true, "--kustomize": true,
+	"-A": false, "--all-namespaces": false,
+	"-R": false, "--recursive": false,
+	"--field-selector": true,
+}
+
+// metadataValueFlags are the other label/annotate flags taking a separate value
+var metadataValueFlags = map[string]bool{
+	"-o": true, "--output": true,
+	"--resource-version": true,
+	"--field-manager":    true,
+	"--template":         true,
+}
+
+// metadataChange is a label or annotation set (key=value) or removed (key-)
+type metadataChange struct {
+	key    string
+	value  string
+	remove bool
+}
+
+// parseMetadataArgs splits label/annotate args into the args of a get
+// selecting the same objects and the changes made to them
+func parseMetadataArgs(args []string) ([]string, []metadataChange) {
+	getArgs := []string{"get"}
+	var changes []metadataChange
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		name, _, hasValue := strings.Cut(arg, "=")
+		switch {
+		case strings.HasPrefix(arg, "-"):
+			takesValue, selects := selectFlags[name]
+			if !selects {
+				// Skip the flags not selecting objects, with their value
+				if metadataValueFlags[name] && !hasValue {
+					i++
+				}
+				continue
+			}
+			getArgs = append(getArgs, arg)
+			if takesValue && !hasValue && i+1 < len(args) {
+				i++
+				getArgs = append(getArgs, args[i])
+			}
+		case hasValue:
+			value := strings.TrimPrefix(arg, name+"=")
+			changes = append(changes, metadataChange{key: name, value: value})
+		case strings.HasSuffix(arg, "-"):
+			changes = append(changes, metadataChange{key: strings.TrimSuffix(arg, "-"), remove: true})
+		default:
+			getArgs = append(getArgs, arg)
+		}
+	}
+	return append(getArgs, "-o", "json"), changes
+}
+
+// verifyMetadata re-reads the objects changed by a label or annotate command
+// in the clusters where it succeeded and prints, per cluster, whether each
+// object now has the expected labels or annotations. Returns false if any
+// cluster could not be verified or an object does not match.
+func verifyMetadata(sess *session, exec *executor.Executor, merger *output.Merger, format output.Format, args []string, results []executor.Result) bool {
+	field := verifyVerbs[args[0]]
+	if dryRun(args) {
+		fmt.Fprintln(os.Stderr, "Note: nothing to verify for a dry run")
+		return true
+	}
+	getArgs, changes := parseMetadataArgs(args)
+	if len(changes) == 0 {
+		return true
+	}
+
+	var changed []string
+	for _, r := range results {
+		if r.Error == nil {
+			changed = append(changed, r.Context)
+		}
+	}
+	if len(changed) == 0 {
+		return true
+	}
+
+	ok := true
+	table := &output.Table{Headers: []string{"CLUSTER", "OBJECTS", "STATUS"}}
+	for _, r := range sess.runOn(exec, changed, getArgs) {
+		var obj map[string]interface{}
+		if r.Error != nil {
+			ok = false
+			table.Rows = append(table.Rows, []string{r.Context, "-", "error: " + firstLine(r.Error.Error())})
+			continue
+		}
+		if err := json.Unmarshal([]byte(r.Output), &obj); err != nil {
+			ok = false
+			table.Rows = append(table.Rows, []string{r.Context, "-", fmt.Sprintf("error: invalid output: %v", err)})
+			continue
+		}
+		objects := []map[string]interface{}{obj}
+		if _, isList := obj["items"]; isList {
+			objects = listItems(obj)
+		}
+
+		var mismatches []string
+		for _, o := range objects {
+			metadata, _ := o["metadata"].(map[string]interface{})
+			values, _ := metadata[field].(map[string]interface{})
+			for _, c := range changes {
+				value, present := values[c.key].(string)
+				switch {
+				case c.remove && present:
+					mismatches = append(mismatches, fmt.Sprintf("%s: %s still set", objectRef(o), c.key))
+				case !c.remove && !present:
+					mismatches = append(mismatches, fmt.Sprintf("%s: %s missing", objectRef(o), c.key))
+				case !c.remove && value != c.value:
+					mismatches = append(mismatches, fmt.Sprintf("%s: %s=%s, want %s", objectRef(o), c.key, value, c.value))
+				}
+			}
+		}
+		status := "verified"
+		if len(mismatches) > 0 {
+			ok = false
+			status = strings.Join(mismatches, "; ")
+		}
+		table.Rows = append(table.Rows, []string{r.Context, fmt.Sprint(len(objects)), status})
+	}
+
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return false
+	}
+	fmt.Printf("\nVerification of %s:\n%s", field, rendered)
+	return ok
+}
+
+// objectRef returns an object's kind/name, e.g. "deployment/web"
+func objectRef(obj map[string]interface{}) string {
+	kind, _ := obj["kind"].(string)
+	return strings.ToLower(kind) + "/" + objectName(obj)
+}
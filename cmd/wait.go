@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/output"
+)
+
+// waitRedrawInterval is how often the elapsed time on the wait board is refreshed
+const waitRedrawInterval = 500 * time.Millisecond
+
+// runWait runs "kubectl wait" against every target cluster at once, showing
+// whether each cluster met the condition yet on a live status board, then
+// prints a table of the outcomes and exits non-zero unless every cluster met it
+func runWait(sess *session, exec *executor.Executor, args []string) {
+	format, err := output.ParseFormat(tableFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// --timeout is taken by multikubectl, so kubectl waits as long as it may run
+	hasTimeout := false
+	for _, arg := range args {
+		if arg == "--timeout" || strings.HasPrefix(arg, "--timeout=") {
+			hasTimeout = true
+		}
+	}
+	if !hasTimeout && timeout > 0 {
+		args = append(args, "--timeout="+timeout.String())
+	} else if !hasTimeout {
+		// No timeout: a negative one makes kubectl wait for a week
+		args = append(args, "--timeout=-1s")
+	}
+
+	contexts := sess.Contexts()
+	board := newStatusBoard(contexts)
+	start := time.Now()
+	var mu sync.Mutex
+	finished := make(map[string]executor.Result)
+	draw := func() {
+		lines := make(map[string]string)
+		for _, ctx := range contexts {
+			r, ok := finished[ctx]
+			switch {
+			case !ok && board.live:
+				lines[ctx] = fmt.Sprintf("waiting (%s)", time.Since(start).Round(time.Second))
+			case !ok:
+				lines[ctx] = "waiting"
+			default:
+				status, detail := waitOutcome(r)
+				if status == "met" {
+					lines[ctx] = "met (" + detail + ")"
+				} else {
+					lines[ctx] = status + ": " + detail
+				}
+			}
+		}
+		board.update(lines)
+	}
+
+	// Off a terminal the board only prints changes, so a heartbeat shows the
+	// clusters still waiting
+	var heartbeat *output.Heartbeat
+	if interval := sess.heartbeatInterval(args); interval > 0 && !board.live && !quiet {
+		heartbeat = output.NewHeartbeat(contexts, interval)
+		heartbeat.Start()
+	}
+	exec.SetResultCallback(func(r executor.Result) {
+		if heartbeat != nil {
+			heartbeat.Done(r.Context)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		finished[r.Context] = r
+		draw()
+	})
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(waitRedrawInterval)
+		defer ticker.Stop()
+		for {
+			mu.Lock()
+			draw()
+			mu.Unlock()
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	// The board replaces the usual progress line
+	results, err := sess.ExecuteOn(context.Background(), exec, contexts, args)
+	close(stop)
+	<-stopped
+	if heartbeat != nil {
+		heartbeat.Stop()
+	}
+	exec.SetResultCallback(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	merger := sess.newMerger()
+	table := &output.Table{Headers: []string{"CLUSTER", "STATUS", "DURATION", "DETAIL"}}
+	var unmet []string
+	for _, r := range results {
+		status, detail := waitOutcome(r)
+		if status != "met" {
+			unmet = append(unmet, fmt.Sprintf("%s (%s)", r.Context, status))
+		}
+		table.Rows = append(table.Rows, []string{r.Context, status, output.FormatDuration(r.Duration), detail})
+	}
+	rendered, err := merger.RenderTable(table, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rendered)
+
+	if len(unmet) > 0 {
+		fmt.Fprintf(os.Stderr, "Condition not met in %d/%d clusters: %s\n", len(unmet), len(results), strings.Join(unmet, ", "))
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Condition met in all %d clusters\n", len(results))
+}
+
+// waitOutcome classifies the result of kubectl wait in one cluster as met,
+// timeout or failed, with a detail: the objects that met the condition, or
+// the error
+func waitOutcome(r executor.Result) (string, string) {
+	if r.Error == nil {
+		met := 0
+		for _, line := range strings.Split(r.Output, "\n") {
+			if strings.TrimSpace(line) != "" {
+				met++
+			}
+		}
+		return "met", fmt.Sprintf("%d object(s)", met)
+	}
+	msg := firstLine(r.Error.Error())
+	if r.TimedOut || strings.Contains(msg, "timed out waiting for the condition") {
+		return "timeout", msg
+	}
+	return "failed", msg
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+// watchesTable reports whether get args watch with table output, which is
+// streamed as one table
+func watchesTable(args []string) bool {
+	if args[0] != "get" || !boolFlag(args, "-w", "--watch", "--watch-only") {
+		return false
+	}
+	format := kubectlOutputFormat(args)
+	return format == "" || format == "wide" || strings.HasPrefix(format, "custom-columns")
+}
+
+// runWatchGet runs get --watch in every target cluster until interrupted,
+// writing the rows of all clusters as one table as they arrive. Each
+// cluster's watch is re-established on its own when it expires or drops,
+// leaving the other clusters' watches running.
+func runWatchGet(cmd *cobra.Command, sess *session, exec *executor.Executor, args []string, settings *outputSettings) {
+	// Watching runs until stopped unless --timeout is given
+	if !cmd.Flags().Changed("timeout") {
+		exec.SetTimeout(0)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	merger := sess.newMerger()
+	merger.SetFilter(settings.filter)
+	merger.SetHeaderMode(headerMode(args))
+	table := merger.NewWatchTable(os.Stdout, sess.Contexts())
+	if settings.streaming() {
+		table.SetJSONL(merger.NewJSONLWriter(os.Stdout))
+	}
+	pipeline := executor.NewPipeline(table.Add)
+	exec.SetLineCallback(pipeline.Send)
+	cleanup, err := sess.Prepare(exec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+
+	results := make([]executor.Result, len(sess.Contexts()))
+	var wg sync.WaitGroup
+	for i, cluster := range sess.Contexts() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			note := func(marker string) { pipeline.Do(func() { table.Note(cluster, marker) }) }
+			if settings.streaming() {
+				note = stderrNote(cluster)
+			}
+			restart := func() {
+				listing, listed := relist(ctx, exec, cluster, args)
+				pipeline.Do(func() {
+					if listed {
+						table.Relisted(cluster, listing)
+					}
+					table.Restart(cluster)
+				})
+			}
+			results[i] = watchCluster(ctx, sess, exec, cluster, args, note, restart)
+		}()
+	}
+	wg.Wait()
+	exec.SetLineCallback(nil)
+	pipeline.Close()
+
+	failed := false
+	for _, r := range results {
+		if r.Error != nil && ctx.Err() == nil {
+			failed = true
+			fmt.Fprintf(os.Stderr, "Error from cluster %s: %s\n", r.Context, firstLine(r.Error.Error()))
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// relist lists the objects get --watch args watch in a cluster, without
+// watching, for the table to find those deleted while the watch was down.
+// Returns false if the listing failed.
+func relist(ctx context.Context, exec *executor.Executor, cluster string, args []string) (string, bool) {
+	var listArgs []string
+	for _, arg := range args {
+		if !boolFlag([]string{arg}, "-w", "--watch", "--watch-only") {
+			listArgs = append(listArgs, arg)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, runner.DefaultTimeout)
+	defer cancel()
+	r := exec.Probe(ctx, []string{cluster}, listArgs)[0]
+	return r.Output, r.Error == nil
+}
+
+// stderrNote returns a note function writing a cluster's markers to stderr,
+// keeping them out of JSON Lines on stdout
+func stderrNote(cluster string) func(string) {
+	return func(marker string) {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", cluster, marker)
+	}
+}
+
+// watchCluster runs a watch in one cluster until ctx is done, running it
+// again whenever it ends, e.g. when the API server closes it. A watch that
+// expired (410 Gone) after running a while is re-established at once, other
+// ends after a delay backing off while the cluster keeps failing. note writes a marker line into
+// the cluster's stream and restart, if not nil, is called before each new
+// run. Returns the result of the first run if it failed, otherwise of the
+// last one.
+func watchCluster(ctx context.Context, sess *session, exec *executor.Executor, cluster string, args []string, note func(string), restart func()) executor.Result {
+	delay := reconnectDelay
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		results, err := sess.ExecuteOn(ctx, exec, []string{cluster}, args)
+		if err != nil {
+			return executor.Result{Context: cluster, Error: err, ExitCode: -1}
+		}
+		r := results[0]
+		if ctx.Err() != nil || (attempt == 0 && r.Error != nil && !watchExpired(r)) {
+			return r
+		}
+		if watchExpired(r) && time.Since(start) > reconnectDelay {
+			note("--- watch expired (410 Gone); re-establishing ---")
+			if restart != nil {
+				restart()
+			}
+			continue
+		}
+
+		reason := "the watch ended"
+		if r.Error != nil {
+			reason = firstLine(r.Error.Error())
+		}
+		if time.Since(start) > time.Minute {
+			delay = reconnectDelay
+		}
+		note(fmt.Sprintf("--- disconnected: %s; reconnecting in %s ---", reason, delay))
+		select {
+		case <-ctx.Done():
+			return r
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+		if restart != nil {
+			restart()
+		}
+		note("--- reconnected ---")
+	}
+}
+
+// watchExpired reports whether a watch ended because the resource version
+// it watched from is no longer available (HTTP 410 Gone)
+func watchExpired(r executor.Result) bool {
+	if r.Error == nil {
+		return false
+	}
+	msg := strings.ToLower(r.Error.Error())
+	return strings.Contains(msg, "too old resource version") || strings.Contains(msg, "(gone)") || strings.Contains(msg, "410 gone")
+}
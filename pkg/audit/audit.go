@@ -0,0 +1,167 @@
+package audit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/executor"
+)
+
+const (
+	DefaultLogFile  = "audit.log"
+	DefaultMaxSize  = 10 // megabytes
+	DefaultMaxFiles = 5
+)
+
+// Entry is one line of the audit log, describing a kubectl command run across the fleet
+type Entry struct {
+	ID       string    `json:"id"`
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	Command  []string  `json:"command"`
+	Args     []string  `json:"args"`
+	Contexts []string  `json:"contexts"`
+	Results  []Result  `json:"results"`
+}
+
+// Result records the outcome of the command on a single context
+type Result struct {
+	Context    string `json:"context"`
+	ExitCode   int    `json:"exitCode"`
+	Error      string `json:"error,omitempty"`
+	TimedOut   bool   `json:"timedOut,omitempty"`
+	DurationMS int64  `json:"durationMs"`
+}
+
+// Logger appends entries to a JSON lines file, rotating it when it grows too large
+type Logger struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+}
+
+// GetLogPath returns the default path of the audit log
+func GetLogPath() string {
+	return filepath.Join(config.GetConfigDir(), DefaultLogFile)
+}
+
+// New creates a logger from the audit config. It returns nil if auditing is disabled.
+func New(cfg *config.Audit) *Logger {
+	l := &Logger{
+		path:     GetLogPath(),
+		maxSize:  DefaultMaxSize << 20,
+		maxFiles: DefaultMaxFiles,
+	}
+	if cfg == nil {
+		return l
+	}
+	if cfg.Disabled {
+		return nil
+	}
+	if cfg.Path != "" {
+		l.path = cfg.Path
+	}
+	if cfg.MaxSizeMB > 0 {
+		l.maxSize = int64(cfg.MaxSizeMB) << 20
+	}
+	if cfg.MaxFiles > 0 {
+		l.maxFiles = cfg.MaxFiles
+	}
+	return l
+}
+
+// NewEntry builds an entry for kubectl args run against contexts, with
+// their credentials redacted
+func NewEntry(args []string, contexts []string, results []executor.Result) Entry {
+	e := Entry{
+		ID:       newID(),
+		Time:     time.Now().UTC(),
+		User:     currentUser(),
+		Command:  Redact(os.Args[1:]),
+		Args:     Redact(args),
+		Contexts: contexts,
+	}
+	for _, r := range results {
+		res := Result{
+			Context:    r.Context,
+			ExitCode:   r.ExitCode,
+			TimedOut:   r.TimedOut,
+			DurationMS: r.Duration.Milliseconds(),
+		}
+		if r.Error != nil {
+			res.Error = r.Error.Error()
+			if res.ExitCode == 0 {
+				res.ExitCode = 1
+			}
+		}
+		e.Results = append(e.Results, res)
+	}
+	return e
+}
+
+// Write appends an entry to the log, rotating the file first if needed
+func (l *Logger) Write(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	if err := l.rotate(int64(len(data))); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// rotate shifts audit.log to audit.log.1, audit.log.1 to audit.log.2 and so
+// on when appending n bytes would exceed the size limit. The oldest file is dropped.
+func (l *Logger) rotate(n int64) error {
+	info, err := os.Stat(l.path)
+	if err != nil || info.Size()+n <= l.maxSize {
+		return nil
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", l.path, l.maxFiles))
+	for i := l.maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+	}
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	return nil
+}
+
+// newID returns a short random identifier for an entry
+func newID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// currentUser returns the name of the local user running the command
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
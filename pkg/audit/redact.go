@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/multikubectl/pkg/policy"
+)
+
+// Redacted replaces credentials in recorded arguments
+const Redacted = "REDACTED"
+
+// secretFlags are flags whose value is a credential
+var secretFlags = []string{"--token", "--password"}
+
+// specFlags take comma-separated key=value specs, some of which may be
+// credentials, e.g. "--extra-cluster name=https://api,token=..."
+var specFlags = []string{"--extra-cluster"}
+
+// sensitiveKeys mark the keys of specs and literals holding credentials
+var sensitiveKeys = []string{"token", "password", "secret", "credential"}
+
+// Redact returns args with the credentials they carry replaced by Redacted,
+// for the audit log and history: the values of --token and --password,
+// tokens in --extra-cluster specs and the literals of "create secret"
+func Redact(args []string) []string {
+	secret := slices.Contains(policy.Positional(args), "secret")
+	redacted := slices.Clone(args)
+	for i := 0; i < len(redacted); i++ {
+		name, value, hasValue := strings.Cut(redacted[i], "=")
+		if !strings.HasPrefix(name, "--") {
+			continue
+		}
+		if hasValue {
+			redacted[i] = name + "=" + redactValue(name, value, secret)
+			continue
+		}
+		if i+1 < len(redacted) && redactable(name) {
+			i++
+			redacted[i] = redactValue(name, redacted[i], secret)
+		}
+	}
+	return redacted
+}
+
+// redactable reports whether flag may carry a credential
+func redactable(flag string) bool {
+	return slices.Contains(secretFlags, flag) || slices.Contains(specFlags, flag) || flag == "--from-literal"
+}
+
+// redactValue redacts the credentials in the value of flag. All literals of
+// a secret are redacted, and those of other objects with a sensitive key.
+func redactValue(flag, value string, secret bool) string {
+	switch {
+	case slices.Contains(secretFlags, flag):
+		return Redacted
+	case flag == "--from-literal":
+		if key, _, ok := strings.Cut(value, "="); ok && (secret || sensitiveKey(key)) {
+			return key + "=" + Redacted
+		}
+	case slices.Contains(specFlags, flag):
+		fields := strings.Split(value, ",")
+		for i, field := range fields {
+			if key, _, ok := strings.Cut(field, "="); ok && sensitiveKey(key) {
+				fields[i] = key + "=" + Redacted
+			}
+		}
+		return strings.Join(fields, ",")
+	}
+	return value
+}
+
+// sensitiveKey reports whether a key names a credential
+func sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	return slices.ContainsFunc(sensitiveKeys, func(s string) bool {
+		return strings.Contains(key, s)
+	})
+}
+
+// IsRedacted reports whether any of args had a credential redacted
+func IsRedacted(args []string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool {
+		return strings.Contains(arg, Redacted)
+	})
+}
+
+// ReadsSecrets reports whether the output of args may hold secret data: any
+// command on secrets, e.g. get secret -o yaml
+func ReadsSecrets(args []string) bool {
+	positional := policy.Positional(args)
+	if len(positional) < 2 {
+		return false
+	}
+	for _, resource := range strings.Split(positional[1], ",") {
+		if policy.NormalizeResource(resource) == "secret" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package breaker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/filelock"
+	"github.com/multikubectl/pkg/reachability"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultStateFile = "breaker"
+	DefaultThreshold = 3
+	DefaultCooldown  = 5 * time.Minute
+)
+
+// Entry tracks consecutive failures of a single context
+type Entry struct {
+	Failures    int       `yaml:"failures"`
+	LastFailure time.Time `yaml:"lastFailure"`
+}
+
+// Breaker skips contexts that failed repeatedly in recent invocations.
+// State is persisted between runs so a dead cluster only costs the full
+// timeout a few times instead of on every command.
+type Breaker struct {
+	Contexts map[string]*Entry `yaml:"contexts,omitempty"`
+
+	threshold int
+	cooldown  time.Duration
+	reach     *reachability.Cache
+	// recorded are the outcomes recorded since the state was saved, replayed
+	// onto the state file by Save so other invocations' updates are kept
+	recorded []outcome
+}
+
+// outcome is whether a context was unreachable in a run
+type outcome struct {
+	context     string
+	unreachable bool
+	at          time.Time
+}
+
+// GetStatePath returns the path to the circuit breaker state file
+func GetStatePath() string {
+	return filepath.Join(config.GetConfigDir(), DefaultStateFile)
+}
+
+// Load loads the circuit breaker state from file
+// A threshold of 0 disables the breaker
+func Load(threshold int, cooldown time.Duration) (*Breaker, error) {
+	contexts, err := load()
+	if err != nil {
+		return nil, err
+	}
+	return &Breaker{
+		Contexts:  contexts,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}, nil
+}
+
+// load reads the entries of the state file
+func load() (map[string]*Entry, error) {
+	var state Breaker
+	data, err := os.ReadFile(GetStatePath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read breaker state: %w", err)
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("failed to parse breaker state: %w", err)
+		}
+	}
+	if state.Contexts == nil {
+		state.Contexts = make(map[string]*Entry)
+	}
+	return state.Contexts, nil
+}
+
+// Save records the outcomes since the last save in the state file. It holds
+// a lock on the file while reading, updating and replacing it, so concurrent
+// invocations don't overwrite each other's failures.
+func (b *Breaker) Save() error {
+	if len(b.recorded) == 0 {
+		return nil
+	}
+
+	path := GetStatePath()
+	unlock, err := filelock.Lock(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	contexts, err := load()
+	if err != nil {
+		return err
+	}
+	b.Contexts = contexts
+	for _, o := range b.recorded {
+		b.apply(o)
+	}
+
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to marshal breaker state: %w", err)
+	}
+	// Replace the file atomically so Load never reads a partial file
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write breaker state: %w", err)
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write breaker state: %w", err)
+	}
+
+	b.recorded = nil
+	return nil
+}
+
+// IsOpen reports whether the context should be skipped.
+// Once the cooldown has passed the context is allowed through again so a
+// recovered cluster is picked up automatically.
+func (b *Breaker) IsOpen(context string) bool {
+	if b.threshold <= 0 {
+		return false
+	}
+	entry, ok := b.Contexts[context]
+	if !ok || entry.Failures < b.threshold {
+		return false
+	}
+	// A check since the last failure, e.g. a probe, tells before the cooldown ends
+	if b.reach != nil {
+		if checked, ok := b.reach.Get(context); ok && checked.Checked.After(entry.LastFailure) {
+			return !checked.Reachable
+		}
+	}
+	return time.Since(entry.LastFailure) < b.cooldown
+}
+
+// SetReachability makes the breaker trust the reachability cache over the
+// cooldown for contexts checked after their last failure
+func (b *Breaker) SetReachability(cache *reachability.Cache) {
+	b.reach = cache
+}
+
+// Failures returns the number of consecutive failures recorded for a context
+func (b *Breaker) Failures(context string) int {
+	if entry, ok := b.Contexts[context]; ok {
+		return entry.Failures
+	}
+	return 0
+}
+
+// Filter splits contexts into those allowed to run and those skipped
+func (b *Breaker) Filter(contexts []string) (allowed []string, skipped []string) {
+	for _, ctx := range contexts {
+		if b.IsOpen(ctx) {
+			skipped = append(skipped, ctx)
+		} else {
+			allowed = append(allowed, ctx)
+		}
+	}
+	return allowed, skipped
+}
+
+// Record updates the failure counters from execution results
+// Only unreachable clusters count as failures; kubectl errors such as
+// NotFound mean the cluster answered and reset the counter.
+func (b *Breaker) Record(results []executor.Result) {
+	now := time.Now()
+	for _, r := range results {
+		o := outcome{context: r.Context, unreachable: r.Unreachable(), at: now}
+		_, tracked := b.Contexts[r.Context]
+		if o.unreachable || tracked {
+			b.apply(o)
+			b.recorded = append(b.recorded, o)
+		}
+	}
+}
+
+// apply updates the entry of a context with an outcome
+func (b *Breaker) apply(o outcome) {
+	if !o.unreachable {
+		delete(b.Contexts, o.context)
+		return
+	}
+	entry, ok := b.Contexts[o.context]
+	if !ok {
+		entry = &Entry{}
+		b.Contexts[o.context] = entry
+	}
+	entry.Failures++
+	entry.LastFailure = o.at
+}
@@ -0,0 +1,77 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// FileOf returns the kubeconfig file that defines a context. Contexts of
+// discovery providers and ephemeral clusters live in no file to edit.
+func (m *Manager) FileOf(context string) (string, error) {
+	ctx, ok := m.contexts[context]
+	if !ok {
+		return "", fmt.Errorf("context '%s' not found in kubeconfig", context)
+	}
+	if provider := m.DiscoveredBy(context); provider != "" {
+		return "", fmt.Errorf("context '%s' is managed by %s", context, provider)
+	}
+	if ctx.File == "" {
+		return "", fmt.Errorf("context '%s' is not defined in a kubeconfig file", context)
+	}
+	return ctx.File, nil
+}
+
+// EditKubeConfig loads the kubeconfig file at path, lets fn modify it and
+// writes it back, after copying the original next to it. Returns the path of
+// the backup.
+func EditKubeConfig(path string, fn func(*clientcmdapi.Config) error) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig %s: %w", path, err)
+	}
+	if err := fn(config); err != nil {
+		return "", err
+	}
+
+	backup, err := writeBackup(path, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up kubeconfig: %w", err)
+	}
+	if err := clientcmd.WriteToFile(*config, path); err != nil {
+		return "", fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	return backup, nil
+}
+
+// writeBackup writes data to a new file named after path and the time,
+// never replacing an earlier backup
+func writeBackup(path string, data []byte) (string, error) {
+	base := path + ".bak-" + time.Now().Format("20060102-150405")
+	for i := 0; ; i++ {
+		backup := base
+		if i > 0 {
+			backup = fmt.Sprintf("%s-%d", base, i)
+		}
+		// The kubeconfig holds credentials, so the backup is private as well
+		f, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return "", err
+		}
+		return backup, f.Close()
+	}
+}
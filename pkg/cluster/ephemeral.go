@@ -0,0 +1,172 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Ephemeral describes a cluster given on the command line or in a clusters
+// file rather than in a kubeconfig. It exists only for the duration of a run.
+type Ephemeral struct {
+	Name                  string `yaml:"name"`
+	Server                string `yaml:"server"`
+	Token                 string `yaml:"token,omitempty"`
+	TokenFile             string `yaml:"token-file,omitempty"`
+	CertificateAuthority  string `yaml:"certificate-authority,omitempty"`
+	InsecureSkipTLSVerify bool   `yaml:"insecure-skip-tls-verify,omitempty"`
+	Namespace             string `yaml:"namespace,omitempty"`
+}
+
+// ParseEphemeral parses an --extra-cluster value of the form
+// "name=https://api.example.com,token-file=token,certificate-authority=ca.crt".
+// Relative paths are relative to the working directory.
+func ParseEphemeral(spec string) (Ephemeral, error) {
+	parts := strings.Split(spec, ",")
+	name, server, ok := strings.Cut(parts[0], "=")
+	if !ok || name == "" || server == "" {
+		return Ephemeral{}, fmt.Errorf("invalid cluster %q: expected name=server[,key=value...]", spec)
+	}
+
+	e := Ephemeral{Name: name, Server: server}
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Ephemeral{}, fmt.Errorf("invalid cluster %q: expected key=value, got %q", name, part)
+		}
+		switch key {
+		case "token":
+			e.Token = value
+		case "token-file":
+			e.TokenFile = value
+		case "certificate-authority", "ca":
+			e.CertificateAuthority = value
+		case "insecure-skip-tls-verify", "insecure":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return Ephemeral{}, fmt.Errorf("invalid cluster %q: %s must be true or false", name, key)
+			}
+			e.InsecureSkipTLSVerify = b
+		case "namespace":
+			e.Namespace = value
+		default:
+			return Ephemeral{}, fmt.Errorf("invalid cluster %q: unknown key %q", name, key)
+		}
+	}
+	return e.absPaths("")
+}
+
+// absPaths makes the file paths of the cluster absolute, relative to dir or
+// the working directory, as the private kubeconfig kubectl reads them from
+// is elsewhere
+func (e Ephemeral) absPaths(dir string) (Ephemeral, error) {
+	for _, path := range []*string{&e.CertificateAuthority, &e.TokenFile} {
+		if *path == "" || filepath.IsAbs(*path) {
+			continue
+		}
+		abs, err := filepath.Abs(filepath.Join(dir, *path))
+		if err != nil {
+			return Ephemeral{}, fmt.Errorf("invalid cluster %q: %w", e.Name, err)
+		}
+		*path = abs
+	}
+	return e, nil
+}
+
+// LoadEphemeralFile reads ephemeral clusters from a YAML file with a
+// top-level "clusters" list. Relative paths are relative to the file.
+func LoadEphemeralFile(path string) ([]Ephemeral, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clusters file: %w", err)
+	}
+
+	var file struct {
+		Clusters []Ephemeral `yaml:"clusters"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse clusters file: %w", err)
+	}
+	for i, e := range file.Clusters {
+		if e.Name == "" || e.Server == "" {
+			return nil, fmt.Errorf("invalid clusters file %s: every cluster needs a name and server", path)
+		}
+		if file.Clusters[i], err = e.absPaths(filepath.Dir(path)); err != nil {
+			return nil, err
+		}
+	}
+	return file.Clusters, nil
+}
+
+// AddEphemeral adds temporary contexts for the given clusters.
+// Names must not collide with contexts in the kubeconfig.
+func (m *Manager) AddEphemeral(clusters []Ephemeral) error {
+	for _, e := range clusters {
+		if _, exists := m.contexts[e.Name]; exists {
+			return fmt.Errorf("cluster %q conflicts with an existing context of the same name", e.Name)
+		}
+		m.add(e.Name, ContextInfo{
+			Cluster:   e.Name,
+			Namespace: e.Namespace,
+			Server:    e.Server,
+			Auth:      authMethod(ephemeralAuth(e)),
+		})
+		m.ephemeral = append(m.ephemeral, e)
+	}
+	return nil
+}
+
+// EphemeralContexts returns the names of contexts added with AddEphemeral
+func (m *Manager) EphemeralContexts() []string {
+	names := make([]string, len(m.ephemeral))
+	for i, e := range m.ephemeral {
+		names[i] = e.Name
+	}
+	return names
+}
+
+// WriteEphemeralKubeConfig writes a private kubeconfig containing only the
+// ephemeral contexts so kubectl can use them. The caller removes the file.
+func (m *Manager) WriteEphemeralKubeConfig() (string, error) {
+	config := clientcmdapi.NewConfig()
+	for _, e := range m.ephemeral {
+		config.Clusters[e.Name] = ephemeralCluster(e)
+		config.AuthInfos[e.Name] = ephemeralAuth(e)
+		config.Contexts[e.Name] = &clientcmdapi.Context{Cluster: e.Name, AuthInfo: e.Name, Namespace: e.Namespace}
+	}
+
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode ephemeral kubeconfig: %w", err)
+	}
+
+	// CreateTemp creates the file with mode 0600, keeping tokens private
+	f, err := os.CreateTemp("", "multikubectl-*.kubeconfig")
+	if err != nil {
+		return "", fmt.Errorf("failed to write ephemeral kubeconfig: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write ephemeral kubeconfig: %w", err)
+	}
+	return f.Name(), nil
+}
+
+func ephemeralAuth(e Ephemeral) *clientcmdapi.AuthInfo {
+	return &clientcmdapi.AuthInfo{Token: e.Token, TokenFile: e.TokenFile}
+}
+
+func ephemeralCluster(e Ephemeral) *clientcmdapi.Cluster {
+	return &clientcmdapi.Cluster{
+		Server:                e.Server,
+		CertificateAuthority:  e.CertificateAuthority,
+		InsecureSkipTLSVerify: e.InsecureSkipTLSVerify,
+	}
+}
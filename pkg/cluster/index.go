@@ -0,0 +1,209 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/multikubectl/pkg/config"
+	"gopkg.in/yaml.v3"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// DefaultIndexFile caches the contexts of the kubeconfig, under ~/.multikube
+const DefaultIndexFile = "kubeconfig-index.json"
+
+// ContextInfo is what multikubectl needs to know of a kubeconfig context
+type ContextInfo struct {
+	Cluster   string `json:"cluster,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Server    string `json:"server,omitempty"`
+	ProxyURL  string `json:"proxyURL,omitempty"`
+	// Auth describes how the context's user authenticates, as GetAuthMethod
+	Auth string `json:"auth,omitempty"`
+	// File is the kubeconfig file defining the context
+	File string `json:"file,omitempty"`
+}
+
+// index is the context index of a kubeconfig, which may merge several files.
+// It is cached so that large kubeconfigs are only parsed again once one of
+// their files changes. The current context is not part of it: switching
+// contexts rewrites a file without necessarily changing its size or, within
+// the file system's timestamp resolution, its modification time.
+type index struct {
+	Files    []indexedFile          `json:"files"`
+	Contexts map[string]ContextInfo `json:"contexts"`
+	// Order lists the contexts in the order the kubeconfig files define them
+	Order []string `json:"order"`
+}
+
+// indexedFile identifies the version of a kubeconfig file an index was built
+// from. Files that don't exist are recorded with a size of -1.
+type indexedFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// GetIndexPath returns the path to the kubeconfig index cache
+func GetIndexPath() string {
+	return filepath.Join(config.GetConfigDir(), DefaultIndexFile)
+}
+
+// statFiles returns the current version of the given kubeconfig files
+func statFiles(paths []string) []indexedFile {
+	files := make([]indexedFile, len(paths))
+	for i, path := range paths {
+		files[i] = indexedFile{Path: path, Size: -1}
+		if info, err := os.Stat(path); err == nil {
+			files[i].Size = info.Size()
+			files[i].ModTime = info.ModTime()
+		}
+	}
+	return files
+}
+
+// loadIndex returns the cached index if it was built from files as they are
+// now, or nil
+func loadIndex(files []indexedFile) *index {
+	data, err := os.ReadFile(GetIndexPath())
+	if err != nil {
+		return nil
+	}
+	var idx index
+	if err := json.Unmarshal(data, &idx); err != nil || idx.Contexts == nil || len(idx.Order) != len(idx.Contexts) {
+		return nil
+	}
+	if !slices.EqualFunc(idx.Files, files, func(a, b indexedFile) bool {
+		return a.Path == b.Path && a.Size == b.Size && a.ModTime.Equal(b.ModTime)
+	}) {
+		return nil
+	}
+	return &idx
+}
+
+// currentContext reads the current-context of kubeconfig files without
+// loading them: the top-level current-context of the first file that sets
+// one, as kubectl merges them. yaml.v3 also reads JSON kubeconfigs.
+func currentContext(paths []string) string {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var kc struct {
+			CurrentContext string `yaml:"current-context"`
+		}
+		if yaml.Unmarshal(data, &kc) == nil && kc.CurrentContext != "" {
+			return kc.CurrentContext
+		}
+	}
+	return ""
+}
+
+// save writes the index to the cache, replacing it atomically so concurrent
+// invocations never read a partial file
+func (idx *index) save() error {
+	if err := os.MkdirAll(config.GetConfigDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal kubeconfig index: %w", err)
+	}
+	f, err := os.CreateTemp(config.GetConfigDir(), DefaultIndexFile+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write kubeconfig index: %w", err)
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), GetIndexPath())
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write kubeconfig index: %w", err)
+	}
+	return nil
+}
+
+// indexContexts returns the index entries of the contexts of a kubeconfig
+func indexContexts(kc *clientcmdapi.Config) map[string]ContextInfo {
+	contexts := make(map[string]ContextInfo, len(kc.Contexts))
+	for name, ctx := range kc.Contexts {
+		info := ContextInfo{
+			Cluster:   ctx.Cluster,
+			Namespace: ctx.Namespace,
+			Auth:      authMethod(kc.AuthInfos[ctx.AuthInfo]),
+			File:      ctx.LocationOfOrigin,
+		}
+		if cluster, ok := kc.Clusters[ctx.Cluster]; ok {
+			info.Server = cluster.Server
+			info.ProxyURL = cluster.ProxyURL
+		}
+		contexts[name] = info
+	}
+	return contexts
+}
+
+// contextOrder returns the contexts of a merged kubeconfig in the order its
+// files list them, which client-go's maps lose. The first file defining a
+// context wins, as when merging.
+func contextOrder(paths []string, contexts map[string]ContextInfo) []string {
+	order := make([]string, 0, len(contexts))
+	seen := make(map[string]bool, len(contexts))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var file struct {
+			Contexts []struct {
+				Name string `yaml:"name"`
+			} `yaml:"contexts"`
+		}
+		if yaml.Unmarshal(data, &file) != nil {
+			continue
+		}
+		for _, ctx := range file.Contexts {
+			if _, ok := contexts[ctx.Name]; ok && !seen[ctx.Name] {
+				seen[ctx.Name] = true
+				order = append(order, ctx.Name)
+			}
+		}
+	}
+	// Contexts the files could not be read back for go last
+	var rest []string
+	for name := range contexts {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	slices.Sort(rest)
+	return append(order, rest...)
+}
+
+// authMethod describes how a user authenticates, e.g. "exec (aws)"
+func authMethod(auth *clientcmdapi.AuthInfo) string {
+	switch {
+	case auth == nil:
+		return "none"
+	case auth.Exec != nil:
+		return fmt.Sprintf("exec (%s)", auth.Exec.Command)
+	case auth.AuthProvider != nil:
+		return fmt.Sprintf("auth provider (%s)", auth.AuthProvider.Name)
+	case auth.Token != "" || auth.TokenFile != "":
+		return "token"
+	case auth.ClientCertificate != "" || len(auth.ClientCertificateData) > 0:
+		return "client certificate"
+	case auth.Username != "":
+		return "basic"
+	default:
+		return "none"
+	}
+}
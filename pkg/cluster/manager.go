@@ -3,122 +3,233 @@ package cluster
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
 
-	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
-// KubeConfig represents the structure of a kubeconfig file
-type KubeConfig struct {
-	APIVersion     string          `yaml:"apiVersion"`
-	Kind           string          `yaml:"kind"`
-	CurrentContext string          `yaml:"current-context"`
-	Clusters       []ClusterEntry  `yaml:"clusters"`
-	Contexts       []ContextEntry  `yaml:"contexts"`
-	Users          []UserEntry     `yaml:"users"`
+// Manager manages multiple kubernetes clusters
+type Manager struct {
+	kubeConfigPath string
+	currentContext string
+	contexts       map[string]ContextInfo
+	// order lists the contexts in the order they were loaded
+	order []string
+	// paths are the kubeconfig files loaded, in order of precedence
+	paths     []string
+	ephemeral []Ephemeral
+	// discovered maps contexts found by discovery providers to the provider name
+	discovered map[string]string
+	// sources maps contexts loaded from extra kubeconfig files to their file
+	sources map[string]string
 }
 
-type ClusterEntry struct {
-	Name    string  `yaml:"name"`
-	Cluster Cluster `yaml:"cluster"`
+// NewManager creates a new cluster manager. An empty kubeConfigPath uses the
+// same lookup as kubectl: $KUBECONFIG (which may list several files to merge)
+// and then ~/.kube/config.
+func NewManager(kubeConfigPath string) (*Manager, error) {
+	m := &Manager{
+		kubeConfigPath: kubeConfigPath,
+	}
+
+	if err := m.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
 }
 
-type Cluster struct {
-	Server                   string `yaml:"server"`
-	CertificateAuthorityData string `yaml:"certificate-authority-data,omitempty"`
-	CertificateAuthority     string `yaml:"certificate-authority,omitempty"`
-	InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify,omitempty"`
+// loadConfig loads the contexts of the kubeconfig from the index cache, and
+// only parses the kubeconfig when one of its files changed since it was cached
+func (m *Manager) loadConfig() error {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = m.kubeConfigPath
+
+	paths := rules.GetLoadingPrecedence()
+	if m.kubeConfigPath != "" {
+		paths = []string{m.kubeConfigPath}
+	}
+	m.paths = paths
+	files := statFiles(paths)
+	if idx := loadIndex(files); idx != nil {
+		m.currentContext = currentContext(paths)
+		m.contexts = idx.Contexts
+		m.order = idx.Order
+		return nil
+	}
+
+	kc, err := rules.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	idx := &index{Files: files, Contexts: indexContexts(kc)}
+	idx.Order = contextOrder(paths, idx.Contexts)
+	// A kubeconfig that can't be cached is only slower to load next time
+	idx.save()
+
+	m.currentContext = kc.CurrentContext
+	m.contexts = idx.Contexts
+	m.order = idx.Order
+	return nil
 }
 
-type ContextEntry struct {
-	Name    string  `yaml:"name"`
-	Context Context `yaml:"context"`
+// Context returns what the kubeconfig defines of a context
+func (m *Manager) Context(context string) (ContextInfo, bool) {
+	info, ok := m.contexts[context]
+	return info, ok
 }
 
-type Context struct {
-	Cluster   string `yaml:"cluster"`
-	User      string `yaml:"user"`
-	Namespace string `yaml:"namespace,omitempty"`
+// GetContexts returns all available context names in the order of the
+// kubeconfig files, followed by those added later
+func (m *Manager) GetContexts() []string {
+	return slices.Clone(m.order)
 }
 
-type UserEntry struct {
-	Name string `yaml:"name"`
-	User User   `yaml:"user"`
+// add adds a context after those already loaded
+func (m *Manager) add(name string, info ContextInfo) {
+	m.contexts[name] = info
+	m.order = append(m.order, name)
 }
 
-type User struct {
-	ClientCertificateData string `yaml:"client-certificate-data,omitempty"`
-	ClientKeyData         string `yaml:"client-key-data,omitempty"`
-	ClientCertificate     string `yaml:"client-certificate,omitempty"`
-	ClientKey             string `yaml:"client-key,omitempty"`
-	Token                 string `yaml:"token,omitempty"`
+// AddKubeConfigFile merges the contexts of an extra kubeconfig file, such as
+// one generated by an import. Contexts already present are kept.
+func (m *Manager) AddKubeConfigFile(path string) error {
+	extra, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", path, err)
+	}
+	if m.sources == nil {
+		m.sources = make(map[string]string)
+	}
+	infos := indexContexts(extra)
+	for _, name := range contextOrder([]string{path}, infos) {
+		if _, exists := m.contexts[name]; exists {
+			continue
+		}
+		m.add(name, infos[name])
+		m.sources[name] = path
+	}
+	return nil
 }
 
-// Manager manages multiple kubernetes clusters
-type Manager struct {
-	kubeConfigPath string
-	config         *KubeConfig
+// KubeConfigFor returns the extra kubeconfig file a context was loaded from,
+// or "" for contexts from the main kubeconfig
+func (m *Manager) KubeConfigFor(context string) string {
+	return m.sources[context]
 }
 
-// NewManager creates a new cluster manager
-func NewManager(kubeConfigPath string) (*Manager, error) {
-	if kubeConfigPath == "" {
-		kubeConfigPath = getDefaultKubeConfigPath()
+// AddDiscovered adds contexts found by a discovery provider. They are listed
+// like kubeconfig contexts but only become usable once the provider logs in.
+// Names already in the kubeconfig are skipped.
+func (m *Manager) AddDiscovered(provider string, contexts []string) {
+	if m.discovered == nil {
+		m.discovered = make(map[string]string)
 	}
-
-	m := &Manager{
-		kubeConfigPath: kubeConfigPath,
+	for _, ctx := range contexts {
+		if _, exists := m.contexts[ctx]; exists {
+			continue
+		}
+		m.add(ctx, ContextInfo{Auth: authMethod(nil)})
+		m.discovered[ctx] = provider
 	}
+}
 
-	if err := m.loadConfig(); err != nil {
-		return nil, err
-	}
+// DiscoveredBy returns the provider that discovered a context, or "" for kubeconfig contexts
+func (m *Manager) DiscoveredBy(context string) string {
+	return m.discovered[context]
+}
 
-	return m, nil
+// GetServer returns the API server URL of a context's cluster, or "" if unknown
+func (m *Manager) GetServer(context string) string {
+	return m.contexts[context].Server
 }
 
-func getDefaultKubeConfigPath() string {
-	if envPath := os.Getenv("KUBECONFIG"); envPath != "" {
-		return envPath
-	}
-	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".kube", "config")
+// GetProxyURL returns the proxy-url of a context's cluster, or "" if none is set
+func (m *Manager) GetProxyURL(context string) string {
+	return m.contexts[context].ProxyURL
 }
 
-func (m *Manager) loadConfig() error {
-	data, err := os.ReadFile(m.kubeConfigPath)
+// GetAuthMethod describes how a context's user authenticates, e.g.
+// "exec (aws)" or "client certificate", or "" if the context is unknown
+func (m *Manager) GetAuthMethod(context string) string {
+	return m.contexts[context].Auth
+}
+
+// ClusterWithServer returns the cluster of a context, as its kubeconfig file
+// defines it, with another server, e.g. the local end of an SSH tunnel, and
+// the name to verify the server's certificate against
+func (m *Manager) ClusterWithServer(context, server, serverName string) (string, *clientcmdapi.Cluster, error) {
+	info, ok := m.contexts[context]
+	if !ok || info.File == "" {
+		return "", nil, fmt.Errorf("context %q is not defined in a kubeconfig file", context)
+	}
+	kc, err := clientcmd.LoadFromFile(info.File)
 	if err != nil {
-		return fmt.Errorf("failed to read kubeconfig: %w", err)
+		return "", nil, fmt.Errorf("failed to load kubeconfig %s: %w", info.File, err)
 	}
-
-	var config KubeConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return fmt.Errorf("failed to parse kubeconfig: %w", err)
+	// Certificate files are relative to the kubeconfig file
+	if err := clientcmd.ResolveLocalPaths(kc); err != nil {
+		return "", nil, fmt.Errorf("failed to load kubeconfig %s: %w", info.File, err)
 	}
-
-	m.config = &config
-	return nil
+	cluster, ok := kc.Clusters[info.Cluster]
+	if !ok {
+		return "", nil, fmt.Errorf("cluster %q of context %q not found in %s", info.Cluster, context, info.File)
+	}
+	cluster.Server = server
+	cluster.TLSServerName = serverName
+	return info.Cluster, cluster, nil
 }
 
-// GetContexts returns all available context names
-func (m *Manager) GetContexts() []string {
-	var contexts []string
-	for _, ctx := range m.config.Contexts {
-		contexts = append(contexts, ctx.Name)
+// WriteTunnelKubeConfig writes a private kubeconfig for commands that can't
+// take kubectl's --server flag: it sets the current context to context and
+// redefines the context's cluster with server. Put it first in KUBECONFIG,
+// before the file defining the context. The caller removes the file.
+func (m *Manager) WriteTunnelKubeConfig(context, server, serverName string) (string, error) {
+	name, cluster, err := m.ClusterWithServer(context, server, serverName)
+	if err != nil {
+		return "", err
+	}
+	config := clientcmdapi.NewConfig()
+	config.CurrentContext = context
+	config.Clusters[name] = cluster
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the kubeconfig of %s: %w", context, err)
 	}
-	return contexts
+
+	f, err := os.CreateTemp("", "multikubectl-*.kubeconfig")
+	if err != nil {
+		return "", fmt.Errorf("failed to write the kubeconfig of %s: %w", context, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write the kubeconfig of %s: %w", context, err)
+	}
+	return f.Name(), nil
 }
 
 // GetCurrentContext returns the current context name
 func (m *Manager) GetCurrentContext() string {
-	return m.config.CurrentContext
+	return m.currentContext
 }
 
-// GetKubeConfigPath returns the kubeconfig file path
+// GetKubeConfigPath returns the kubeconfig path given explicitly, or "" when
+// kubectl should resolve it (and merge $KUBECONFIG) itself. Use
+// GetKubeConfigPaths for the files actually loaded.
 func (m *Manager) GetKubeConfigPath() string {
 	return m.kubeConfigPath
 }
 
+// GetKubeConfigPaths returns the kubeconfig files the contexts were loaded
+// from, in order of precedence: the explicit path, the files of $KUBECONFIG
+// or ~/.kube/config
+func (m *Manager) GetKubeConfigPaths() []string {
+	return slices.Clone(m.paths)
+}
+
 // FilterContexts filters contexts based on the provided list
 // If contexts is empty, returns all contexts
 func (m *Manager) FilterContexts(contexts []string) []string {
@@ -126,14 +237,9 @@ func (m *Manager) FilterContexts(contexts []string) []string {
 		return m.GetContexts()
 	}
 
-	availableContexts := make(map[string]bool)
-	for _, ctx := range m.config.Contexts {
-		availableContexts[ctx.Name] = true
-	}
-
 	var filtered []string
 	for _, ctx := range contexts {
-		if availableContexts[ctx] {
+		if _, ok := m.contexts[ctx]; ok {
 			filtered = append(filtered, ctx)
 		}
 	}
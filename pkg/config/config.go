@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -19,6 +22,195 @@ type MultiKubeConfig struct {
 	Contexts []string `yaml:"contexts,omitempty"`
 	// KubeConfig is the path to the kubeconfig file (optional)
 	KubeConfig string `yaml:"kubeconfig,omitempty"`
+	// RateLimit is the default client-side rate limit applied to each context
+	RateLimit *RateLimit `yaml:"rateLimit,omitempty"`
+	// Theme configures output colors
+	Theme *Theme `yaml:"theme,omitempty"`
+	// Groups maps group names to members, which are contexts or other groups
+	Groups map[string][]string `yaml:"groups,omitempty"`
+	// GroupPolicies sets how the contexts of a group are run, keyed by group name
+	GroupPolicies map[string]*GroupPolicy `yaml:"groupPolicies,omitempty"`
+	// VerbRestrictions limits kubectl verbs to the listed contexts or groups,
+	// e.g. {delete: [sandbox]}. Verbs not listed may run anywhere.
+	VerbRestrictions map[string][]string `yaml:"verbRestrictions,omitempty"`
+	// PolicyFile is the policy evaluated before each command (default ~/.multikube/policy.yaml)
+	PolicyFile string `yaml:"policyFile,omitempty"`
+	// CheckNamespace checks that the namespace given with -n exists in each
+	// context before running: "warn" or "skip" (default: no check)
+	CheckNamespace string `yaml:"checkNamespace,omitempty"`
+	// CheckExists checks that the objects named by patch, scale, label, ...
+	// exist in each context before running: "warn" or "skip" (default: no check)
+	CheckExists string `yaml:"checkExists,omitempty"`
+	// Timeouts maps kubectl verbs, or a verb and its subcommand such as
+	// "rollout status", to the timeout their commands run with unless
+	// --timeout is given, e.g. "5m" for apply or "0" for no timeout
+	Timeouts map[string]string `yaml:"timeouts,omitempty"`
+	// Heartbeat is the interval of the line printed per cluster still running,
+	// e.g. "30s" (default: 1m for long-running commands when stderr is not a
+	// terminal, "0" disables)
+	Heartbeat string `yaml:"heartbeat,omitempty"`
+	// Audit configures the log of executed commands
+	Audit *Audit `yaml:"audit,omitempty"`
+	// History configures the recorded outputs used by "multikubectl replay"
+	History *History `yaml:"history,omitempty"`
+	// Logs configures the limits applied to logs commands that set none
+	Logs *Logs `yaml:"logs,omitempty"`
+	// ContextSettings holds per-context overrides keyed by context name
+	ContextSettings map[string]*ContextSettings `yaml:"contextSettings,omitempty"`
+	// KubeConfigs lists extra kubeconfig files whose contexts are merged in,
+	// e.g. files generated by "multikubectl import"
+	KubeConfigs []string `yaml:"kubeconfigs,omitempty"`
+	// Teleport discovers clusters from "tsh kube ls" when set
+	Teleport *Teleport `yaml:"teleport,omitempty"`
+	// VCluster discovers virtual clusters on host contexts when set
+	VCluster *VCluster `yaml:"vcluster,omitempty"`
+}
+
+// Audit configures the append-only audit log. It is written by default.
+type Audit struct {
+	// Disabled turns the audit log off
+	Disabled bool `yaml:"disabled,omitempty"`
+	// Path is the log file (default ~/.multikube/audit.log)
+	Path string `yaml:"path,omitempty"`
+	// MaxSizeMB rotates the log once it grows past this size (default 10)
+	MaxSizeMB int `yaml:"maxSizeMB,omitempty"`
+	// MaxFiles is the number of rotated files kept (default 5)
+	MaxFiles int `yaml:"maxFiles,omitempty"`
+}
+
+// History configures the recorded runs kept for replay. Runs are recorded by default.
+type History struct {
+	// Disabled stops recording runs
+	Disabled bool `yaml:"disabled,omitempty"`
+	// Limit is the number of runs kept (default 100)
+	Limit int `yaml:"limit,omitempty"`
+}
+
+// DefaultLogTail is the number of lines per container logs commands print
+// when neither the command nor the config sets a limit
+const DefaultLogTail = 200
+
+// Logs configures the limits added to logs commands without --tail, --since
+// or --since-time, so a fleet-wide logs doesn't dump every cluster's history
+type Logs struct {
+	// Tail is the --tail added (default 200, -1 for no limit)
+	Tail int `yaml:"tail,omitempty"`
+	// Since is the --since added, e.g. "1h" (default: none)
+	Since string `yaml:"since,omitempty"`
+}
+
+// VCluster configures discovery of virtual clusters
+type VCluster struct {
+	// Enabled turns on discovery
+	Enabled bool `yaml:"enabled"`
+	// Hosts are the host contexts to search (default: all kubeconfig contexts)
+	Hosts []string `yaml:"hosts,omitempty"`
+	// Binary is the path to the vcluster CLI (default "vcluster" from PATH)
+	Binary string `yaml:"binary,omitempty"`
+}
+
+// Teleport configures discovery of Teleport-fronted clusters
+type Teleport struct {
+	// Enabled turns on discovery
+	Enabled bool `yaml:"enabled"`
+	// Proxy is the Teleport proxy address (defaults to the current tsh profile)
+	Proxy string `yaml:"proxy,omitempty"`
+	// Cluster is the Teleport cluster (defaults to the current tsh profile)
+	Cluster string `yaml:"cluster,omitempty"`
+	// Prefix is prepended to kube cluster names to form context names (default "tsh-")
+	Prefix string `yaml:"prefix,omitempty"`
+	// Binary is the path to tsh (default "tsh" from PATH)
+	Binary string `yaml:"binary,omitempty"`
+}
+
+// Group execution modes
+const (
+	GroupParallel = "parallel"
+	GroupSerial   = "serial"
+)
+
+// GroupPolicy configures how many contexts of a group run at the same time
+type GroupPolicy struct {
+	// Mode is GroupParallel (the default) or GroupSerial, which runs the
+	// group's contexts one at a time in order
+	Mode string `yaml:"mode,omitempty"`
+	// MaxParallel caps the contexts of the group running at the same time (0: no cap)
+	MaxParallel int `yaml:"maxParallel,omitempty"`
+}
+
+// Limit returns how many contexts of the group may run at the same time, or 0 for no limit
+func (p *GroupPolicy) Limit() int {
+	if p.Mode == GroupSerial {
+		return 1
+	}
+	return p.MaxParallel
+}
+
+// RateLimit configures client-side request limits
+type RateLimit struct {
+	QPS   float64 `yaml:"qps,omitempty"`
+	Burst int     `yaml:"burst,omitempty"`
+}
+
+// Theme configures output colors. Colors are names ("red", "bold cyan",
+// "bright-blue") or raw ANSI codes ("38;5;208").
+type Theme struct {
+	// Preset is the base theme: default, colorblind, or none
+	Preset  string `yaml:"preset,omitempty"`
+	Cluster string `yaml:"cluster,omitempty"`
+	Header  string `yaml:"header,omitempty"`
+	Error   string `yaml:"error,omitempty"`
+	Warning string `yaml:"warning,omitempty"`
+	// Clusters are the colors cycled through for the cluster prefix of logs
+	Clusters []string `yaml:"clusters,omitempty"`
+}
+
+// ContextSettings holds settings that apply to a single context
+type ContextSettings struct {
+	// Alias is a short name for the context, exported with the inventory
+	Alias string `yaml:"alias,omitempty"`
+	// Labels are arbitrary key/value pairs matched by --context-selector
+	Labels map[string]string `yaml:"labels,omitempty"`
+	// Vars are substituted for ${NAME} in manifests piped on stdin
+	Vars map[string]string `yaml:"vars,omitempty"`
+	// RateLimit overrides the default rate limit for this context
+	RateLimit *RateLimit `yaml:"rateLimit,omitempty"`
+	// Tunnel reaches the API server through an SSH jump host
+	Tunnel *Tunnel `yaml:"tunnel,omitempty"`
+	// Proxy is the HTTP(S) proxy used to reach the API server
+	Proxy *Proxy `yaml:"proxy,omitempty"`
+	// Impersonate runs this context's commands as another user (overrides --as/--as-group)
+	Impersonate *Impersonate `yaml:"impersonate,omitempty"`
+	// Priority orders the target contexts: higher priorities run first in
+	// serial mode and waves and are listed first, equal ones keep their order
+	Priority int `yaml:"priority,omitempty"`
+	// Secondary is the context running this context's commands with --failover
+	// when this one can't be reached
+	Secondary string `yaml:"secondary,omitempty"`
+}
+
+// Impersonate configures the user and groups passed to kubectl as --as/--as-group
+type Impersonate struct {
+	User   string   `yaml:"user,omitempty"`
+	Groups []string `yaml:"groups,omitempty"`
+}
+
+// Proxy configures the HTTP(S) proxy for a context. A proxy-url set on the
+// cluster in the kubeconfig takes precedence.
+type Proxy struct {
+	// URL is the proxy address, e.g. "http://proxy.corp:3128"
+	URL string `yaml:"url"`
+	// NoProxy lists hosts that bypass the proxy
+	NoProxy []string `yaml:"noProxy,omitempty"`
+}
+
+// Tunnel configures an SSH port forward to a context's API server
+type Tunnel struct {
+	// Host is the jump host, e.g. "user@bastion.example.com"
+	Host         string `yaml:"host"`
+	Port         int    `yaml:"port,omitempty"`
+	LocalPort    int    `yaml:"localPort,omitempty"`
+	IdentityFile string `yaml:"identityFile,omitempty"`
 }
 
 // GetConfigPath returns the path to the multikube config file
@@ -101,6 +293,70 @@ func (c *MultiKubeConfig) RemoveContext(context string) bool {
 	return false
 }
 
+// RenameContext replaces a context's name wherever the configuration refers
+// to it: the context list, groups, verb restrictions, its settings and the
+// secondaries of other contexts. Returns whether anything changed.
+func (c *MultiKubeConfig) RenameContext(old, new string) bool {
+	changed := false
+	rename := func(names []string) {
+		for i, name := range names {
+			if name == old {
+				names[i] = new
+				changed = true
+			}
+		}
+	}
+	rename(c.Contexts)
+	for _, members := range c.Groups {
+		rename(members)
+	}
+	for _, allowed := range c.VerbRestrictions {
+		rename(allowed)
+	}
+	for _, settings := range c.ContextSettings {
+		if settings != nil && settings.Secondary == old {
+			settings.Secondary = new
+			changed = true
+		}
+	}
+	if settings, ok := c.ContextSettings[old]; ok {
+		delete(c.ContextSettings, old)
+		c.ContextSettings[new] = settings
+		changed = true
+	}
+	return changed
+}
+
+// DeleteContext drops every reference the configuration makes to a context
+// that no longer exists, unlike RemoveContext which only stops using it.
+// Returns whether anything changed.
+func (c *MultiKubeConfig) DeleteContext(context string) bool {
+	changed := false
+	drop := func(names []string) []string {
+		kept := slices.DeleteFunc(names, func(name string) bool { return name == context })
+		changed = changed || len(kept) < len(names)
+		return kept
+	}
+	c.Contexts = drop(c.Contexts)
+	for name, members := range c.Groups {
+		c.Groups[name] = drop(members)
+	}
+	for verb, allowed := range c.VerbRestrictions {
+		c.VerbRestrictions[verb] = drop(allowed)
+	}
+	for _, settings := range c.ContextSettings {
+		if settings != nil && settings.Secondary == context {
+			settings.Secondary = ""
+			changed = true
+		}
+	}
+	if _, ok := c.ContextSettings[context]; ok {
+		delete(c.ContextSettings, context)
+		changed = true
+	}
+	return changed
+}
+
 // HasContext checks if a context exists in the configuration
 func (c *MultiKubeConfig) HasContext(context string) bool {
 	for _, ctx := range c.Contexts {
@@ -120,3 +376,69 @@ func (c *MultiKubeConfig) Clear() {
 func (c *MultiKubeConfig) SetContexts(contexts []string) {
 	c.Contexts = contexts
 }
+
+// AddKubeConfig registers an extra kubeconfig file
+func (c *MultiKubeConfig) AddKubeConfig(path string) {
+	if !slices.Contains(c.KubeConfigs, path) {
+		c.KubeConfigs = append(c.KubeConfigs, path)
+	}
+}
+
+// SettingsFor returns the settings for a context, or an empty value if none are configured
+func (c *MultiKubeConfig) SettingsFor(context string) ContextSettings {
+	if s, ok := c.ContextSettings[context]; ok && s != nil {
+		return *s
+	}
+	return ContextSettings{}
+}
+
+// ExpandContexts replaces group names in names with the contexts they contain,
+// recursively, keeping the first occurrence of each context. Names that are not
+// groups are returned as-is. It fails if groups reference each other in a cycle.
+func (c *MultiKubeConfig) ExpandContexts(names []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if err := c.expand(name, nil, seen, &result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// expand appends the contexts of name to result; path holds the groups being expanded
+func (c *MultiKubeConfig) expand(name string, path []string, seen map[string]bool, result *[]string) error {
+	members, isGroup := c.Groups[name]
+	if !isGroup {
+		if !seen[name] {
+			seen[name] = true
+			*result = append(*result, name)
+		}
+		return nil
+	}
+
+	if i := slices.Index(path, name); i >= 0 {
+		cycle := append(slices.Clone(path[i:]), name)
+		return fmt.Errorf("group cycle: %s", strings.Join(cycle, " -> "))
+	}
+	path = append(path, name)
+	for _, member := range members {
+		if err := c.expand(member, path, seen, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GroupsOf returns the sorted names of all groups containing context, directly or through nested groups
+func (c *MultiKubeConfig) GroupsOf(context string) []string {
+	var groups []string
+	for name := range c.Groups {
+		members, err := c.ExpandContexts([]string{name})
+		if err == nil && slices.Contains(members, context) {
+			groups = append(groups, name)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
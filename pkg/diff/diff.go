@@ -0,0 +1,231 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// None is shown for a field that is absent on one side
+	None = "<none>"
+	// maxValueLength limits how much of a long value is shown in a change
+	maxValueLength = 120
+)
+
+// Change is a single field-level difference between two objects
+type Change struct {
+	Path     string
+	Baseline string
+	Actual   string
+}
+
+// ignoredMetadata are server-populated metadata fields that differ between
+// clusters even when the objects are otherwise identical
+var ignoredMetadata = []string{
+	"uid", "resourceVersion", "generation", "creationTimestamp",
+	"managedFields", "selfLink", "ownerReferences",
+}
+
+// ignoredAnnotations are annotations written by tooling rather than users
+var ignoredAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+}
+
+// Normalize removes status and server-populated fields from an object so
+// that only user-controlled configuration is compared
+func Normalize(obj map[string]interface{}) map[string]interface{} {
+	delete(obj, "status")
+
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		return obj
+	}
+	for _, field := range ignoredMetadata {
+		delete(metadata, field)
+	}
+	if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+		for _, annotation := range ignoredAnnotations {
+			delete(annotations, annotation)
+		}
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+	return obj
+}
+
+// Compare returns the field-level differences between baseline and actual.
+// With subset, only fields present in baseline are compared, which suits
+// local manifests that omit server-defaulted fields.
+func Compare(baseline, actual interface{}, subset bool) []Change {
+	var changes []Change
+	compare("", baseline, actual, subset, &changes)
+	return changes
+}
+
+func compare(path string, a, b interface{}, subset bool, changes *[]Change) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			for _, key := range unionKeys(av, bv, subset) {
+				aChild, aOK := av[key]
+				bChild, bOK := bv[key]
+				childPath := path + formatKey(key)
+				switch {
+				case !aOK:
+					*changes = append(*changes, Change{Path: childPath, Baseline: None, Actual: FormatValue(bChild)})
+				case !bOK:
+					*changes = append(*changes, Change{Path: childPath, Baseline: FormatValue(aChild), Actual: None})
+				default:
+					compare(childPath, aChild, bChild, subset, changes)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			n := len(av)
+			if !subset && len(bv) > n {
+				n = len(bv)
+			}
+			for i := 0; i < n; i++ {
+				childPath := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(av):
+					*changes = append(*changes, Change{Path: childPath, Baseline: None, Actual: FormatValue(bv[i])})
+				case i >= len(bv):
+					*changes = append(*changes, Change{Path: childPath, Baseline: FormatValue(av[i]), Actual: None})
+				default:
+					compare(childPath, av[i], bv[i], subset, changes)
+				}
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, Baseline: FormatValue(a), Actual: FormatValue(b)})
+	}
+}
+
+func unionKeys(a, b map[string]interface{}, subset bool) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for k := range a {
+		seen[k] = true
+		keys = append(keys, k)
+	}
+	if !subset {
+		for k := range b {
+			if !seen[k] {
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var simpleKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// formatKey renders a map key as a path segment, quoting keys like "app.kubernetes.io/name"
+func formatKey(key string) string {
+	if simpleKey.MatchString(key) {
+		return "." + key
+	}
+	return fmt.Sprintf("[%q]", key)
+}
+
+// FormatValue renders a value compactly for display in a change
+func FormatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return truncate(s)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return truncate(string(data))
+}
+
+func truncate(s string) string {
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	if len(s) > maxValueLength {
+		return s[:maxValueLength-3] + "..."
+	}
+	return s
+}
+
+// DecodeDocuments parses a multi-document YAML or JSON stream into objects
+// with JSON-compatible value types, skipping empty documents
+func DecodeDocuments(data []byte) ([]map[string]interface{}, error) {
+	var objects []map[string]interface{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if doc == nil {
+			continue
+		}
+
+		// Round-trip through JSON so numbers and maps match objects fetched from clusters
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert manifest: %w", err)
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, fmt.Errorf("manifest document is not an object: %w", err)
+		}
+		if list, ok := obj["items"].([]interface{}); ok && strings.HasSuffix(fmt.Sprint(obj["kind"]), "List") {
+			for _, item := range list {
+				if itemObj, ok := item.(map[string]interface{}); ok {
+					objects = append(objects, itemObj)
+				}
+			}
+			continue
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// ResourceRef returns the kubectl reference for an object, e.g. "deployment.apps/nginx"
+func ResourceRef(obj map[string]interface{}) (string, error) {
+	kind, _ := obj["kind"].(string)
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	if kind == "" || name == "" {
+		return "", fmt.Errorf("object is missing kind or metadata.name")
+	}
+
+	resource := strings.ToLower(kind)
+	if apiVersion, _ := obj["apiVersion"].(string); strings.Contains(apiVersion, "/") {
+		resource += "." + apiVersion[:strings.Index(apiVersion, "/")]
+	}
+	return resource + "/" + name, nil
+}
+
+// Namespace returns the namespace of an object, or "" if unset
+func Namespace(obj map[string]interface{}) string {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	namespace, _ := metadata["namespace"].(string)
+	return namespace
+}
@@ -0,0 +1,50 @@
+package diff
+
+import "reflect"
+
+// MergePatch computes the JSON merge patch (RFC 7386) that turns original
+// into modified. Removed fields are set to null; lists are replaced whole.
+func MergePatch(original, modified map[string]interface{}) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for key, value := range modified {
+		old, ok := original[key]
+		if ok && reflect.DeepEqual(old, value) {
+			continue
+		}
+		oldMap, oldIsMap := old.(map[string]interface{})
+		newMap, newIsMap := value.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			patch[key] = MergePatch(oldMap, newMap)
+			continue
+		}
+		patch[key] = value
+	}
+	for key := range original {
+		if _, ok := modified[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	return patch
+}
+
+// ApplyMergePatch returns a copy of target with a JSON merge patch applied,
+// as the API server would apply it
+func ApplyMergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(target))
+	for key, value := range target {
+		result[key] = value
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		if patchMap, ok := value.(map[string]interface{}); ok {
+			targetMap, _ := result[key].(map[string]interface{})
+			result[key] = ApplyMergePatch(targetMap, patchMap)
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContextLines is the number of unchanged lines shown around each change
+const DefaultContextLines = 3
+
+// opKind is the kind of a line in an edit script
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type lineOp struct {
+	kind opKind
+	text string
+	// aLine and bLine are the 0-based line numbers before and after this op
+	aLine, bLine int
+}
+
+// Unified returns a unified diff between a and b, or "" if they are equal
+func Unified(aName, bName, a, b string, contextLines int) string {
+	if a == b {
+		return ""
+	}
+
+	ops := editScript(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+
+	for i := 0; i < len(ops); {
+		// Find the next change
+		for i < len(ops) && ops[i].kind == opEqual {
+			i++
+		}
+		if i >= len(ops) {
+			break
+		}
+
+		// Extend the hunk while changes are within 2*contextLines of each other
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*contextLines {
+				end += min(contextLines, run-end)
+				break
+			}
+			end = run
+		}
+
+		writeHunk(&out, ops[start:end])
+		i = end
+	}
+
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, ops []lineOp) {
+	aCount, bCount := 0, 0
+	for _, op := range ops {
+		if op.kind != opInsert {
+			aCount++
+		}
+		if op.kind != opDelete {
+			bCount++
+		}
+	}
+	aStart, bStart := ops[0].aLine+1, ops[0].bLine+1
+	if aCount == 0 {
+		aStart--
+	}
+	if bCount == 0 {
+		bStart--
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+
+	for _, op := range ops {
+		switch op.kind {
+		case opEqual:
+			out.WriteString(" ")
+		case opDelete:
+			out.WriteString("-")
+		case opInsert:
+			out.WriteString("+")
+		}
+		out.WriteString(op.text)
+		out.WriteString("\n")
+	}
+}
+
+// editScript computes a minimal line edit script using the longest common subsequence
+func editScript(a, b []string) []lineOp {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []lineOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, lineOp{kind: opEqual, text: a[i], aLine: i, bLine: j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, lineOp{kind: opDelete, text: a[i], aLine: i, bLine: j})
+			i++
+		default:
+			ops = append(ops, lineOp{kind: opInsert, text: b[j], aLine: i, bLine: j})
+			j++
+		}
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,14 @@
+package discovery
+
+// Provider discovers clusters from an external system and materializes
+// kubeconfig contexts for them on demand
+type Provider interface {
+	// Name identifies the provider in messages, e.g. "teleport"
+	Name() string
+	// Contexts lists the context names of all discovered clusters
+	Contexts() ([]string, error)
+	// Login makes the context usable by kubectl, writing it to KubeConfig if needed
+	Login(context string) error
+	// KubeConfig is the kubeconfig file the provider writes its contexts to
+	KubeConfig() string
+}
@@ -0,0 +1,105 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// DefaultTeleportPrefix is prepended to Teleport kube cluster names to form context names
+const DefaultTeleportPrefix = "tsh-"
+
+// Teleport discovers Kubernetes clusters with "tsh kube ls" and logs into them
+// with "tsh kube login", keeping the contexts in a private kubeconfig so the
+// user's own kubeconfig and current context are untouched
+type Teleport struct {
+	// Binary is the tsh executable
+	Binary string
+	// Proxy and Cluster select the Teleport proxy and cluster (optional)
+	Proxy   string
+	Cluster string
+	// Prefix is prepended to kube cluster names to form context names
+	Prefix     string
+	kubeConfig string
+}
+
+// NewTeleport creates a Teleport provider storing contexts in kubeConfig
+func NewTeleport(kubeConfig string) *Teleport {
+	return &Teleport{
+		Binary:     "tsh",
+		Prefix:     DefaultTeleportPrefix,
+		kubeConfig: kubeConfig,
+	}
+}
+
+func (t *Teleport) Name() string {
+	return "teleport"
+}
+
+func (t *Teleport) KubeConfig() string {
+	return t.kubeConfig
+}
+
+// Contexts lists the kube clusters visible to the current tsh session
+func (t *Teleport) Contexts() ([]string, error) {
+	out, err := t.tsh("kube", "ls", "--format=json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("tsh kube ls failed: %w", exitError(err))
+	}
+
+	var clusters []struct {
+		Name string `json:"kube_cluster_name"`
+	}
+	if err := json.Unmarshal(out, &clusters); err != nil {
+		return nil, fmt.Errorf("failed to parse tsh kube ls output: %w", err)
+	}
+
+	contexts := make([]string, 0, len(clusters))
+	for _, c := range clusters {
+		contexts = append(contexts, t.Prefix+c.Name)
+	}
+	return contexts, nil
+}
+
+// Login runs "tsh kube login" for the context unless it is already in the private kubeconfig
+func (t *Teleport) Login(context string) error {
+	if config, err := clientcmd.LoadFromFile(t.kubeConfig); err == nil {
+		if _, ok := config.Contexts[context]; ok {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(t.kubeConfig), 0700); err != nil {
+		return fmt.Errorf("failed to create teleport kubeconfig directory: %w", err)
+	}
+	name := strings.TrimPrefix(context, t.Prefix)
+	cmd := t.tsh("kube", "login", name, "--set-context-name", context)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+t.kubeConfig)
+	if _, err := cmd.Output(); err != nil {
+		return fmt.Errorf("tsh kube login %s failed: %w", name, exitError(err))
+	}
+	return nil
+}
+
+func (t *Teleport) tsh(args ...string) *exec.Cmd {
+	if t.Proxy != "" {
+		args = append(args, "--proxy", t.Proxy)
+	}
+	if t.Cluster != "" {
+		args = append(args, "--cluster", t.Cluster)
+	}
+	return exec.Command(t.Binary, args...)
+}
+
+// exitError includes the stderr of a failed command in its error
+func exitError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
@@ -0,0 +1,285 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// VClusterListTTL is how long the virtual clusters found on a host are
+// reused before the host is searched again
+const VClusterListTTL = 5 * time.Minute
+
+// VCluster discovers virtual clusters running on host contexts, using the
+// vcluster CLI when installed and the "app=vcluster" label otherwise.
+// Contexts are named like vcluster's own: vcluster_<name>_<namespace>_<host>.
+type VCluster struct {
+	// Binary is the vcluster executable
+	Binary string
+	// Hosts are the host contexts to search
+	Hosts []string
+	// KubeConfigPath is the KUBECONFIG of the host contexts, which may list
+	// several files ("" for kubectl's default)
+	KubeConfigPath string
+	// HostKubeConfigs are the kubeconfig files of hosts defined outside
+	// KubeConfigPath, e.g. by an import
+	HostKubeConfigs map[string]string
+	kubeConfig      string
+	found           map[string]virtualCluster
+}
+
+type virtualCluster struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Host      string `json:"host"`
+}
+
+// hostListing is the cached search of a host
+type hostListing struct {
+	Checked time.Time        `json:"checked"`
+	Found   []virtualCluster `json:"found"`
+}
+
+func (v virtualCluster) context() string {
+	return fmt.Sprintf("vcluster_%s_%s_%s", v.Name, v.Namespace, v.Host)
+}
+
+// NewVCluster creates a vcluster provider searching hosts and storing contexts in kubeConfig
+func NewVCluster(hosts []string, kubeConfig string) *VCluster {
+	return &VCluster{
+		Binary:     "vcluster",
+		Hosts:      hosts,
+		kubeConfig: kubeConfig,
+		found:      make(map[string]virtualCluster),
+	}
+}
+
+func (v *VCluster) Name() string {
+	return "vcluster"
+}
+
+func (v *VCluster) KubeConfig() string {
+	return v.kubeConfig
+}
+
+// Contexts searches the host contexts in parallel, reusing the searches of
+// the last VClusterListTTL. Hosts that can't be searched are skipped so one
+// unreachable host doesn't hide the others.
+func (v *VCluster) Contexts() ([]string, error) {
+	_, err := exec.LookPath(v.Binary)
+	useCLI := err == nil
+
+	cache := v.loadListings()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var failed []string
+	searched := false
+	for _, host := range v.Hosts {
+		if listing, ok := cache[host]; ok && time.Since(listing.Checked) < VClusterListTTL {
+			continue
+		}
+		searched = true
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			var found []virtualCluster
+			var err error
+			if useCLI {
+				found, err = v.listCLI(host)
+			} else {
+				found, err = v.listLabels(host)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed = append(failed, host)
+				delete(cache, host)
+				return
+			}
+			cache[host] = hostListing{Checked: time.Now(), Found: found}
+		}(host)
+	}
+	wg.Wait()
+	if searched {
+		// Hosts are only searched again sooner if the cache can't be written
+		v.saveListings(cache)
+	}
+
+	// Contexts are listed in the order of the hosts
+	var contexts []string
+	for _, host := range v.Hosts {
+		for _, vc := range cache[host].Found {
+			v.found[vc.context()] = vc
+			contexts = append(contexts, vc.context())
+		}
+	}
+
+	if len(failed) == len(v.Hosts) && len(failed) > 0 {
+		return nil, fmt.Errorf("failed to list vclusters on %s", strings.Join(failed, ", "))
+	}
+	return contexts, nil
+}
+
+// listingsPath returns the file caching the searches of the hosts
+func (v *VCluster) listingsPath() string {
+	return filepath.Join(filepath.Dir(v.kubeConfig), "hosts.json")
+}
+
+// loadListings returns the cached searches of the hosts
+func (v *VCluster) loadListings() map[string]hostListing {
+	cache := make(map[string]hostListing)
+	if data, err := os.ReadFile(v.listingsPath()); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+// saveListings writes the cached searches of the hosts, replacing the file
+// atomically so concurrent invocations never read a partial file
+func (v *VCluster) saveListings(cache map[string]hostListing) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(v.kubeConfig), 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(v.kubeConfig), "hosts.json.*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), v.listingsPath())
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// hostCommand returns a command of the vcluster CLI or kubectl run against
+// a host context, reading the host's kubeconfig
+func (v *VCluster) hostCommand(host, name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	kubeConfig := v.KubeConfigPath
+	if path, ok := v.HostKubeConfigs[host]; ok {
+		kubeConfig = path
+	}
+	if kubeConfig != "" {
+		cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeConfig)
+	}
+	return cmd
+}
+
+// listCLI lists vclusters with "vcluster list"
+func (v *VCluster) listCLI(host string) ([]virtualCluster, error) {
+	out, err := v.hostCommand(host, v.Binary, "list", "--context", host, "--output", "json").Output()
+	if err != nil {
+		return nil, exitError(err)
+	}
+	var list []struct {
+		Name      string
+		Namespace string
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse vcluster list output: %w", err)
+	}
+	found := make([]virtualCluster, 0, len(list))
+	for _, item := range list {
+		found = append(found, virtualCluster{Name: item.Name, Namespace: item.Namespace, Host: host})
+	}
+	return found, nil
+}
+
+// listLabels finds vcluster control planes by the labels of their workloads
+func (v *VCluster) listLabels(host string) ([]virtualCluster, error) {
+	out, err := v.hostCommand(host, "kubectl", "--context", host, "get", "statefulsets,deployments", "-A", "-l", "app=vcluster", "-o", "json").Output()
+	if err != nil {
+		return nil, exitError(err)
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name      string            `json:"name"`
+				Namespace string            `json:"namespace"`
+				Labels    map[string]string `json:"labels"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse kubectl output: %w", err)
+	}
+	found := make([]virtualCluster, 0, len(list.Items))
+	for _, item := range list.Items {
+		name := item.Metadata.Labels["release"]
+		if name == "" {
+			name = item.Metadata.Name
+		}
+		found = append(found, virtualCluster{Name: name, Namespace: item.Metadata.Namespace, Host: host})
+	}
+	return found, nil
+}
+
+// Login generates the vcluster's kubeconfig with "vcluster connect --print"
+// unless the context is already in the private kubeconfig
+func (v *VCluster) Login(context string) error {
+	config, err := clientcmd.LoadFromFile(v.kubeConfig)
+	if err != nil {
+		config = clientcmdapi.NewConfig()
+	}
+	if _, ok := config.Contexts[context]; ok {
+		return nil
+	}
+
+	vc, ok := v.found[context]
+	if !ok {
+		return fmt.Errorf("unknown vcluster context %q", context)
+	}
+	if _, err := exec.LookPath(v.Binary); err != nil {
+		return fmt.Errorf("the vcluster CLI is required to connect to %s", context)
+	}
+	out, err := v.hostCommand(vc.Host, v.Binary, "connect", vc.Name, "--namespace", vc.Namespace,
+		"--context", vc.Host, "--print", "--kube-config-context-name", context).Output()
+	if err != nil {
+		return fmt.Errorf("vcluster connect %s failed: %w", vc.Name, exitError(err))
+	}
+
+	printed, err := clientcmd.Load(out)
+	if err != nil {
+		return fmt.Errorf("failed to parse kubeconfig for %s: %w", context, err)
+	}
+	ctx, ok := printed.Contexts[context]
+	if !ok && len(printed.Contexts) == 1 {
+		// Older CLIs ignore --kube-config-context-name
+		for _, c := range printed.Contexts {
+			ctx, ok = c, true
+		}
+	}
+	if !ok {
+		return fmt.Errorf("vcluster connect did not print context %s", context)
+	}
+	// Store everything under the context name so vclusters don't collide
+	config.Clusters[context] = printed.Clusters[ctx.Cluster]
+	config.AuthInfos[context] = printed.AuthInfos[ctx.AuthInfo]
+	config.Contexts[context] = &clientcmdapi.Context{Cluster: context, AuthInfo: context, Namespace: ctx.Namespace}
+
+	if err := os.MkdirAll(filepath.Dir(v.kubeConfig), 0700); err != nil {
+		return fmt.Errorf("failed to create vcluster kubeconfig directory: %w", err)
+	}
+	if err := clientcmd.WriteToFile(*config, v.kubeConfig); err != nil {
+		return fmt.Errorf("failed to write vcluster kubeconfig: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxFixtureName is the longest fixture file name kept readable before hashing
+const maxFixtureName = 100
+
+// Fixture is the recorded result of one kubectl invocation on one context
+type Fixture struct {
+	Args       []string `json:"args"`
+	Output     string   `json:"output"`
+	Stderr     string   `json:"stderr,omitempty"`
+	ExitCode   int      `json:"exitCode"`
+	Error      string   `json:"error,omitempty"`
+	TimedOut   bool     `json:"timedOut,omitempty"`
+	DurationMS int64    `json:"durationMs"`
+}
+
+// ContextFileName returns a context name usable as a file name, escaping the
+// "/" of names such as EKS ARNs
+func ContextFileName(context string) string {
+	return url.PathEscape(context)
+}
+
+// FixturePath returns the file holding the fixture for args on a context:
+// <dir>/<context>/<args>.json, with long argument lists shortened by a hash
+func FixturePath(dir, context string, args []string) string {
+	name := strings.NewReplacer("/", "~", " ", "_").Replace(strings.Join(args, "_"))
+	if len(name) > maxFixtureName {
+		sum := sha256.Sum256([]byte(strings.Join(args, "\x00")))
+		name = name[:maxFixtureName] + "-" + hex.EncodeToString(sum[:6])
+	}
+	if name == "" {
+		name = "_"
+	}
+	return filepath.Join(dir, ContextFileName(context), name+".json")
+}
+
+// SaveFixture records a result for args under dir
+func SaveFixture(dir string, args []string, r Result) error {
+	f := Fixture{
+		Args:       args,
+		Output:     r.Output,
+		Stderr:     r.Stderr,
+		ExitCode:   r.ExitCode,
+		TimedOut:   r.TimedOut,
+		DurationMS: r.Duration.Milliseconds(),
+	}
+	if r.Error != nil {
+		f.Error = r.Error.Error()
+	}
+
+	path := FixturePath(dir, r.Context, args)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fixture: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// LoadFixture returns the recorded result for args on a context
+func LoadFixture(dir, context string, args []string) (Result, error) {
+	data, err := os.ReadFile(FixturePath(dir, context, args))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Result{}, fmt.Errorf("no fixture recorded for '%s' in %s", strings.Join(args, " "), context)
+		}
+		return Result{}, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Result{}, fmt.Errorf("failed to parse fixture: %w", err)
+	}
+
+	r := Result{
+		Context:  context,
+		Output:   f.Output,
+		Stderr:   f.Stderr,
+		ExitCode: f.ExitCode,
+		TimedOut: f.TimedOut,
+		Duration: time.Duration(f.DurationMS) * time.Millisecond,
+	}
+	if f.Error != "" {
+		r.Error = errors.New(f.Error)
+	}
+	return r, nil
+}
+
+// FixtureContexts returns the sorted contexts that have fixtures under dir
+func FixtureContexts(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture directory: %w", err)
+	}
+	var contexts []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		context, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("invalid fixture directory %s: %w", entry.Name(), err)
+		}
+		contexts = append(contexts, context)
+	}
+	sort.Strings(contexts)
+	return contexts, nil
+}
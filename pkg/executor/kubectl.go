@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
-	"sync"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -13,14 +16,101 @@ import (
 type Result struct {
 	Context  string
 	Output   string
+	Stderr   string
 	Error    error
 	ExitCode int
+	TimedOut bool
+	// Canceled is set when the run was interrupted, e.g. by Ctrl-C
+	Canceled bool
+	// As describes the impersonated identity, if any
+	As string
+	// FailoverFor is the primary context this secondary ran the command for, if any
+	FailoverFor string
+	// StartTime and EndTime bound the kubectl invocation
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration
+	// OutputFile holds the output instead of Output when the executor
+	// spools output to files (see SetSpoolDir)
+	OutputFile string
+}
+
+// unreachablePatterns are kubectl error messages indicating the API server could not be reached
+var unreachablePatterns = []string{
+	"Unable to connect to the server",
+	"connection refused",
+	"no such host",
+	"i/o timeout",
+	"TLS handshake timeout",
+}
+
+// Unreachable reports whether the result failed because the cluster could not be reached
+func (r Result) Unreachable() bool {
+	if r.TimedOut {
+		return true
+	}
+	if r.Error == nil {
+		return false
+	}
+	msg := r.Error.Error()
+	for _, pattern := range unreachablePatterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// Impersonation is the user and groups a context's commands run as
+type Impersonation struct {
+	User   string
+	Groups []string
+}
+
+// String describes the identity, e.g. "admin (groups: system:masters)"
+func (i Impersonation) String() string {
+	switch {
+	case len(i.Groups) == 0:
+		return i.User
+	case i.User == "":
+		return "groups: " + strings.Join(i.Groups, ", ")
+	default:
+		return fmt.Sprintf("%s (groups: %s)", i.User, strings.Join(i.Groups, ", "))
+	}
+}
+
+// Args returns the kubectl flags impersonating the identity
+func (i Impersonation) Args() []string {
+	var args []string
+	if i.User != "" {
+		args = append(args, "--as", i.User)
+	}
+	for _, g := range i.Groups {
+		args = append(args, "--as-group", g)
+	}
+	return args
 }
 
 // Executor executes kubectl commands across multiple clusters
 type Executor struct {
 	kubeConfigPath string
+	kubeConfigs    map[string]string
+	extraArgs      map[string][]string
+	env            map[string][]string
+	stdin          map[string][]byte
+	impersonation  map[string]Impersonation
 	timeout        time.Duration
+	limiter        *RateLimiter
+	serial         bool
+	serialDelay    time.Duration
+	lanes          []Lane
+	onResult       func(Result)
+	onLine         func(context, line string)
+	recordDir      string
+	replayDir      string
+	rewriteArgs    func(context string, args []string) []string
+	rawCommand     bool
+	spoolDir       string
 }
 
 // NewExecutor creates a new kubectl executor
@@ -31,51 +121,305 @@ func NewExecutor(kubeConfigPath string, timeout time.Duration) *Executor {
 	}
 }
 
+// SetKubeConfigFor makes the given contexts use a different kubeconfig file
+func (e *Executor) SetKubeConfigFor(contexts []string, path string) {
+	if e.kubeConfigs == nil {
+		e.kubeConfigs = make(map[string]string)
+	}
+	for _, ctx := range contexts {
+		e.kubeConfigs[ctx] = path
+	}
+}
+
+// KubeConfigFor returns the kubeconfig file set for a context, or "" if it
+// uses the executor's
+func (e *Executor) KubeConfigFor(context string) string {
+	return e.kubeConfigs[context]
+}
+
+// ExtraArgs returns the kubectl flags added for a single context
+func (e *Executor) ExtraArgs(context string) []string {
+	return e.extraArgs[context]
+}
+
+// SetExtraArgs sets kubectl flags added for a single context, e.g. a rewritten --server
+func (e *Executor) SetExtraArgs(context string, args []string) {
+	if e.extraArgs == nil {
+		e.extraArgs = make(map[string][]string)
+	}
+	e.extraArgs[context] = args
+}
+
+// SetEnv sets environment variables ("KEY=value") added to a single context's kubectl process
+func (e *Executor) SetEnv(context string, env []string) {
+	if e.env == nil {
+		e.env = make(map[string][]string)
+	}
+	e.env[context] = env
+}
+
+// AddEnv adds environment variables to a single context's process, keeping those already set
+func (e *Executor) AddEnv(context string, env ...string) {
+	e.SetEnv(context, append(e.env[context], env...))
+}
+
+// SetStdin sets the data a single context's kubectl reads on stdin, e.g.
+// manifests rendered for that cluster for "apply -f -"
+func (e *Executor) SetStdin(context string, data []byte) {
+	if e.stdin == nil {
+		e.stdin = make(map[string][]byte)
+	}
+	e.stdin[context] = data
+}
+
+// SetImpersonation makes a context's commands run as the given user and groups
+func (e *Executor) SetImpersonation(context string, imp Impersonation) {
+	if e.impersonation == nil {
+		e.impersonation = make(map[string]Impersonation)
+	}
+	e.impersonation[context] = imp
+}
+
+// SetRateLimiter sets the per-context rate limiter applied before each kubectl invocation
+func (e *Executor) SetRateLimiter(limiter *RateLimiter) {
+	e.limiter = limiter
+}
+
+// SetSerial makes Execute run contexts one at a time in the given order,
+// waiting delay between consecutive contexts
+func (e *Executor) SetSerial(serial bool, delay time.Duration) {
+	e.serial = serial
+	e.serialDelay = delay
+}
+
+// SetRecordDir saves every result as a fixture under dir
+func (e *Executor) SetRecordDir(dir string) {
+	e.recordDir = dir
+}
+
+// SetReplayDir serves results from the fixtures under dir instead of running kubectl
+func (e *Executor) SetReplayDir(dir string) {
+	e.replayDir = dir
+}
+
+// SetRawCommand makes the executor run args as a command of their own instead
+// of as kubectl arguments. The command finds its context in $KUBECONTEXT and
+// the kubeconfig in $KUBECONFIG; kubectl flags such as impersonation are not added.
+func (e *Executor) SetRawCommand(raw bool) {
+	e.rawCommand = raw
+}
+
+// RawCommand reports whether the executor runs args as a command of their own
+func (e *Executor) RawCommand() bool {
+	return e.rawCommand
+}
+
+// SetArgsFunc registers a function that adapts the kubectl args for each
+// context, e.g. to substitute a pod name that differs between clusters
+func (e *Executor) SetArgsFunc(fn func(context string, args []string) []string) {
+	e.rewriteArgs = fn
+}
+
+// ResultCallback returns the function registered with SetResultCallback, or nil
+func (e *Executor) ResultCallback() func(Result) {
+	return e.onResult
+}
+
+// SetResultCallback registers a function called as soon as each context finishes.
+// It may be called concurrently from multiple goroutines.
+func (e *Executor) SetResultCallback(fn func(Result)) {
+	e.onResult = fn
+}
+
+// SetLineCallback registers a function called with each line of stdout as
+// soon as kubectl writes it, for commands that run until stopped such as
+// logs -f. Stdout is then not collected in the results. It may be called
+// concurrently from multiple goroutines.
+func (e *Executor) SetLineCallback(fn func(context, line string)) {
+	e.onLine = fn
+}
+
+// SetTimeout changes the timeout of each kubectl command; 0 disables it
+func (e *Executor) SetTimeout(timeout time.Duration) {
+	e.timeout = timeout
+}
+
+// SetSpoolDir makes each context's output be written to a file in dir, named
+// by Result.OutputFile, instead of held in memory, for outputs too large to
+// buffer for every cluster at once
+func (e *Executor) SetSpoolDir(dir string) {
+	e.spoolDir = dir
+}
+
 // Execute runs a kubectl command against multiple contexts in parallel
+// (or sequentially in serial mode). Results are returned in the order of contexts.
 func (e *Executor) Execute(contexts []string, args []string) []Result {
-	var wg sync.WaitGroup
-	results := make([]Result, len(contexts))
+	return e.ExecuteContext(context.Background(), contexts, args)
+}
+
+// Probe runs a kubectl command against contexts for an internal check, such as
+// whether a namespace exists: without the result and line callbacks, the args
+// function or fixture recording
+func (e *Executor) Probe(ctx context.Context, contexts []string, args []string) []Result {
+	probe := *e
+	probe.onResult = nil
+	probe.onLine = nil
+	probe.rewriteArgs = nil
+	probe.recordDir = ""
+	probe.spoolDir = ""
+	return probe.ExecuteContext(ctx, contexts, args)
+}
 
-	for i, ctx := range contexts {
-		wg.Add(1)
-		go func(index int, context string) {
-			defer wg.Done()
-			results[index] = e.executeOne(context, args)
-		}(i, ctx)
+// ExecuteContext is like Execute, but kills the kubectl processes still running
+// when ctx is done
+func (e *Executor) ExecuteContext(ctx context.Context, contexts []string, args []string) []Result {
+	if e.serial {
+		return e.executeSerial(ctx, contexts, args)
 	}
+	return e.executeParallel(ctx, contexts, args)
+}
 
-	wg.Wait()
+func (e *Executor) executeSerial(ctx context.Context, contexts []string, args []string) []Result {
+	results := make([]Result, len(contexts))
+	for i, name := range contexts {
+		if i > 0 && e.serialDelay > 0 {
+			time.Sleep(e.serialDelay)
+		}
+		results[i] = e.run(ctx, name, args)
+	}
 	return results
 }
 
-func (e *Executor) executeOne(contextName string, args []string) Result {
-	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+// run executes a single context and notifies the result callback
+func (e *Executor) run(parent context.Context, contextName string, args []string) Result {
+	if e.rewriteArgs != nil {
+		args = e.rewriteArgs(contextName, args)
+	}
+
+	var result Result
+	if e.replayDir != "" {
+		result = e.replay(contextName, args)
+	} else {
+		result = e.executeOne(parent, contextName, args)
+	}
+	if e.recordDir != "" {
+		if err := SaveFixture(e.recordDir, args, result); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	if e.onResult != nil {
+		e.onResult(result)
+	}
+	return result
+}
+
+// replay returns the recorded result for a context, or an error result if none was recorded
+func (e *Executor) replay(contextName string, args []string) Result {
+	result, err := LoadFixture(e.replayDir, contextName, args)
+	if err != nil {
+		return Result{Context: contextName, Error: err, ExitCode: -1}
+	}
+	result.As = e.impersonation[contextName].String()
+	return result
+}
+
+func (e *Executor) executeOne(parent context.Context, contextName string, args []string) Result {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if e.timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, e.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
 	defer cancel()
 
-	// Build kubectl command with context
-	cmdArgs := []string{"--context", contextName}
-	if e.kubeConfigPath != "" {
-		cmdArgs = append([]string{"--kubeconfig", e.kubeConfigPath}, cmdArgs...)
+	if e.limiter != nil {
+		if err := e.limiter.Wait(ctx, contextName); err != nil {
+			return Result{
+				Context:  contextName,
+				Error:    fmt.Errorf("rate limit wait: %w", err),
+				ExitCode: -1,
+				TimedOut: ctx.Err() == context.DeadlineExceeded,
+				As:       e.impersonation[contextName].String(),
+			}
+		}
 	}
-	cmdArgs = append(cmdArgs, args...)
 
-	cmd := exec.CommandContext(ctx, "kubectl", cmdArgs...)
+	cmd := e.command(ctx, contextName, args)
+	// Don't wait forever on child processes still holding the pipes after kubectl is killed
+	cmd.WaitDelay = 2 * time.Second
 
+	if data, ok := e.stdin[contextName]; ok {
+		cmd.Stdin = bytes.NewReader(data)
+	}
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	var spool *os.File
+	if e.spoolDir != "" {
+		f, err := os.CreateTemp(e.spoolDir, "output-*")
+		if err != nil {
+			return Result{
+				Context:  contextName,
+				Error:    fmt.Errorf("failed to create output file: %w", err),
+				ExitCode: -1,
+				As:       e.impersonation[contextName].String(),
+			}
+		}
+		defer f.Close()
+		spool = f
+		cmd.Stdout = f
+	}
+	if e.onLine != nil {
+		lines := &lineWriter{context: contextName, fn: e.onLine}
+		defer lines.flush()
+		cmd.Stdout = lines
+		if spool != nil {
+			cmd.Stdout = io.MultiWriter(spool, lines)
+		}
+	}
 
+	start := time.Now()
 	err := cmd.Run()
+	end := time.Now()
 
 	result := Result{
-		Context: contextName,
-		Output:  stdout.String(),
+		Context:   contextName,
+		Output:    stdout.String(),
+		Stderr:    stderr.String(),
+		As:        e.impersonation[contextName].String(),
+		StartTime: start,
+		EndTime:   end,
+		Duration:  end.Sub(start),
+	}
+	if spool != nil {
+		result.OutputFile = spool.Name()
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		result.TimedOut = true
+		result.ExitCode = -1
+		// Report the time until the deadline, not including waiting for the killed process to exit
+		if deadline, ok := ctx.Deadline(); ok {
+			result.Duration = deadline.Sub(start)
+		}
+		result.Error = fmt.Errorf("timed out after %s", result.Duration.Round(100*time.Millisecond))
+		return result
+	}
+	if ctx.Err() == context.Canceled {
+		result.Canceled = true
+		result.ExitCode = -1
+		result.Error = fmt.Errorf("canceled")
+		return result
 	}
 
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			result.ExitCode = exitErr.ExitCode()
 			result.Error = fmt.Errorf("%s", stderr.String())
+			if strings.TrimSpace(stderr.String()) == "" {
+				result.Error = err
+			}
 		} else {
 			result.Error = err
 		}
@@ -83,3 +427,72 @@ func (e *Executor) executeOne(contextName string, args []string) Result {
 
 	return result
 }
+
+// command builds the kubectl command for a context, adding its kubeconfig,
+// extra flags, impersonation and environment, or the raw command in raw mode
+func (e *Executor) command(ctx context.Context, contextName string, args []string) *exec.Cmd {
+	cmdArgs := []string{"--context", contextName}
+	kubeConfigPath := e.kubeConfigPath
+	if path, ok := e.kubeConfigs[contextName]; ok {
+		kubeConfigPath = path
+	}
+
+	if e.rawCommand {
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Env = append(os.Environ(), "KUBECONTEXT="+contextName)
+		if kubeConfigPath == "" && os.Getenv("KUBECONFIG") == "" {
+			// kubectl's default, so the command doesn't have to know it
+			if home, err := os.UserHomeDir(); err == nil {
+				kubeConfigPath = filepath.Join(home, ".kube", "config")
+			}
+		}
+		if kubeConfigPath != "" {
+			cmd.Env = append(cmd.Env, "KUBECONFIG="+kubeConfigPath)
+		}
+		cmd.Env = append(cmd.Env, e.env[contextName]...)
+		return cmd
+	}
+
+	if kubeConfigPath != "" {
+		cmdArgs = append([]string{"--kubeconfig", kubeConfigPath}, cmdArgs...)
+	}
+	cmdArgs = append(cmdArgs, e.extraArgs[contextName]...)
+	cmdArgs = append(cmdArgs, e.impersonation[contextName].Args()...)
+	cmdArgs = append(cmdArgs, args...)
+
+	cmd := exec.CommandContext(ctx, "kubectl", cmdArgs...)
+	if env, ok := e.env[contextName]; ok {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
+
+// ExecuteInteractive runs kubectl against a single context connected to the
+// terminal, without a timeout, for commands such as attach
+func (e *Executor) ExecuteInteractive(ctx context.Context, contextName string, args []string) Result {
+	cmd := e.command(ctx, contextName, args)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	end := time.Now()
+
+	result := Result{
+		Context:   contextName,
+		As:        e.impersonation[contextName].String(),
+		StartTime: start,
+		EndTime:   end,
+		Duration:  end.Sub(start),
+	}
+	if err != nil {
+		result.Error = err
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			result.ExitCode = exitErr.ExitCode()
+		} else {
+			result.ExitCode = -1
+		}
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Lane is a set of contexts of which at most Limit run at the same time,
+// started in the order they are given
+type Lane struct {
+	Name     string
+	Contexts []string
+	Limit    int
+}
+
+// SetLanes sets the lanes that limit how many contexts of a set run at the
+// same time when contexts run in parallel. Contexts in no lane start at once.
+func (e *Executor) SetLanes(lanes []Lane) {
+	e.lanes = lanes
+}
+
+// executeParallel runs contexts in parallel, within the limits of their
+// lanes, and returns the results in the order of contexts
+func (e *Executor) executeParallel(ctx context.Context, contexts []string, args []string) []Result {
+	results := make([]Result, len(contexts))
+	queued := make(map[int]bool)
+	var wg sync.WaitGroup
+
+	for _, lane := range e.lanes {
+		var queue []int
+		for i, name := range contexts {
+			if !queued[i] && slices.Contains(lane.Contexts, name) {
+				queue = append(queue, i)
+				queued[i] = true
+			}
+		}
+		if len(queue) == 0 {
+			continue
+		}
+		next := make(chan int, len(queue))
+		for _, i := range queue {
+			next <- i
+		}
+		close(next)
+		for range min(max(lane.Limit, 1), len(queue)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					results[i] = e.run(ctx, contexts[i], args)
+				}
+			}()
+		}
+	}
+
+	for i, name := range contexts {
+		if queued[i] {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = e.run(ctx, name, args)
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,40 @@
+package executor
+
+import (
+	"bytes"
+	"sync"
+)
+
+// lineWriter passes each complete line written to it to a callback, without
+// the newline
+type lineWriter struct {
+	mu      sync.Mutex
+	context string
+	fn      func(context, line string)
+	pending []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.fn(w.context, string(w.pending[:i]))
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush passes on the last line if it has no newline
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) > 0 {
+		w.fn(w.context, string(w.pending))
+		w.pending = nil
+	}
+}
@@ -0,0 +1,69 @@
+package executor
+
+// pipelineSize is how many lines a Pipeline holds before Send blocks
+const pipelineSize = 1024
+
+// Pipeline carries the lines of every context's output to a single consumer
+// through a bounded queue. When the consumer falls behind, e.g. writing to a
+// slow terminal or a pager, Send blocks, which stops reading kubectl's output
+// until it catches up: kubectl then blocks on its full pipe, so fast clusters
+// can't queue up lines without bound.
+//
+// Only streaming commands (logs -f, get -w, events -w) use a pipeline. Other
+// runs still collect each cluster's whole output before merging it, since a
+// table is only aligned once every cluster's rows are known; -o json and -o
+// yaml outputs are spooled to files instead (see SetSpoolDir).
+type Pipeline struct {
+	consume func(context, line string)
+	queue   chan pipelineItem
+	done    chan struct{}
+}
+
+// pipelineItem is a line, or a function to run in its place
+type pipelineItem struct {
+	context string
+	line    string
+	fn      func()
+}
+
+// NewPipeline starts a pipeline calling consume with each line sent through
+// it, one at a time and in the order they were sent
+func NewPipeline(consume func(context, line string)) *Pipeline {
+	p := &Pipeline{
+		consume: consume,
+		queue:   make(chan pipelineItem, pipelineSize),
+		done:    make(chan struct{}),
+	}
+	go p.loop()
+	return p
+}
+
+// Send queues a line of a context's output, blocking while the queue is full.
+// It can be passed to Executor.SetLineCallback.
+func (p *Pipeline) Send(context, line string) {
+	p.queue <- pipelineItem{context: context, line: line}
+}
+
+// Do queues fn to run on the consumer after the lines sent before it, e.g.
+// to reset a context's state when its command is restarted
+func (p *Pipeline) Do(fn func()) {
+	p.queue <- pipelineItem{fn: fn}
+}
+
+// Close waits for the queued lines to be consumed and stops the pipeline.
+// Nothing may be sent after Close.
+func (p *Pipeline) Close() {
+	close(p.queue)
+	<-p.done
+}
+
+func (p *Pipeline) loop() {
+	defer close(p.done)
+	for item := range p.queue {
+		if item.fn != nil {
+			item.fn()
+			continue
+		}
+		p.consume(item.context, item.line)
+	}
+}
@@ -0,0 +1,55 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// Planned is the command a context would run, as resolved by the executor
+type Planned struct {
+	Context string
+	// Command is the program and its arguments
+	Command []string
+	// Env holds the variables added to the program's environment
+	Env []string
+	// Stdin is the data the program would read on stdin, if set per context
+	Stdin []byte
+}
+
+// String returns the command line, quoted for a POSIX shell
+func (p Planned) String() string {
+	words := make([]string, len(p.Command))
+	for i, word := range p.Command {
+		words[i] = shellQuote(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// Plan resolves the command each context would run for args, with the
+// per-context args, kubeconfig, flags and environment, without running it
+func (e *Executor) Plan(contexts []string, args []string) []Planned {
+	plans := make([]Planned, len(contexts))
+	for i, name := range contexts {
+		contextArgs := args
+		if e.rewriteArgs != nil {
+			contextArgs = e.rewriteArgs(name, args)
+		}
+		cmd := e.command(context.Background(), name, contextArgs)
+		plan := Planned{Context: name, Command: cmd.Args, Stdin: e.stdin[name]}
+		// The command's environment is ours with the context's variables appended
+		if cmd.Env != nil {
+			plan.Env = cmd.Env[len(os.Environ()):]
+		}
+		plans[i] = plan
+	}
+	return plans
+}
+
+// shellQuote quotes s for a POSIX shell when it contains anything but safe characters
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=,@%+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
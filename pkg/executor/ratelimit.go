@@ -0,0 +1,192 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/multikubectl/pkg/filelock"
+)
+
+// DefaultRateLimitFile keeps the rate limit buckets between invocations,
+// under ~/.multikube
+const DefaultRateLimitFile = "ratelimit.json"
+
+// RateLimit defines client-side request limits for a single context
+type RateLimit struct {
+	// QPS is the sustained number of requests per second (0 means unlimited)
+	QPS float64
+	// Burst is the maximum number of requests allowed at once
+	Burst int
+}
+
+// RateLimiter throttles the kubectl invocations against each context with a
+// token bucket per context. A bucket starts with Burst tokens and refills at
+// QPS. With a state file the buckets are shared by every invocation of
+// multikubectl, so that a loop of short runs is throttled like one long run.
+type RateLimiter struct {
+	mu        sync.Mutex
+	defaults  RateLimit
+	overrides map[string]RateLimit
+	buckets   map[string]*bucket
+	statePath string
+	// warned is set once a state file error was reported
+	warned bool
+}
+
+// NewRateLimiter creates a rate limiter using defaults for every context
+// unless an override is given for it
+func NewRateLimiter(defaults RateLimit, overrides map[string]RateLimit) *RateLimiter {
+	return &RateLimiter{
+		defaults:  defaults,
+		overrides: overrides,
+		buckets:   make(map[string]*bucket),
+	}
+}
+
+// SetStateFile keeps the buckets in path, read before and written after
+// each request is counted while holding a lock on path.lock
+func (l *RateLimiter) SetStateFile(path string) {
+	l.statePath = path
+}
+
+// LimitFor returns the effective rate limit for a context
+func (l *RateLimiter) LimitFor(contextName string) RateLimit {
+	if limit, ok := l.overrides[contextName]; ok {
+		return limit
+	}
+	return l.defaults
+}
+
+// Wait blocks until a request against the context is allowed or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context, contextName string) error {
+	limit := l.LimitFor(contextName)
+	if limit.QPS <= 0 {
+		return nil
+	}
+
+	for {
+		delay := l.take(contextName, limit)
+		if delay <= 0 {
+			return nil
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// take counts a request against the context if its bucket has a token, and
+// otherwise returns how long until it has one
+func (l *RateLimiter) take(contextName string, limit RateLimit) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.statePath != "" {
+		// Other invocations may have taken tokens since; the lock keeps them
+		// from taking the same token between load and save
+		unlock, err := filelock.Lock(l.statePath + ".lock")
+		if err != nil {
+			l.warn(err)
+		} else {
+			defer unlock()
+		}
+		l.load()
+	}
+	b, ok := l.buckets[contextName]
+	if !ok {
+		b = newBucket(limit)
+		l.buckets[contextName] = b
+	}
+	delay := b.take(limit, time.Now())
+	if delay <= 0 && l.statePath != "" {
+		// A state file that can't be written only loses the count of this request
+		if err := l.save(); err != nil {
+			l.warn(fmt.Errorf("failed to save rate limit state: %w", err))
+		}
+	}
+	return delay
+}
+
+// warn reports the first state file error on stderr; requests are still
+// limited within this invocation
+func (l *RateLimiter) warn(err error) {
+	if !l.warned {
+		l.warned = true
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// load replaces the buckets with those of the state file, if it can be read
+func (l *RateLimiter) load() {
+	data, err := os.ReadFile(l.statePath)
+	if err != nil {
+		return
+	}
+	var buckets map[string]*bucket
+	if json.Unmarshal(data, &buckets) == nil && buckets != nil {
+		l.buckets = buckets
+	}
+}
+
+// save writes the buckets to the state file, replacing it atomically so
+// concurrent invocations never read a partial file
+func (l *RateLimiter) save() error {
+	data, err := json.Marshal(l.buckets)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.statePath), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(l.statePath), filepath.Base(l.statePath)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), l.statePath)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// bucket is a token bucket refilled at the limit's QPS
+type bucket struct {
+	Tokens float64   `json:"tokens"`
+	Last   time.Time `json:"last"`
+}
+
+func newBucket(limit RateLimit) *bucket {
+	return &bucket{Tokens: burst(limit), Last: time.Now()}
+}
+
+// burst returns the number of tokens a bucket holds at most
+func burst(limit RateLimit) float64 {
+	return max(float64(limit.Burst), 1)
+}
+
+// take refills the bucket up to now and takes a token, or returns how long
+// until a token is available
+func (b *bucket) take(limit RateLimit, now time.Time) time.Duration {
+	if now.After(b.Last) {
+		b.Tokens = min(b.Tokens+now.Sub(b.Last).Seconds()*limit.QPS, burst(limit))
+		b.Last = now
+	}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return 0
+	}
+	return time.Duration((1 - b.Tokens) / limit.QPS * float64(time.Second))
+}
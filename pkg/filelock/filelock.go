@@ -0,0 +1,29 @@
+// Package filelock serializes read-modify-write cycles of the state files
+// shared by concurrent multikubectl invocations.
+package filelock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Lock blocks until it holds an exclusive lock on the file at path, creating
+// it if needed, and returns the function that releases the lock
+func Lock(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock file: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lock(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	return func() {
+		unlock(f)
+		f.Close()
+	}, nil
+}
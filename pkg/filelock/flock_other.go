@@ -0,0 +1,13 @@
+//go:build !unix
+
+package filelock
+
+import "os"
+
+// Without flock, invocations are not serialized; the state files are still
+// replaced atomically, so at worst an update is lost
+func lock(f *os.File) error {
+	return nil
+}
+
+func unlock(f *os.File) {}
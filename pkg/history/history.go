@@ -0,0 +1,195 @@
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/executor"
+)
+
+const (
+	DefaultHistoryDir = "history"
+	DefaultLimit      = 100
+	// MaxSpooledOutput is the largest output spooled to a file (see
+	// executor.SetSpoolDir) that is recorded; larger ones are only recorded
+	// by size, so that recording doesn't load them into memory
+	MaxSpooledOutput = 1 << 20
+)
+
+// Record is a stored run: the kubectl command and each context's output
+type Record struct {
+	ID       string    `json:"id"`
+	Time     time.Time `json:"time"`
+	Args     []string  `json:"args"`
+	Contexts []string  `json:"contexts"`
+	Results  []Result  `json:"results"`
+}
+
+// Result is the output of the command on a single context
+type Result struct {
+	Context string `json:"context"`
+	Output  string `json:"output,omitempty"`
+	// OmittedSize is the size of an output too large to record, which is
+	// left out of Output
+	OmittedSize int64 `json:"omittedSize,omitempty"`
+	// OutputHash replaces Output for commands reading secrets, see HashOutputs
+	OutputHash string `json:"outputHash,omitempty"`
+	Stderr     string `json:"stderr,omitempty"`
+	ExitCode   int    `json:"exitCode"`
+}
+
+// Find returns the recorded result for a context
+func (r *Record) Find(context string) (Result, bool) {
+	for _, res := range r.Results {
+		if res.Context == context {
+			return res, true
+		}
+	}
+	return Result{}, false
+}
+
+// GetHistoryDir returns the directory holding recorded runs
+func GetHistoryDir() string {
+	return filepath.Join(config.GetConfigDir(), DefaultHistoryDir)
+}
+
+// NewRecord builds a record of kubectl args run against contexts. Outputs
+// spooled to files are read back from them up to MaxSpooledOutput.
+func NewRecord(id string, args []string, contexts []string, results []executor.Result) *Record {
+	rec := &Record{
+		ID:       id,
+		Time:     time.Now().UTC(),
+		Args:     args,
+		Contexts: contexts,
+	}
+	for _, r := range results {
+		exitCode := r.ExitCode
+		if r.Error != nil && exitCode == 0 {
+			exitCode = 1
+		}
+		res := Result{
+			Context:  r.Context,
+			Output:   r.Output,
+			Stderr:   r.Stderr,
+			ExitCode: exitCode,
+		}
+		if r.OutputFile != "" {
+			res.Output, res.OmittedSize = readSpooled(r.OutputFile)
+		}
+		rec.Results = append(rec.Results, res)
+	}
+	return rec
+}
+
+// HashOutputs replaces each recorded output by its hash, for commands whose
+// output holds secrets: replay can still tell whether it changed
+func (r *Record) HashOutputs() {
+	for i := range r.Results {
+		if r.Results[i].Output != "" {
+			r.Results[i].OutputHash = HashOutput(r.Results[i].Output)
+			r.Results[i].Output = ""
+		}
+	}
+}
+
+// HashOutput returns the hash an output is recorded as by HashOutputs
+func HashOutput(output string) string {
+	sum := sha256.Sum256([]byte(output))
+	return hex.EncodeToString(sum[:])
+}
+
+// readSpooled returns a spooled output if it is at most MaxSpooledOutput
+// bytes, and otherwise its size
+func readSpooled(path string) (string, int64) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0
+	}
+	if info.Size() > MaxSpooledOutput {
+		return "", info.Size()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0
+	}
+	return string(data), 0
+}
+
+// Save stores a record and removes the oldest records beyond limit
+func Save(rec *Record, limit int) error {
+	dir := GetHistoryDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history record: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, rec.ID+".json"), data, 0600); err != nil {
+		return fmt.Errorf("failed to write history record: %w", err)
+	}
+
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	records, err := List()
+	if err != nil {
+		return err
+	}
+	for _, old := range records[min(limit, len(records)):] {
+		os.Remove(filepath.Join(dir, old.ID+".json"))
+	}
+	return nil
+}
+
+// Load loads the record with the given ID
+func Load(id string) (*Record, error) {
+	data, err := os.ReadFile(filepath.Join(GetHistoryDir(), id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no recorded run with id %q", id)
+		}
+		return nil, fmt.Errorf("failed to read history record: %w", err)
+	}
+
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to parse history record: %w", err)
+	}
+	return &rec, nil
+}
+
+// List returns all records, newest first
+func List() ([]*Record, error) {
+	entries, err := os.ReadDir(GetHistoryDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	var records []*Record
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		rec, err := Load(id)
+		if err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Time.After(records[j].Time) })
+	return records, nil
+}
@@ -0,0 +1,63 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// APIVersion identifies the inventory schema. Fields may be added within a
+// version but are never renamed or removed.
+const APIVersion = "multikubectl/v1"
+
+// Inventory is the resolved set of target clusters
+type Inventory struct {
+	APIVersion string    `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string    `json:"kind" yaml:"kind"`
+	Clusters   []Cluster `json:"clusters" yaml:"clusters"`
+}
+
+// Cluster describes one context in the inventory
+type Cluster struct {
+	// Name is the context name
+	Name string `json:"name" yaml:"name"`
+	// Alias is the short name from the multikube config, or empty
+	Alias string `json:"alias" yaml:"alias"`
+	// Server is the API server URL, or empty if not known until login
+	Server string `json:"server" yaml:"server"`
+	// Cluster is the kubeconfig cluster entry the context refers to
+	Cluster string `json:"cluster" yaml:"cluster"`
+	// Namespace is the context's default namespace
+	Namespace string `json:"namespace" yaml:"namespace"`
+	// Source is where the context came from: kubeconfig, ephemeral,
+	// a discovery provider name, or the path of an imported kubeconfig
+	Source string            `json:"source" yaml:"source"`
+	Labels map[string]string `json:"labels" yaml:"labels"`
+	Groups []string          `json:"groups" yaml:"groups"`
+}
+
+// New creates an empty inventory
+func New() *Inventory {
+	return &Inventory{APIVersion: APIVersion, Kind: "Inventory", Clusters: []Cluster{}}
+}
+
+// Encode renders the inventory as "json" or "yaml"
+func (inv *Inventory) Encode(format string) ([]byte, error) {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(inv, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode inventory: %w", err)
+		}
+		return append(data, '\n'), nil
+	case "yaml":
+		data, err := yaml.Marshal(inv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode inventory: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unknown inventory format %q (valid: json, yaml)", format)
+	}
+}
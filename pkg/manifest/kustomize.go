@@ -0,0 +1,25 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Kustomize builds the kustomization in dir with kubectl's built-in
+// kustomize and returns the rendered manifests
+func Kustomize(dir string) ([]byte, error) {
+	cmd := exec.Command("kubectl", "kustomize", dir)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("failed to build %s: %s", dir, msg)
+	}
+	return stdout.Bytes(), nil
+}
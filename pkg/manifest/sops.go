@@ -0,0 +1,56 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// sopsMetadata matches the top-level "sops" key SOPS adds to the files it
+// encrypts, in YAML or JSON
+var sopsMetadata = regexp.MustCompile(`(?m)^(sops:|\s*"sops":\s*\{)`)
+
+// Encrypted reports whether a manifest file was encrypted with SOPS
+func Encrypted(data []byte) bool {
+	return sopsMetadata.Match(data) && bytes.Contains(data, []byte("mac"))
+}
+
+// Decrypt decrypts a SOPS encrypted file with the sops CLI, which finds the
+// age, PGP or KMS keys as usual. The plaintext is only kept in memory.
+func Decrypt(path string) ([]byte, error) {
+	return sops(path, nil, "--decrypt", path)
+}
+
+// DecryptData decrypts SOPS encrypted YAML or JSON read from name, e.g.
+// stdin, feeding it to the sops CLI on its stdin
+func DecryptData(name string, data []byte) ([]byte, error) {
+	format := "yaml"
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		format = "json"
+	}
+	return sops(name, data, "--decrypt", "--input-type", format, "--output-type", format, "/dev/stdin")
+}
+
+// sops runs the sops CLI on the manifest name, with stdin if not nil
+func sops(name string, stdin []byte, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath("sops"); err != nil {
+		return nil, fmt.Errorf("%s is encrypted with SOPS but sops was not found in PATH", name)
+	}
+	cmd := exec.Command("sops", args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("failed to decrypt %s: %s", name, msg)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,27 @@
+package manifest
+
+import (
+	"regexp"
+)
+
+// varReference matches a ${NAME} variable reference
+var varReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Substitute replaces the ${NAME} references in data with vars. References
+// to other names are left as they are and returned, in order of appearance.
+func Substitute(data []byte, vars map[string]string) ([]byte, []string) {
+	var undefined []string
+	seen := make(map[string]bool)
+	out := varReference.ReplaceAllFunc(data, func(ref []byte) []byte {
+		name := string(ref[2 : len(ref)-1])
+		if value, ok := vars[name]; ok {
+			return []byte(value)
+		}
+		if !seen[name] {
+			seen[name] = true
+			undefined = append(undefined, name)
+		}
+		return ref
+	})
+	return out, undefined
+}
@@ -0,0 +1,80 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateData is the data a manifest template is rendered with
+type TemplateData struct {
+	// Context is the kubeconfig context the manifests are applied to
+	Context string
+	// Alias is the context's alias from the config, or its name
+	Alias string
+	// Values are the cluster's values, read from its values file
+	Values map[string]interface{}
+}
+
+// templateFuncs are the functions available in manifest templates
+var templateFuncs = template.FuncMap{
+	"default": func(def, value interface{}) interface{} {
+		if value == nil || value == "" {
+			return def
+		}
+		return value
+	},
+	"required": func(msg string, value interface{}) (interface{}, error) {
+		if value == nil || value == "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return value, nil
+	},
+	"quote": func(value interface{}) string {
+		return fmt.Sprintf("%q", fmt.Sprint(value))
+	},
+	"toYaml": func(value interface{}) (string, error) {
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(data), "\n"), nil
+	},
+	"indent": func(n int, s string) string {
+		pad := strings.Repeat(" ", n)
+		return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+	},
+}
+
+// ReadValues reads a YAML values file
+func ReadValues(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values: %w", err)
+	}
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse values %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// Render evaluates a manifest template. Referencing a value missing from the
+// values file is an error, so a typo doesn't apply an empty field. That error
+// comes before default or required see the value, so optional values are
+// looked up with index, e.g. {{ index .Values "tag" | default "stable" }}.
+func Render(name string, text []byte, data TemplateData) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return out.Bytes(), nil
+}
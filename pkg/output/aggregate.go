@@ -0,0 +1,169 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Aggregations appended to a merged table by Aggregate
+const (
+	AggregateSum = "sum"
+	AggregateAvg = "avg"
+)
+
+// quantityKind is the kind of number a table cell holds
+type quantityKind int
+
+const (
+	kindCount quantityKind = iota
+	// kindFraction is a ready count such as "2/3"
+	kindFraction
+	// kindMilli is CPU in millicores such as "250m"
+	kindMilli
+	// kindBytes is memory such as "128Mi"
+	kindBytes
+	kindPercent
+)
+
+// quantity is a parsed numeric cell; of is the denominator of a fraction
+type quantity struct {
+	kind  quantityKind
+	value float64
+	of    float64
+}
+
+// byteUnits are the memory suffixes kubectl top and quantities use
+var byteUnits = []struct {
+	suffix string
+	factor float64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+	{"k", 1e3}, {"K", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+}
+
+// parseQuantity parses a numeric cell of column header: a count, a count
+// followed by a note as in RESTARTS ("3 (2h ago)"), a fraction as in READY,
+// a percentage, or CPU and memory as printed by kubectl top. Units are only
+// recognized in CPU and MEMORY columns, so that e.g. an AGE of "5m" is not
+// taken for millicores.
+func parseQuantity(header, cell string) (quantity, bool) {
+	if before, _, ok := strings.Cut(cell, " ("); ok {
+		cell = before
+	}
+	cpu := strings.HasPrefix(header, "CPU") && !strings.HasSuffix(header, "%")
+	memory := strings.HasPrefix(header, "MEM") && !strings.HasSuffix(header, "%")
+	if num, den, ok := strings.Cut(cell, "/"); ok {
+		n, err1 := strconv.ParseFloat(num, 64)
+		d, err2 := strconv.ParseFloat(den, 64)
+		return quantity{kind: kindFraction, value: n, of: d}, err1 == nil && err2 == nil
+	}
+	if v, err := strconv.ParseFloat(cell, 64); err == nil {
+		switch {
+		case cpu:
+			// Whole cores next to millicores, e.g. "1" and "250m"
+			return quantity{kind: kindMilli, value: v * 1000}, true
+		case memory:
+			return quantity{kind: kindBytes, value: v}, true
+		}
+		return quantity{kind: kindCount, value: v}, true
+	}
+	if s, ok := strings.CutSuffix(cell, "%"); ok {
+		v, err := strconv.ParseFloat(s, 64)
+		return quantity{kind: kindPercent, value: v}, err == nil
+	}
+	if s, ok := strings.CutSuffix(cell, "m"); ok && cpu {
+		v, err := strconv.ParseFloat(s, 64)
+		return quantity{kind: kindMilli, value: v}, err == nil
+	}
+	for _, unit := range byteUnits {
+		if s, ok := strings.CutSuffix(cell, unit.suffix); ok && memory {
+			v, err := strconv.ParseFloat(s, 64)
+			return quantity{kind: kindBytes, value: v * unit.factor}, err == nil
+		}
+	}
+	return quantity{}, false
+}
+
+// Aggregate appends a TOTAL and/or AVG row to a merged table, summing or
+// averaging each column whose cells are all numbers of one kind: counts,
+// ready fractions, CPU, memory or percentages (which are only averaged).
+// Other columns are left blank, and empty cells are not counted.
+func (t *Table) Aggregate(modes []string) {
+	if !t.HasHeader() {
+		return
+	}
+	sums := make([]quantity, len(t.Headers))
+	numeric := make([]bool, len(t.Headers))
+	counts := make([]int, len(t.Headers))
+	for col := 1; col < len(t.Headers); col++ {
+		numeric[col] = true
+		for _, row := range t.Rows {
+			// Rows merged before a later cluster added columns are shorter
+			if col >= len(row) || row[col] == "" {
+				continue
+			}
+			q, ok := parseQuantity(t.Headers[col], row[col])
+			if !ok || (counts[col] > 0 && q.kind != sums[col].kind) {
+				numeric[col] = false
+				break
+			}
+			sums[col] = quantity{kind: q.kind, value: sums[col].value + q.value, of: sums[col].of + q.of}
+			counts[col]++
+		}
+	}
+
+	for _, mode := range modes {
+		label := "TOTAL"
+		if mode == AggregateAvg {
+			label = "AVG"
+		}
+		row := make([]string, len(t.Headers))
+		row[0] = label
+		for col := 1; col < len(t.Headers); col++ {
+			if !numeric[col] || counts[col] == 0 {
+				continue
+			}
+			q := sums[col]
+			if mode == AggregateAvg {
+				n := float64(counts[col])
+				q = quantity{kind: q.kind, value: q.value / n, of: q.of / n}
+			} else if q.kind == kindPercent {
+				continue
+			}
+			row[col] = formatQuantity(q)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+}
+
+// formatQuantity formats an aggregated quantity like the cells it came from
+func formatQuantity(q quantity) string {
+	switch q.kind {
+	case kindFraction:
+		return formatNumber(q.value) + "/" + formatNumber(q.of)
+	case kindMilli:
+		return formatNumber(q.value) + "m"
+	case kindBytes:
+		return formatNumber(q.value/(1<<20)) + "Mi"
+	case kindPercent:
+		return formatNumber(q.value) + "%"
+	}
+	return formatNumber(q.value)
+}
+
+// formatNumber formats v with at most one decimal
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
+
+// ParseAggregations validates the aggregations given as --aggregate values
+func ParseAggregations(values []string) ([]string, error) {
+	for _, v := range values {
+		if v != AggregateSum && v != AggregateAvg {
+			return nil, fmt.Errorf("invalid aggregation %q (must be %s or %s)", v, AggregateSum, AggregateAvg)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,136 @@
+package output
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Palette holds the ANSI SGR codes used to colorize merged output
+type Palette struct {
+	Cluster string
+	Header  string
+	Error   string
+	Warning string
+	// Clusters are the colors cycled through to tell clusters apart in
+	// prefixed and interleaved logs
+	Clusters []string
+}
+
+// presets are the built-in color themes
+var presets = map[string]Palette{
+	"default": {
+		Cluster:  "36",
+		Header:   "1",
+		Error:    "31",
+		Warning:  "33",
+		Clusters: []string{"36", "35", "32", "34", "96", "95", "92", "94"},
+	},
+	// colorblind avoids red/green distinctions, using blue and orange instead
+	"colorblind": {
+		Cluster:  "38;5;75",
+		Header:   "1",
+		Error:    "1;38;5;208",
+		Warning:  "38;5;220",
+		Clusters: []string{"38;5;75", "38;5;141", "38;5;45", "38;5;183", "38;5;111", "38;5;250"},
+	},
+}
+
+// colorNames maps color names to ANSI SGR codes
+var colorNames = map[string]string{
+	"bold":           "1",
+	"dim":            "2",
+	"italic":         "3",
+	"underline":      "4",
+	"black":          "30",
+	"red":            "31",
+	"green":          "32",
+	"yellow":         "33",
+	"blue":           "34",
+	"magenta":        "35",
+	"cyan":           "36",
+	"white":          "37",
+	"bright-black":   "90",
+	"bright-red":     "91",
+	"bright-green":   "92",
+	"bright-yellow":  "93",
+	"bright-blue":    "94",
+	"bright-magenta": "95",
+	"bright-cyan":    "96",
+	"bright-white":   "97",
+}
+
+var sgrPattern = regexp.MustCompile(`^[0-9;]+$`)
+
+// NewPalette builds a palette from a preset name ("default", "colorblind",
+// or "none") with optional per-element overrides. Returns nil when colors are disabled.
+func NewPalette(preset string, overrides Palette) (*Palette, error) {
+	if preset == "" {
+		preset = "default"
+	}
+	if preset == "none" {
+		return nil, nil
+	}
+	base, ok := presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme preset %q (must be one of: default, colorblind, none)", preset)
+	}
+
+	palette := base
+	for _, field := range []struct {
+		value  string
+		target *string
+	}{
+		{overrides.Cluster, &palette.Cluster},
+		{overrides.Header, &palette.Header},
+		{overrides.Error, &palette.Error},
+		{overrides.Warning, &palette.Warning},
+	} {
+		if field.value == "" {
+			continue
+		}
+		code, err := ParseColor(field.value)
+		if err != nil {
+			return nil, err
+		}
+		*field.target = code
+	}
+
+	if len(overrides.Clusters) > 0 {
+		palette.Clusters = nil
+		for _, spec := range overrides.Clusters {
+			code, err := ParseColor(spec)
+			if err != nil {
+				return nil, err
+			}
+			palette.Clusters = append(palette.Clusters, code)
+		}
+	}
+
+	return &palette, nil
+}
+
+// ParseColor converts a color specification such as "bold red", "bright-cyan"
+// or a raw SGR code like "38;5;208" into an ANSI SGR code
+func ParseColor(spec string) (string, error) {
+	var codes []string
+	for _, part := range strings.FieldsFunc(spec, func(r rune) bool { return r == ' ' || r == ',' || r == '+' }) {
+		part = strings.ToLower(part)
+		if code, ok := colorNames[part]; ok {
+			codes = append(codes, code)
+		} else if sgrPattern.MatchString(part) {
+			codes = append(codes, part)
+		} else {
+			return "", fmt.Errorf("unknown color %q", part)
+		}
+	}
+	return strings.Join(codes, ";"), nil
+}
+
+// paint wraps s in the given SGR code; a nil palette or empty code leaves s unchanged
+func (p *Palette) paint(code, s string) string {
+	if p == nil || code == "" || s == "" {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
@@ -0,0 +1,140 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// CompareTable pivots the table output of every cluster so that each object,
+// by its NAMESPACE and NAME, is one row and each cluster is a column holding
+// the object's values of columns, joined by spaces. With no columns, every
+// column but the object's name and its AGE is compared. Objects missing from
+// a cluster show "-" there, and a trailing SAME column tells whether the
+// object has the same values in every cluster that printed a table.
+func (m *Merger) CompareTable(results []executor.Result, columns []string) (*Table, error) {
+	merged := m.BuildTable(results)
+	if !merged.HasHeader() {
+		return nil, errors.New("--compare needs table output, e.g. from get")
+	}
+
+	keys := objectKeys(merged.Headers)
+	var values []int
+	for i, name := range merged.Headers {
+		if len(columns) == 0 && i > 0 && name != "AGE" && !slices.Contains(keys, i) {
+			values = append(values, i)
+		}
+	}
+	for _, name := range columns {
+		i, err := columnIndex(merged.Headers, name, "compare")
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, i)
+	}
+
+	clusters := tableClusters(results)
+	table := &Table{}
+	for _, i := range keys {
+		table.Headers = append(table.Headers, merged.Headers[i])
+	}
+	table.Headers = append(append(table.Headers, clusters...), "SAME")
+
+	objects, cells := pivotObjects(merged, keys, values)
+	for _, object := range objects {
+		row := slices.Clone(object.key)
+		same := "yes"
+		for _, cluster := range clusters {
+			value, ok := cells[object.id][cluster]
+			if !ok {
+				value = "-"
+			}
+			if !ok || value != cells[object.id][clusters[0]] {
+				same = "no"
+			}
+			row = append(row, value)
+		}
+		table.Rows = append(table.Rows, append(row, same))
+	}
+	return table, nil
+}
+
+// objectKeys returns the columns of a merged table identifying an object:
+// NAMESPACE and NAME, or the first column without a NAME column, e.g. with
+// custom-columns
+func objectKeys(headers []string) []int {
+	var keys []int
+	for i, name := range headers {
+		if i > 0 && (name == "NAMESPACE" || name == "NAME") {
+			keys = append(keys, i)
+		}
+	}
+	if len(keys) == 0 {
+		keys = []int{1}
+	}
+	return keys
+}
+
+// columnIndex returns the position of the column called name in headers,
+// case-insensitively, for the flag named flag
+func columnIndex(headers []string, name, flag string) (int, error) {
+	i := slices.IndexFunc(headers, func(h string) bool { return strings.EqualFold(h, name) })
+	if i <= 0 {
+		return 0, fmt.Errorf("no column %s to %s; the columns are %s", name, flag, strings.Join(headers[1:], ", "))
+	}
+	return i, nil
+}
+
+// tableClusters returns the clusters whose output is part of a merged table
+func tableClusters(results []executor.Result) []string {
+	var clusters []string
+	for _, r := range results {
+		if r.Output != "" && (r.Error == nil || hasPartialOutput(r)) {
+			clusters = append(clusters, r.Context)
+		}
+	}
+	return clusters
+}
+
+// pivotObject is an object of a merged table, identified by the values of
+// its key columns
+type pivotObject struct {
+	id  string
+	key []string
+}
+
+// pivotObjects returns the objects of a merged table in order of appearance,
+// and for each object id the values of columns, joined by spaces, per cluster
+func pivotObjects(merged *Table, keys, columns []int) ([]pivotObject, map[string]map[string]string) {
+	var objects []pivotObject
+	cells := make(map[string]map[string]string)
+	for _, row := range merged.Rows {
+		var key []string
+		for _, i := range keys {
+			key = append(key, cell(row, i))
+		}
+		id := strings.Join(key, "\x00")
+		if _, seen := cells[id]; !seen {
+			objects = append(objects, pivotObject{id: id, key: key})
+			cells[id] = make(map[string]string)
+		}
+		var value []string
+		for _, i := range columns {
+			value = append(value, cell(row, i))
+		}
+		cells[id][row[0]] = strings.Join(value, " ")
+	}
+	return objects, cells
+}
+
+// cell returns the i-th cell of row, which is shorter than the headers if
+// its cluster did not print the later columns
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
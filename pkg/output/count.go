@@ -0,0 +1,67 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+	"gopkg.in/yaml.v3"
+)
+
+// RenderCounts returns a line counting the objects each cluster's get output
+// lists, and their total, e.g. "prod-us: 42 pods, prod-eu: 39 pods, total: 81".
+// Table and name output count rows that pass the filter; JSON and YAML count
+// the items of the List. noun names an object, e.g. "pod". Failed clusters
+// count as "error".
+func (m *Merger) RenderCounts(results []executor.Result, format, noun string) string {
+	var parts []string
+	total := 0
+	for _, r := range results {
+		if r.Error != nil && !hasPartialOutput(r) {
+			parts = append(parts, r.Context+": error")
+			continue
+		}
+		n := m.count(r, format)
+		total += n
+		parts = append(parts, fmt.Sprintf("%s: %d %s", r.Context, n, plural(noun, n)))
+	}
+	return strings.Join(append(parts, fmt.Sprintf("total: %d", total)), ", ") + "\n"
+}
+
+// count returns the number of objects a cluster's output lists
+func (m *Merger) count(r executor.Result, format string) int {
+	if strings.TrimSpace(r.Output) == "" {
+		return 0
+	}
+	var list map[string]interface{}
+	switch format {
+	case "json":
+		if json.Unmarshal([]byte(r.Output), &list) != nil {
+			return 0
+		}
+	case "yaml":
+		if yaml.Unmarshal([]byte(r.Output), &list) != nil {
+			return 0
+		}
+	default:
+		return len(m.BuildTable([]executor.Result{r}).Rows)
+	}
+	if items, ok := list["items"].([]interface{}); ok {
+		return len(items)
+	}
+	return 1
+}
+
+// plural returns noun in the plural unless n is 1
+func plural(noun string, n int) string {
+	switch {
+	case n == 1:
+		return noun
+	case strings.HasSuffix(noun, "y") && !strings.HasSuffix(noun, "ey"):
+		return strings.TrimSuffix(noun, "y") + "ies"
+	case strings.HasSuffix(noun, "s"), strings.HasSuffix(noun, "x"), strings.HasSuffix(noun, "ch"), strings.HasSuffix(noun, "sh"):
+		return noun + "es"
+	}
+	return noun + "s"
+}
@@ -0,0 +1,240 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventWatcher writes the Events that kubectl events --watch -o json prints
+// in every cluster as one table, each row written as soon as its event
+// arrives, tagged with its cluster in the cluster's own color. Columns widen
+// as longer cells arrive, since the rows to come are unknown.
+type EventWatcher struct {
+	merger  *Merger
+	w       io.Writer
+	types   []string
+	reasons []string
+
+	// jsonl, if set, takes the events instead of w
+	jsonl *JSONLWriter
+
+	mu         sync.Mutex
+	pending    map[string][]string
+	seen       map[string]string
+	widths     []int
+	headerDone bool
+}
+
+// NewEventWatcher creates a watcher writing to w the events whose type and
+// reason are among types and reasons (case-insensitive, empty allows any)
+func (m *Merger) NewEventWatcher(w io.Writer, contexts, types, reasons []string) *EventWatcher {
+	m.clusterColumnWidth = len("CLUSTER")
+	for _, ctx := range contexts {
+		m.clusterColumnWidth = max(m.clusterColumnWidth, len(ctx))
+	}
+	return &EventWatcher{
+		merger:  m,
+		w:       w,
+		types:   types,
+		reasons: reasons,
+		pending: make(map[string][]string),
+		seen:    make(map[string]string),
+	}
+}
+
+// SetJSONL makes the watcher emit each event as a JSON Lines item record
+// instead of writing it as a row
+func (ew *EventWatcher) SetJSONL(j *JSONLWriter) {
+	ew.jsonl = j
+}
+
+// Add takes a line of a cluster's output. kubectl indents the JSON of each
+// event, so an event is complete at the line closing its top-level object.
+// Events already written are skipped, so a watch can be restarted.
+func (ew *EventWatcher) Add(context, line string) {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	if len(ew.pending[context]) == 0 && !strings.HasPrefix(line, "{") {
+		// Not JSON, e.g. a reconnection marker, which JSON Lines leave out
+		if ew.jsonl == nil {
+			fmt.Fprintln(ew.w, ew.tag(context, line))
+		}
+		return
+	}
+	ew.pending[context] = append(ew.pending[context], line)
+	if line != "}" {
+		return
+	}
+	data := strings.Join(ew.pending[context], "\n")
+	delete(ew.pending, context)
+
+	var obj struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(data), &obj); err != nil {
+		fmt.Fprintln(ew.w, ew.merger.colorError(fmt.Sprintf("# Error from cluster %s: invalid event: %v", context, err)))
+		return
+	}
+	// The events present when the watch starts come as a list
+	events := obj.Items
+	if obj.Items == nil {
+		events = []json.RawMessage{json.RawMessage(data)}
+	}
+	for _, raw := range events {
+		var event map[string]interface{}
+		if json.Unmarshal(raw, &event) != nil {
+			continue
+		}
+		metadata, _ := event["metadata"].(map[string]interface{})
+		uid, _ := metadata["uid"].(string)
+		version, _ := metadata["resourceVersion"].(string)
+		key := context + "/" + uid
+		if uid != "" && ew.seen[key] == version {
+			continue
+		}
+		ew.seen[key] = version
+		ew.write(context, event)
+	}
+}
+
+// write writes an event as a row, unless filtered out
+func (ew *EventWatcher) write(context string, event map[string]interface{}) {
+	eventType, _ := event["type"].(string)
+	reason, _ := event["reason"].(string)
+	if !matchAny(ew.types, eventType) || !matchAny(ew.reasons, reason) {
+		return
+	}
+
+	headers := []string{"LAST SEEN", "TYPE", "REASON", "OBJECT", "MESSAGE"}
+	cells := []string{eventAge(event), eventType, reason, eventObject(event), eventField(event, "message")}
+	if ew.merger.allNamespaces {
+		metadata, _ := event["metadata"].(map[string]interface{})
+		namespace, _ := metadata["namespace"].(string)
+		headers = append([]string{"NAMESPACE"}, headers...)
+		cells = append([]string{namespace}, cells...)
+	}
+	for i, cell := range cells {
+		if cell == "" {
+			cells[i] = "<none>"
+		}
+	}
+	if len(ew.widths) == 0 {
+		for _, h := range headers {
+			ew.widths = append(ew.widths, len(h))
+		}
+	}
+	// The message is last and never padded
+	for i := range len(cells) - 1 {
+		ew.widths[i] = max(ew.widths[i], len(cells[i]))
+	}
+
+	header, row := ew.pad(headers), ew.pad(cells)
+	if f := ew.merger.filter; f != nil {
+		line := ew.merger.formatLine(context, row)
+		if !f.MatchRow(context, line, row, parseColumns(header)) {
+			return
+		}
+	}
+	if ew.jsonl != nil {
+		if object, err := json.Marshal(event); err == nil {
+			ew.jsonl.Item(context, object)
+		}
+		return
+	}
+	m := ew.merger
+	if !ew.headerDone && !m.quiet {
+		fmt.Fprintln(ew.w, m.colorHeader(m.formatLine("CLUSTER", header)))
+	}
+	ew.headerDone = true
+	if eventType == "Warning" && m.palette != nil {
+		row = m.palette.paint(m.palette.Warning, row)
+	}
+	fmt.Fprintln(ew.w, ew.tag(context, row))
+}
+
+// tag prefixes a line with the cluster column, in the cluster's color
+func (ew *EventWatcher) tag(context, line string) string {
+	cluster := fmt.Sprintf("%-*s", ew.merger.clusterColumnWidth, context)
+	return ew.merger.clusterColor(context, cluster) + "   " + line
+}
+
+// pad joins cells into a line with each column padded to its width
+func (ew *EventWatcher) pad(cells []string) string {
+	var b strings.Builder
+	for i, cell := range cells {
+		if i == len(cells)-1 {
+			b.WriteString(cell)
+			break
+		}
+		fmt.Fprintf(&b, "%-*s   ", ew.widths[i], cell)
+	}
+	return b.String()
+}
+
+// matchAny reports whether value is one of values, ignoring case, or values is empty
+func matchAny(values []string, value string) bool {
+	return len(values) == 0 || slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(v, value)
+	})
+}
+
+// eventField returns a string field of an event
+func eventField(event map[string]interface{}, name string) string {
+	s, _ := event[name].(string)
+	return s
+}
+
+// eventObject returns the object an event is about as kind/name, e.g. "pod/web-1"
+func eventObject(event map[string]interface{}) string {
+	obj, _ := event["involvedObject"].(map[string]interface{})
+	kind, _ := obj["kind"].(string)
+	name, _ := obj["name"].(string)
+	return strings.ToLower(kind) + "/" + name
+}
+
+// eventAge returns how long ago an event was last seen, like kubectl's LAST
+// SEEN column, with the number of occurrences if it repeated
+func eventAge(event map[string]interface{}) string {
+	series, _ := event["series"].(map[string]interface{})
+	metadata, _ := event["metadata"].(map[string]interface{})
+	var seen time.Time
+	for _, v := range []interface{}{series["lastObservedTime"], event["lastTimestamp"], event["eventTime"], metadata["creationTimestamp"]} {
+		if s, ok := v.(string); ok && s != "" {
+			if t, err := time.Parse(time.RFC3339, s); err == nil {
+				seen = t
+				break
+			}
+		}
+	}
+	age := "<unknown>"
+	if !seen.IsZero() {
+		age = shortAge(time.Since(seen))
+	}
+	count, _ := event["count"].(float64)
+	if seriesCount, ok := series["count"].(float64); ok {
+		count = seriesCount
+	}
+	if count > 1 {
+		age += fmt.Sprintf(" (x%d)", int(count))
+	}
+	return age
+}
+
+// shortAge renders an age in its largest unit, e.g. 45s, 12m, 3h or 2d
+func shortAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", max(int(d.Seconds()), 0))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,110 @@
+package output
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RowFilter keeps only the rows matching a regular expression and/or column conditions
+type RowFilter struct {
+	pattern    *regexp.Regexp
+	conditions []fieldCondition
+}
+
+// fieldCondition is a single COLUMN=value or COLUMN!=value expression
+type fieldCondition struct {
+	column string
+	value  string
+	negate bool
+}
+
+// NewRowFilter creates a row filter from a regular expression and field
+// expressions such as "STATUS!=Running" or "NAMESPACE=default".
+// Returns nil if neither is given.
+func NewRowFilter(grep string, fieldFilters []string) (*RowFilter, error) {
+	if grep == "" && len(fieldFilters) == 0 {
+		return nil, nil
+	}
+
+	f := &RowFilter{}
+	if grep != "" {
+		pattern, err := regexp.Compile(grep)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --grep pattern: %w", err)
+		}
+		f.pattern = pattern
+	}
+
+	for _, expr := range fieldFilters {
+		cond, err := parseFieldCondition(expr)
+		if err != nil {
+			return nil, err
+		}
+		f.conditions = append(f.conditions, cond)
+	}
+
+	return f, nil
+}
+
+func parseFieldCondition(expr string) (fieldCondition, error) {
+	if idx := strings.Index(expr, "!="); idx > 0 {
+		return fieldCondition{
+			column: strings.ToUpper(strings.TrimSpace(expr[:idx])),
+			value:  strings.TrimSpace(expr[idx+2:]),
+			negate: true,
+		}, nil
+	}
+	if idx := strings.Index(expr, "="); idx > 0 {
+		value := strings.TrimPrefix(expr[idx+1:], "=")
+		return fieldCondition{
+			column: strings.ToUpper(strings.TrimSpace(expr[:idx])),
+			value:  strings.TrimSpace(value),
+		}, nil
+	}
+	return fieldCondition{}, fmt.Errorf("invalid field filter %q (expected COLUMN=value or COLUMN!=value)", expr)
+}
+
+// HasFieldConditions reports whether the filter needs table columns to be evaluated
+func (f *RowFilter) HasFieldConditions() bool {
+	return len(f.conditions) > 0
+}
+
+// MatchLine reports whether a plain line matches the regular expression
+func (f *RowFilter) MatchLine(line string) bool {
+	return f.pattern == nil || f.pattern.MatchString(line)
+}
+
+// MatchRow reports whether a table row matches the filter.
+// line is the row as printed (including the cluster column) and columns
+// are the columns of the cluster's own header, or nil if it had none.
+func (f *RowFilter) MatchRow(cluster, line, rawLine string, columns []column) bool {
+	if !f.MatchLine(line) {
+		return false
+	}
+	if len(f.conditions) == 0 {
+		return true
+	}
+
+	cells := splitRow(rawLine, columns)
+	for _, cond := range f.conditions {
+		value, ok := "", false
+		if cond.column == "CLUSTER" {
+			value, ok = cluster, true
+		} else {
+			for i, col := range columns {
+				if strings.ToUpper(col.Name) == cond.column {
+					value, ok = cells[i], true
+					break
+				}
+			}
+		}
+		if !ok {
+			return false
+		}
+		if (value == cond.value) == cond.negate {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Format is the rendering format for merged table output
+type Format string
+
+const (
+	// FormatTable renders an aligned text table (the default)
+	FormatTable Format = "table"
+	// FormatCSV renders comma-separated values
+	FormatCSV Format = "csv"
+	// FormatTSV renders tab-separated values
+	FormatTSV Format = "tsv"
+	// FormatMarkdown renders a GitHub-flavored Markdown table
+	FormatMarkdown Format = "markdown"
+	// FormatHTML renders an HTML table
+	FormatHTML Format = "html"
+	// FormatJSONL renders one JSON object per row, streamed as clusters complete
+	FormatJSONL Format = "jsonl"
+)
+
+// ParseFormat parses an output format name
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatTable, FormatCSV, FormatTSV, FormatMarkdown, FormatHTML, FormatJSONL:
+		return Format(s), nil
+	case "md":
+		return FormatMarkdown, nil
+	case "":
+		return FormatTable, nil
+	}
+	return "", fmt.Errorf("invalid output format %q (must be one of: table, csv, tsv, markdown, html, jsonl)", s)
+}
+
+// RenderTable renders a merged table in the given format
+func RenderTable(table *Table, format Format) (string, error) {
+	switch format {
+	case FormatTable:
+		return renderAligned(table, true, 0, nil), nil
+	case FormatCSV:
+		return renderDelimited(table, ',')
+	case FormatTSV:
+		return renderDelimited(table, '\t')
+	case FormatMarkdown:
+		return renderMarkdown(table), nil
+	case FormatHTML:
+		return renderHTML(table), nil
+	case FormatJSONL:
+		return renderJSONL(table)
+	}
+	return "", fmt.Errorf("unsupported table format %q", format)
+}
+
+// renderDelimited renders the table as properly quoted delimiter-separated values
+func renderDelimited(table *Table, delimiter rune) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = delimiter
+
+	if err := w.Write(table.Headers); err != nil {
+		return "", fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, row := range table.Rows {
+		if err := w.Write(row); err != nil {
+			return "", fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to render table: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// renderMarkdown renders the table as a GitHub-flavored Markdown table
+func renderMarkdown(table *Table) string {
+	var b strings.Builder
+
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, cell := range cells {
+			b.WriteString(" ")
+			b.WriteString(strings.ReplaceAll(cell, "|", "\\|"))
+			b.WriteString(" |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(table.Headers)
+	b.WriteString("|")
+	for range table.Headers {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, row := range table.Rows {
+		writeRow(row)
+	}
+
+	return b.String()
+}
+
+// renderHTML renders the table as an HTML table
+func renderHTML(table *Table) string {
+	var b strings.Builder
+
+	b.WriteString("<table>\n  <thead>\n    <tr>")
+	for _, header := range table.Headers {
+		b.WriteString("<th>" + html.EscapeString(header) + "</th>")
+	}
+	b.WriteString("</tr>\n  </thead>\n  <tbody>\n")
+	for _, row := range table.Rows {
+		b.WriteString("    <tr>")
+		for _, cell := range row {
+			b.WriteString("<td>" + html.EscapeString(cell) + "</td>")
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("  </tbody>\n</table>\n")
+
+	return b.String()
+}
+
+// RenderTable renders a table in the given format, using the merger's colors for aligned text
+func (m *Merger) RenderTable(table *Table, format Format) (string, error) {
+	if format == FormatTable {
+		return renderAligned(table, !m.quiet, 0, m.palette), nil
+	}
+	return RenderTable(table, format)
+}
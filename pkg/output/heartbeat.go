@@ -0,0 +1,81 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Heartbeat periodically prints a line on stderr for each cluster still
+// executing, e.g. "prod-eu: still running, 2m10s", so that logs that are not
+// a terminal, such as CI logs, show progress where the Progress line can't
+type Heartbeat struct {
+	mu       sync.Mutex
+	out      io.Writer
+	order    []string
+	pending  map[string]bool
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewHeartbeat creates a heartbeat for the given contexts, printed every interval
+func NewHeartbeat(contexts []string, interval time.Duration) *Heartbeat {
+	pending := make(map[string]bool, len(contexts))
+	for _, ctx := range contexts {
+		pending[ctx] = true
+	}
+	return &Heartbeat{
+		out:      os.Stderr,
+		order:    contexts,
+		pending:  pending,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start begins printing the heartbeat in the background
+func (h *Heartbeat) Start() {
+	start := time.Now()
+	go func() {
+		defer close(h.done)
+
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				h.beat(time.Since(start))
+			case <-h.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Done marks a context as finished
+func (h *Heartbeat) Done(contextName string) {
+	h.mu.Lock()
+	delete(h.pending, contextName)
+	h.mu.Unlock()
+}
+
+// Stop stops the heartbeat
+func (h *Heartbeat) Stop() {
+	close(h.stop)
+	<-h.done
+}
+
+func (h *Heartbeat) beat(elapsed time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, ctx := range h.order {
+		if h.pending[ctx] {
+			fmt.Fprintf(h.out, "%s: still running, %s\n", ctx, elapsed.Round(time.Second))
+		}
+	}
+}
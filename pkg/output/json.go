@@ -0,0 +1,106 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/itchyny/gojq"
+	"github.com/multikubectl/pkg/executor"
+)
+
+// ClusterAnnotation is the annotation injected into merged objects to record their cluster
+const ClusterAnnotation = "multikubectl/cluster"
+
+// MergeJSON merges `-o json` output from multiple clusters into a single
+// List whose items are annotated with the cluster they came from
+func (m *Merger) MergeJSON(results []executor.Result) (map[string]interface{}, error) {
+	items := []interface{}{}
+
+	for _, result := range results {
+		if result.Error != nil || strings.TrimSpace(result.Output) == "" {
+			continue
+		}
+
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(result.Output), &obj); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON from cluster %s: %w", result.Context, err)
+		}
+
+		if list, ok := obj["items"].([]interface{}); ok {
+			for _, item := range list {
+				if itemObj, ok := item.(map[string]interface{}); ok {
+					injectCluster(itemObj, result.Context)
+				}
+				items = append(items, item)
+			}
+		} else {
+			injectCluster(obj, result.Context)
+			items = append(items, obj)
+		}
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	}, nil
+}
+
+// injectCluster records the cluster name in the object's annotations
+func injectCluster(obj map[string]interface{}, cluster string) {
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		obj["metadata"] = metadata
+	}
+	annotations, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		annotations = map[string]interface{}{}
+		metadata["annotations"] = annotations
+	}
+	annotations[ClusterAnnotation] = cluster
+}
+
+// FormatJSON renders a merged JSON document with kubectl-style indentation
+func FormatJSON(doc interface{}) (string, error) {
+	data, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// ApplyQuery evaluates a jq expression against a merged JSON document and
+// renders each resulting value on its own
+func ApplyQuery(doc interface{}, query string) (string, error) {
+	parsed, err := gojq.Parse(query)
+	if err != nil {
+		return "", fmt.Errorf("invalid query: %w", err)
+	}
+
+	var output strings.Builder
+	iter := parsed.Run(doc)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := v.(error); ok {
+			return "", fmt.Errorf("query failed: %w", err)
+		}
+		if s, ok := v.(string); ok {
+			output.WriteString(s)
+			output.WriteString("\n")
+			continue
+		}
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal query result: %w", err)
+		}
+		output.Write(data)
+		output.WriteString("\n")
+	}
+
+	return output.String(), nil
+}
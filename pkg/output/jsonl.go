@@ -0,0 +1,195 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// Record kinds emitted in JSON Lines output
+const (
+	KindRow    = "row"
+	KindItem   = "item"
+	KindOutput = "output"
+	KindError  = "error"
+	// KindDeleted is a row of an object found deleted when a watch was re-established
+	KindDeleted = "deleted"
+)
+
+// jsonlRecord is a single line of JSON Lines output
+type jsonlRecord struct {
+	Context   string            `json:"context,omitempty"`
+	Kind      string            `json:"kind"`
+	Timestamp time.Time         `json:"timestamp"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Object    json.RawMessage   `json:"object,omitempty"`
+	Output    string            `json:"output,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	ExitCode  int               `json:"exitCode,omitempty"`
+}
+
+// JSONLWriter writes each cluster's result as JSON Lines as soon as it
+// completes, or each line of commands that run until stopped as soon as it
+// arrives. Its methods are safe to call from concurrent callbacks.
+type JSONLWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	merger *Merger
+	// pending holds the lines of each cluster's JSON object read so far
+	pending map[string][]string
+}
+
+// NewJSONLWriter creates a JSON Lines writer that parses tables like the merger
+func (m *Merger) NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{w: w, merger: m, pending: make(map[string][]string)}
+}
+
+// Write emits the records of a single cluster's result: one per table row or
+// JSON item, the raw output when it is neither, and the error if the cluster failed
+func (j *JSONLWriter) Write(r executor.Result) {
+	now := time.Now().UTC()
+	var records []jsonlRecord
+
+	if output := strings.TrimSpace(r.Output); output != "" {
+		if items, ok := jsonItems(output); ok {
+			for _, item := range items {
+				records = append(records, jsonlRecord{Context: r.Context, Kind: KindItem, Timestamp: now, Object: item})
+			}
+		} else if table := j.merger.BuildTable([]executor.Result{r}); table.HasHeader() {
+			for _, row := range table.Rows {
+				fields := make(map[string]string)
+				for i, header := range table.Headers[1:] {
+					if i+1 < len(row) {
+						fields[header] = row[i+1]
+					}
+				}
+				records = append(records, jsonlRecord{Context: r.Context, Kind: KindRow, Timestamp: now, Fields: fields})
+			}
+		} else {
+			records = append(records, jsonlRecord{Context: r.Context, Kind: KindOutput, Timestamp: now, Output: r.Output})
+		}
+	}
+
+	if r.Error != nil {
+		records = append(records, jsonlRecord{
+			Context:   r.Context,
+			Kind:      KindError,
+			Timestamp: now,
+			Error:     strings.TrimSpace(r.Error.Error()),
+			ExitCode:  r.ExitCode,
+		})
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.emit(records...)
+}
+
+// Line takes a line of a cluster's output as kubectl writes it, e.g. from
+// get --watch -o json or logs -f. kubectl indents JSON, so an object is
+// emitted as item records at the line closing it; other lines are emitted
+// as output records of their own.
+func (j *JSONLWriter) Line(context, line string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now().UTC()
+	if len(j.pending[context]) == 0 && !strings.HasPrefix(line, "{") {
+		j.emit(jsonlRecord{Context: context, Kind: KindOutput, Timestamp: now, Output: line})
+		return
+	}
+	j.pending[context] = append(j.pending[context], line)
+	if line != "}" {
+		return
+	}
+	data := strings.Join(j.pending[context], "\n")
+	delete(j.pending, context)
+	items, ok := jsonItems(data)
+	if !ok {
+		j.emit(jsonlRecord{Context: context, Kind: KindOutput, Timestamp: now, Output: data})
+		return
+	}
+	for _, item := range items {
+		j.emit(jsonlRecord{Context: context, Kind: KindItem, Timestamp: now, Object: item})
+	}
+}
+
+// Row emits a table row of a cluster as it arrives, keyed by column
+func (j *JSONLWriter) Row(context string, fields map[string]string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.emit(jsonlRecord{Context: context, Kind: KindRow, Timestamp: time.Now().UTC(), Fields: fields})
+}
+
+// Deleted emits the last row of an object a watch missed the deletion of
+func (j *JSONLWriter) Deleted(context string, fields map[string]string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.emit(jsonlRecord{Context: context, Kind: KindDeleted, Timestamp: time.Now().UTC(), Fields: fields})
+}
+
+// Item emits a JSON object of a cluster as it arrives
+func (j *JSONLWriter) Item(context string, object json.RawMessage) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.emit(jsonlRecord{Context: context, Kind: KindItem, Timestamp: time.Now().UTC(), Object: object})
+}
+
+// emit writes records, one per line. The caller holds j.mu.
+func (j *JSONLWriter) emit(records ...jsonlRecord) {
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			continue
+		}
+		j.w.Write(append(data, '\n'))
+	}
+}
+
+// jsonItems splits kubectl JSON output into its items, or the object itself if it isn't a list
+func jsonItems(output string) ([]json.RawMessage, bool) {
+	if !strings.HasPrefix(output, "{") {
+		return nil, false
+	}
+	var obj struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &obj); err != nil {
+		return nil, false
+	}
+	if obj.Items != nil {
+		return obj.Items, true
+	}
+	return []json.RawMessage{json.RawMessage(output)}, true
+}
+
+// renderJSONL renders each table row as a JSON object, tagged with the
+// context taken from its CLUSTER or CONTEXT column
+func renderJSONL(table *Table) (string, error) {
+	var b strings.Builder
+	now := time.Now().UTC()
+	for _, row := range table.Rows {
+		rec := jsonlRecord{Kind: KindRow, Timestamp: now, Fields: make(map[string]string)}
+		for i, header := range table.Headers {
+			if i >= len(row) {
+				break
+			}
+			if (header == "CLUSTER" || header == "CONTEXT") && rec.Context == "" {
+				rec.Context = row[i]
+				continue
+			}
+			rec.Fields[header] = row[i]
+		}
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return "", fmt.Errorf("failed to render row: %w", err)
+		}
+		b.Write(data)
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,299 @@
+package output
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// logLine is a log line of one cluster with the timestamp it is ordered by
+type logLine struct {
+	context string
+	line    string
+	time    time.Time
+	// stamped is set when the line has a timestamp of its own
+	stamped bool
+	// arrived is when a followed line was received
+	arrived time.Time
+	// dups are the other clusters that logged the same line
+	dups []string
+}
+
+// stripLogPrefix removes the "[pod/name/container] " kubectl logs --prefix
+// puts before each line
+func stripLogPrefix(line string) string {
+	if strings.HasPrefix(line, "[") {
+		if i := strings.Index(line, "] "); i >= 0 {
+			return line[i+2:]
+		}
+	}
+	return line
+}
+
+// logMessage returns a log line without its pod prefix and timestamp, which
+// is what identical lines of different clusters have in common
+func logMessage(line string) string {
+	_, message, _ := strings.Cut(stripLogPrefix(line), " ")
+	return message
+}
+
+// LogTimestamp parses the timestamp kubectl logs --timestamps puts at the
+// start of each line, after the "[pod/name/container] " of --prefix if present
+func LogTimestamp(line string) (time.Time, bool) {
+	field, _, _ := strings.Cut(stripLogPrefix(line), " ")
+	t, err := time.Parse(time.RFC3339Nano, field)
+	return t, err == nil
+}
+
+// StripLogTimestamp removes the timestamp LogTimestamp parses from a line,
+// keeping the "[pod/name/container] " of --prefix if present
+func StripLogTimestamp(line string) string {
+	if _, ok := LogTimestamp(line); !ok {
+		return line
+	}
+	rest := stripLogPrefix(line)
+	_, message, _ := strings.Cut(rest, " ")
+	return line[:len(line)-len(rest)] + message
+}
+
+// InterleaveLogs merges the `logs --timestamps` output of multiple clusters
+// into one stream ordered by timestamp, each line prefixed with its cluster.
+// Lines without a timestamp, like the continuation of a multi-line message,
+// stay after the line they follow.
+func (m *Merger) InterleaveLogs(results []executor.Result) string {
+	var lines []logLine
+	for _, result := range results {
+		if (result.Error != nil && !hasPartialOutput(result)) || result.Output == "" {
+			continue
+		}
+		var last time.Time
+		for _, line := range strings.Split(strings.TrimSuffix(result.Output, "\n"), "\n") {
+			t, stamped := LogTimestamp(line)
+			if stamped {
+				last = t
+			}
+			lines = append(lines, logLine{context: result.Context, line: line, time: last, stamped: stamped})
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].time.Before(lines[j].time) })
+
+	var output strings.Builder
+	for _, l := range m.dedupeLogs(lines, make(map[string]bool)) {
+		output.WriteString(m.formatLogLine(l))
+	}
+	return output.String()
+}
+
+// formatLogLine renders an interleaved log line with the cluster prefix,
+// or "" if the row filter drops it
+func (m *Merger) formatLogLine(l logLine) string {
+	if m.filter != nil && !m.filter.MatchLine(l.line) {
+		return ""
+	}
+	line := m.logPrefix(l.context) + m.colorLevel(l.line)
+	if len(l.dups) > 0 {
+		line += m.colorCluster(" (also in " + strings.Join(l.dups, ", ") + ")")
+	}
+	return line + "\n"
+}
+
+// SetDedupe collapses identical interleaved log lines of different clusters
+// logged within window of each other into one line listing the clusters.
+// 0 disables it.
+func (m *Merger) SetDedupe(window time.Duration) {
+	m.dedupeWindow = window
+}
+
+// dedupeLogs collapses the lines, ordered by timestamp, that repeat a line
+// of another cluster within the dedupe window. Lines without a timestamp
+// follow the fate of the line they continue; dropping records, per cluster,
+// whether that line was collapsed.
+func (m *Merger) dedupeLogs(lines []logLine, dropping map[string]bool) []logLine {
+	if m.dedupeWindow <= 0 {
+		return lines
+	}
+	kept := make(map[string]int)
+	var out []logLine
+	for _, l := range lines {
+		if !l.stamped {
+			if !dropping[l.context] {
+				out = append(out, l)
+			}
+			continue
+		}
+		key := logMessage(l.line)
+		if i, ok := kept[key]; ok && l.time.Sub(out[i].time) <= m.dedupeWindow &&
+			out[i].context != l.context && !slices.Contains(out[i].dups, l.context) {
+			out[i].dups = append(out[i].dups, l.context)
+			dropping[l.context] = true
+			continue
+		}
+		dropping[l.context] = false
+		kept[key] = len(out)
+		out = append(out, l)
+	}
+	return out
+}
+
+// LogLine renders a line of a cluster's followed logs with the cluster prefix,
+// or "" if the row filter drops it
+func (m *Merger) LogLine(context, line string) string {
+	return m.formatLogLine(logLine{context: context, line: line})
+}
+
+// errorLevel and warningLevel match the severity of common log formats:
+// bare ERROR/WARN words, level=error, "level":"warn" and klog's E0118/W0118
+var (
+	errorLevel   = regexp.MustCompile(`\b(ERROR|FATAL|PANIC|CRITICAL)\b|(?i:\blevel"?[=:] ?"?(error|fatal|panic|crit))|^E\d{4} `)
+	warningLevel = regexp.MustCompile(`\b(WARN|WARNING)\b|(?i:\blevel"?[=:] ?"?warn)|^W\d{4} `)
+)
+
+// SetLevelColors colors the log lines of prefixed and interleaved logs by
+// their severity
+func (m *Merger) SetLevelColors(enabled bool) {
+	m.levelColors = enabled
+}
+
+// SetContexts sets the target contexts, in order, which picks the color of
+// each cluster in prefixed and interleaved logs
+func (m *Merger) SetContexts(contexts []string) {
+	m.contextIndex = make(map[string]int)
+	for i, ctx := range contexts {
+		m.contextIndex[ctx] = i
+	}
+}
+
+// logPrefix renders a cluster's line prefix in the cluster's own color
+func (m *Merger) logPrefix(context string) string {
+	return m.clusterColor(context, m.renderPrefix(m.prefix, defaultPrefix, context, ""))
+}
+
+// clusterColor paints s in the color of a cluster
+func (m *Merger) clusterColor(context, s string) string {
+	if m.palette == nil || len(m.palette.Clusters) == 0 {
+		return m.colorCluster(s)
+	}
+	i, ok := m.contextIndex[context]
+	if !ok {
+		h := fnv.New32a()
+		h.Write([]byte(context))
+		i = int(h.Sum32() % uint32(len(m.palette.Clusters)))
+	}
+	return m.palette.paint(m.palette.Clusters[i%len(m.palette.Clusters)], s)
+}
+
+// colorLevel colors an error or warning log line when level colors are on
+func (m *Merger) colorLevel(line string) string {
+	if !m.levelColors || m.palette == nil {
+		return line
+	}
+	if errorLevel.MatchString(line) {
+		return m.palette.paint(m.palette.Error, line)
+	}
+	if warningLevel.MatchString(line) {
+		return m.palette.paint(m.palette.Warning, line)
+	}
+	return line
+}
+
+// maxHeldLines bounds the lines a LogInterleaver holds for its window
+const maxHeldLines = 10000
+
+// LogInterleaver orders followed log lines from multiple clusters by
+// timestamp. Lines are held for a short window after they arrive, so lines
+// of a slower cluster with an earlier timestamp can still go before them.
+type LogInterleaver struct {
+	merger  *Merger
+	w       io.Writer
+	window  time.Duration
+	mu      sync.Mutex
+	pending []logLine
+	last    map[string]time.Time
+	// dropping is the dedupe state of each cluster's last line
+	dropping map[string]bool
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewLogInterleaver starts writing the lines added to it to w, each held for window
+func (m *Merger) NewLogInterleaver(w io.Writer, window time.Duration) *LogInterleaver {
+	li := &LogInterleaver{
+		merger:   m,
+		w:        w,
+		window:   window,
+		last:     make(map[string]time.Time),
+		dropping: make(map[string]bool),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go li.loop()
+	return li
+}
+
+// Add queues a line of a cluster's followed logs
+func (li *LogInterleaver) Add(context, line string) {
+	li.mu.Lock()
+	defer li.mu.Unlock()
+	t, stamped := LogTimestamp(line)
+	if stamped {
+		li.last[context] = t
+	}
+	li.pending = append(li.pending, logLine{context: context, line: line, time: li.last[context], stamped: stamped, arrived: time.Now()})
+	if len(li.pending) >= maxHeldLines {
+		// Bound memory for bursts: write what is held now rather than at the end of the window
+		li.write(time.Time{})
+	}
+}
+
+// Close writes the lines still held and stops the interleaver
+func (li *LogInterleaver) Close() {
+	close(li.stop)
+	<-li.done
+	li.mu.Lock()
+	defer li.mu.Unlock()
+	li.write(time.Time{})
+}
+
+func (li *LogInterleaver) loop() {
+	defer close(li.done)
+	ticker := time.NewTicker(li.window / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			li.mu.Lock()
+			li.write(time.Now().Add(-li.window))
+			li.mu.Unlock()
+		case <-li.stop:
+			return
+		}
+	}
+}
+
+// write writes, in timestamp order, the pending lines up to the newest one
+// that arrived before cutoff (all of them for a zero cutoff)
+func (li *LogInterleaver) write(cutoff time.Time) {
+	sort.SliceStable(li.pending, func(i, j int) bool { return li.pending[i].time.Before(li.pending[j].time) })
+	n := len(li.pending)
+	if !cutoff.IsZero() {
+		// Lines queued later with an earlier timestamp stay ordered before the lines written
+		n = 0
+		for i, l := range li.pending {
+			if l.arrived.Before(cutoff) {
+				n = i + 1
+			}
+		}
+	}
+	for _, l := range li.merger.dedupeLogs(li.pending[:n], li.dropping) {
+		fmt.Fprint(li.w, li.merger.formatLogLine(l))
+	}
+	li.pending = li.pending[n:]
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// Cells of MatrixTable
+const (
+	MatrixPresent = "✓"
+	MatrixMissing = "✗"
+)
+
+// MatrixTable pivots the table output of every cluster into a presence
+// matrix: each object, by its NAMESPACE and NAME, is one row and each cluster
+// a column holding MatrixPresent if the object exists there and MatrixMissing
+// if it doesn't. Given a column, e.g. STATUS, present objects show their
+// value of it instead.
+func (m *Merger) MatrixTable(results []executor.Result, column string) (*Table, error) {
+	merged := m.BuildTable(results)
+	if !merged.HasHeader() {
+		return nil, errors.New("--matrix needs table output, e.g. from get")
+	}
+
+	keys := objectKeys(merged.Headers)
+	var values []int
+	if column != "" {
+		i, err := columnIndex(merged.Headers, column, "show in the matrix")
+		if err != nil {
+			return nil, err
+		}
+		values = []int{i}
+	}
+
+	clusters := tableClusters(results)
+	table := &Table{}
+	for _, i := range keys {
+		table.Headers = append(table.Headers, merged.Headers[i])
+	}
+	table.Headers = append(table.Headers, clusters...)
+
+	objects, cells := pivotObjects(merged, keys, values)
+	for _, object := range objects {
+		row := slices.Clone(object.key)
+		for _, cluster := range clusters {
+			value, ok := cells[object.id][cluster]
+			switch {
+			case !ok:
+				value = MatrixMissing
+			case column == "" || value == "":
+				value = MatrixPresent
+			}
+			row = append(row, value)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
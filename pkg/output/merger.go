@@ -3,6 +3,8 @@ package output
 import (
 	"fmt"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/multikubectl/pkg/executor"
 )
@@ -10,8 +12,34 @@ import (
 // Merger merges output from multiple clusters
 type Merger struct {
 	clusterColumnWidth int
+	quiet              bool
+	filter             *RowFilter
+	maxWidth           int
+	palette            *Palette
+	allNamespaces      bool
+	headerMode         HeaderMode
+	banner             *template.Template
+	prefix             *template.Template
+	aliases            map[string]string
+	contextIndex       map[string]int
+	levelColors        bool
+	dedupeWindow       time.Duration
 }
 
+// HeaderMode says how the header line of each cluster's table output is found
+type HeaderMode int
+
+const (
+	// HeaderDetect guesses from common kubectl column names whether the
+	// first line is a header, for output of unknown shape
+	HeaderDetect HeaderMode = iota
+	// HeaderFirstLine takes the first line as the header, as kubectl prints
+	// it for the table output of get, top, events and api-resources
+	HeaderFirstLine
+	// HeaderNone treats every line as data, e.g. for --no-headers or -o name
+	HeaderNone
+)
+
 // NewMerger creates a new output merger
 func NewMerger() *Merger {
 	return &Merger{
@@ -19,11 +47,49 @@ func NewMerger() *Merger {
 	}
 }
 
+// SetQuiet enables quiet mode, which drops headers, banners and inline
+// error lines so only raw data lines are emitted (errors are available via MergeErrors)
+func (m *Merger) SetQuiet(quiet bool) {
+	m.quiet = quiet
+}
+
+// SetFilter sets a row filter applied to data lines of every cluster
+func (m *Merger) SetFilter(filter *RowFilter) {
+	m.filter = filter
+}
+
+// SetMaxWidth realigns merged tables and truncates long cells so lines fit
+// in width columns (0 keeps kubectl's own formatting without truncation)
+func (m *Merger) SetMaxWidth(width int) {
+	m.maxWidth = width
+}
+
+// SetAllNamespaces tells the merger the command ran with -A/--all-namespaces,
+// so each cluster's table starts with a NAMESPACE column. Such tables are
+// always realigned, since namespace names of different widths would otherwise
+// shift the columns of one cluster against another.
+func (m *Merger) SetAllNamespaces(allNamespaces bool) {
+	m.allNamespaces = allNamespaces
+}
+
+// SetHeaderMode sets how header lines are recognized (HeaderDetect by default)
+func (m *Merger) SetHeaderMode(mode HeaderMode) {
+	m.headerMode = mode
+}
+
+// SetPalette sets the colors used for cluster names, headers and errors (nil disables colors)
+func (m *Merger) SetPalette(palette *Palette) {
+	m.palette = palette
+}
+
 // MergeResults merges results from multiple clusters into a single output
 func (m *Merger) MergeResults(results []executor.Result, showHeaders bool) string {
 	if len(results) == 0 {
 		return ""
 	}
+	if merged, ok := m.mergeSections(results, showHeaders); ok {
+		return merged
+	}
 
 	// Calculate the max cluster name length for alignment
 	m.clusterColumnWidth = 7 // minimum width for "CLUSTER"
@@ -33,13 +99,24 @@ func (m *Merger) MergeResults(results []executor.Result, showHeaders bool) strin
 		}
 	}
 
+	if m.maxWidth > 0 || m.allNamespaces {
+		if table := m.BuildTable(results); table.HasHeader() {
+			return renderAligned(table, showHeaders && !m.quiet, m.maxWidth, m.palette) + m.inlineErrors(results)
+		}
+	}
+
 	var output strings.Builder
 	headerPrinted := false
 
 	for _, result := range results {
 		if result.Error != nil {
-			output.WriteString(fmt.Sprintf("# Error from cluster %s: %v\n", result.Context, result.Error))
-			continue
+			if !m.quiet {
+				output.WriteString(m.colorError(fmt.Sprintf("# Error from cluster %s: %s", result.Context, errorMessage(result))))
+				output.WriteString("\n")
+			}
+			if !hasPartialOutput(result) {
+				continue
+			}
 		}
 
 		if result.Output == "" {
@@ -52,18 +129,17 @@ func (m *Merger) MergeResults(results []executor.Result, showHeaders bool) strin
 		}
 
 		// Process each line
+		var columns []column
 		for i, line := range lines {
 			if line == "" {
 				continue
 			}
 
-			// Check if this looks like a header line (first line with common header patterns)
-			isHeader := i == 0 && m.isHeaderLine(line)
-
-			if isHeader {
-				if !headerPrinted && showHeaders {
+			if m.isHeader(i, line) {
+				columns = parseColumns(line)
+				if !headerPrinted && showHeaders && !m.quiet {
 					// Print header with CLUSTER column
-					output.WriteString(m.formatLine("CLUSTER", line))
+					output.WriteString(m.colorHeader(m.formatLine("CLUSTER", line)))
 					output.WriteString("\n")
 					headerPrinted = true
 				}
@@ -72,7 +148,11 @@ func (m *Merger) MergeResults(results []executor.Result, showHeaders bool) strin
 			}
 
 			// Regular data line - add cluster name
-			output.WriteString(m.formatLine(result.Context, line))
+			formatted := m.formatLine(result.Context, line)
+			if m.filter != nil && !m.filter.MatchRow(result.Context, formatted, line, columns) {
+				continue
+			}
+			output.WriteString(m.colorLine(result.Context, line))
 			output.WriteString("\n")
 		}
 	}
@@ -80,8 +160,27 @@ func (m *Merger) MergeResults(results []executor.Result, showHeaders bool) strin
 	return output.String()
 }
 
+// isHeader reports whether line, the i-th line of a cluster's output, is its table header
+func (m *Merger) isHeader(i int, line string) bool {
+	if i != 0 {
+		return false
+	}
+	switch m.headerMode {
+	case HeaderFirstLine:
+		return true
+	case HeaderNone:
+		return false
+	}
+	return m.isHeaderLine(line)
+}
+
 // isHeaderLine checks if a line looks like a table header
 func (m *Merger) isHeaderLine(line string) bool {
+	// With -A kubectl's header always starts with the NAMESPACE column
+	if m.allNamespaces && strings.HasPrefix(line, "NAMESPACE ") {
+		return true
+	}
+
 	// Common kubectl header patterns
 	headerKeywords := []string{
 		"NAME", "NAMESPACE", "STATUS", "READY", "AGE", "RESTARTS",
@@ -112,19 +211,58 @@ func (m *Merger) formatLine(cluster, line string) string {
 	return fmt.Sprintf(format, cluster, line)
 }
 
+// colorLine formats a line like formatLine with the cluster column colored
+func (m *Merger) colorLine(cluster, line string) string {
+	if m.palette == nil {
+		return m.formatLine(cluster, line)
+	}
+	padded := fmt.Sprintf("%-*s", m.clusterColumnWidth, cluster)
+	return m.colorCluster(padded) + "   " + line
+}
+
+func (m *Merger) colorCluster(s string) string {
+	if m.palette == nil {
+		return s
+	}
+	return m.palette.paint(m.palette.Cluster, s)
+}
+
+func (m *Merger) colorHeader(s string) string {
+	if m.palette == nil {
+		return s
+	}
+	return m.palette.paint(m.palette.Header, s)
+}
+
+func (m *Merger) colorError(s string) string {
+	if m.palette == nil {
+		return s
+	}
+	return m.palette.paint(m.palette.Error, s)
+}
+
 // MergeNonTableOutput merges non-table output (like logs, describe, etc.)
 func (m *Merger) MergeNonTableOutput(results []executor.Result) string {
+	if m.quiet || m.prefix != nil {
+		return m.mergePrefixedOutput(results)
+	}
+
 	var output strings.Builder
 
 	for _, result := range results {
 		if result.Error != nil {
-			output.WriteString(fmt.Sprintf("=== Cluster: %s (Error: %v) ===\n", result.Context, result.Error))
-			continue
+			output.WriteString(m.colorError(m.renderPrefix(m.banner, defaultBanner, result.Context, strings.TrimSpace(errorMessage(result)))))
+			output.WriteString("\n")
+			if !hasPartialOutput(result) {
+				continue
+			}
+		} else {
+			output.WriteString(m.colorCluster(m.renderPrefix(m.banner, defaultBanner, result.Context, "")))
+			output.WriteString("\n")
 		}
-
-		output.WriteString(fmt.Sprintf("=== Cluster: %s ===\n", result.Context))
-		output.WriteString(result.Output)
-		if !strings.HasSuffix(result.Output, "\n") {
+		text := m.filterLines(result.Output)
+		output.WriteString(text)
+		if !strings.HasSuffix(text, "\n") {
 			output.WriteString("\n")
 		}
 		output.WriteString("\n")
@@ -132,3 +270,84 @@ func (m *Merger) MergeNonTableOutput(results []executor.Result) string {
 
 	return output.String()
 }
+
+// mergePrefixedOutput prefixes each non-table output line with its cluster name instead of using banners
+func (m *Merger) mergePrefixedOutput(results []executor.Result) string {
+	var output strings.Builder
+
+	for _, result := range results {
+		if (result.Error != nil && !hasPartialOutput(result)) || result.Output == "" {
+			continue
+		}
+		prefix := m.logPrefix(result.Context)
+		for _, line := range strings.Split(strings.TrimSuffix(result.Output, "\n"), "\n") {
+			if m.filter != nil && !m.filter.MatchLine(line) {
+				continue
+			}
+			output.WriteString(prefix + m.colorLevel(line) + "\n")
+		}
+	}
+
+	return output.String()
+}
+
+// MergeErrors renders one line per failed cluster
+func (m *Merger) MergeErrors(results []executor.Result) string {
+	var output strings.Builder
+
+	for _, result := range results {
+		if result.Error != nil {
+			output.WriteString(fmt.Sprintf("Error from cluster %s: %s\n", result.Context, strings.TrimSpace(errorMessage(result))))
+		}
+	}
+
+	return output.String()
+}
+
+// filterLines keeps only the lines of non-table output matching the row filter
+func (m *Merger) filterLines(text string) string {
+	if m.filter == nil || text == "" {
+		return text
+	}
+	var kept strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if m.filter.MatchLine(line) {
+			kept.WriteString(line)
+			kept.WriteString("\n")
+		}
+	}
+	return kept.String()
+}
+
+// inlineErrors renders the error lines shown below a merged table
+func (m *Merger) inlineErrors(results []executor.Result) string {
+	if m.quiet {
+		return ""
+	}
+	var output strings.Builder
+	for _, result := range results {
+		if result.Error != nil {
+			msg := fmt.Sprintf("%v", result.Error)
+			if hasPartialOutput(result) {
+				msg += " (partial output above)"
+			}
+			output.WriteString(m.colorError(fmt.Sprintf("# Error from cluster %s: %s", result.Context, msg)))
+			output.WriteString("\n")
+		}
+	}
+	return output.String()
+}
+
+// hasPartialOutput reports whether a timed out result captured output before it was killed
+func hasPartialOutput(result executor.Result) bool {
+	return result.TimedOut && strings.TrimSpace(result.Output) != ""
+}
+
+// errorMessage describes a result's error, pointing at partial output if any was captured
+func errorMessage(result executor.Result) string {
+	msg := fmt.Sprintf("%v", result.Error)
+	if hasPartialOutput(result) {
+		msg += " (partial output below)"
+	}
+	return msg
+}
@@ -0,0 +1,74 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// DefaultNameFormat is the format of merged `-o name` output
+const DefaultNameFormat = "{context}/{namespace}/{kind}/{name}"
+
+// MergeNames merges `-o name` output from multiple clusters, rendering each
+// object with format, in which {context}, {namespace}, {kind} and {name} are
+// replaced. Results may be kubectl's "kind/name" lines, or `-o json` output,
+// which also carries the namespace. Segments left empty, like the namespace
+// of a cluster-scoped object, are dropped.
+func (m *Merger) MergeNames(results []executor.Result, format string) (string, error) {
+	var out strings.Builder
+	for _, result := range results {
+		if result.Error != nil || strings.TrimSpace(result.Output) == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(result.Output), "{") {
+			var obj map[string]interface{}
+			if err := json.Unmarshal([]byte(result.Output), &obj); err != nil {
+				return "", fmt.Errorf("failed to parse JSON from cluster %s: %w", result.Context, err)
+			}
+			objects := []interface{}{obj}
+			if items, ok := obj["items"].([]interface{}); ok {
+				objects = items
+			}
+			for _, item := range objects {
+				o, _ := item.(map[string]interface{})
+				kind, namespace, name := jsonObjectName(o)
+				out.WriteString(formatName(format, result.Context, namespace, kind, name) + "\n")
+			}
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimRight(result.Output, "\n"), "\n") {
+			kind, name, ok := strings.Cut(strings.TrimSpace(line), "/")
+			if !ok {
+				continue
+			}
+			out.WriteString(formatName(format, result.Context, "", kind, name) + "\n")
+		}
+	}
+	return out.String(), nil
+}
+
+// jsonObjectName returns an object's kind as kubectl -o name prints it
+// (lowercase, qualified with the API group, e.g. "deployment.apps"), its
+// namespace and its name
+func jsonObjectName(obj map[string]interface{}) (string, string, string) {
+	kind, _ := obj["kind"].(string)
+	kind = strings.ToLower(kind)
+	if apiVersion, _ := obj["apiVersion"].(string); strings.Contains(apiVersion, "/") {
+		kind += "." + apiVersion[:strings.Index(apiVersion, "/")]
+	}
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	namespace, _ := metadata["namespace"].(string)
+	name, _ := metadata["name"].(string)
+	return kind, namespace, name
+}
+
+// formatName renders one object with a name format
+func formatName(format, context, namespace, kind, name string) string {
+	s := strings.NewReplacer("{context}", context, "{namespace}", namespace, "{kind}", kind, "{name}", name).Replace(format)
+	for strings.Contains(s, "//") {
+		s = strings.ReplaceAll(s, "//", "/")
+	}
+	return strings.Trim(s, "/")
+}
@@ -0,0 +1,46 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// Order determines the order in which cluster results are printed
+type Order string
+
+const (
+	// OrderConfig keeps the order given on the command line, in the config file, or in kubeconfig,
+	// after context priorities
+	OrderConfig Order = "config"
+	// OrderName sorts clusters alphabetically by context name
+	OrderName Order = "name"
+	// OrderDuration prints the fastest clusters first
+	OrderDuration Order = "duration"
+)
+
+// ParseOrder parses an order name
+func ParseOrder(s string) (Order, error) {
+	switch Order(s) {
+	case OrderConfig, OrderName, OrderDuration:
+		return Order(s), nil
+	case "":
+		return OrderConfig, nil
+	}
+	return "", fmt.Errorf("invalid order %q (must be one of: name, config, duration)", s)
+}
+
+// SortResults sorts results in place according to order
+func SortResults(results []executor.Result, order Order) {
+	switch order {
+	case OrderName:
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Context < results[j].Context
+		})
+	case OrderDuration:
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Duration < results[j].Duration
+		})
+	}
+}
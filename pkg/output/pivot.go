@@ -0,0 +1,31 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// PivotTable turns output holding one value per cluster, such as a jsonpath
+// or custom-columns query for a version, into a single row with a column per
+// cluster. A table header is dropped and the lines of a value spanning
+// several are joined with ", ". Clusters that failed show "error".
+func (m *Merger) PivotTable(results []executor.Result) *Table {
+	table := &Table{Rows: [][]string{nil}}
+	for _, r := range results {
+		table.Headers = append(table.Headers, r.Context)
+		if r.Error != nil && !hasPartialOutput(r) {
+			table.Rows[0] = append(table.Rows[0], "error")
+			continue
+		}
+		var lines []string
+		for i, line := range strings.Split(strings.TrimSpace(r.Output), "\n") {
+			if line = strings.TrimSpace(line); line == "" || m.isHeader(i, line) {
+				continue
+			}
+			lines = append(lines, strings.Join(strings.Fields(line), " "))
+		}
+		table.Rows[0] = append(table.Rows[0], strings.Join(lines, ", "))
+	}
+	return table
+}
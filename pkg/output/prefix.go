@@ -0,0 +1,71 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultBanner is the banner printed above each cluster's non-table output
+const DefaultBanner = "=== Cluster: {{.Context}}{{if .Error}} (Error: {{.Error}}){{end}} ==="
+
+// DefaultPrefix is the prefix of each line of non-table output in quiet mode
+const DefaultPrefix = "{{.Context}} "
+
+// PrefixData is the data banner and prefix templates are rendered with
+type PrefixData struct {
+	// Context is the cluster's kubeconfig context
+	Context string
+	// Alias is the context's alias from the config, or its name
+	Alias string
+	// Error is the cluster's error message, for banners of failed clusters
+	Error string
+}
+
+// ParsePrefix parses a banner or line prefix template
+func ParsePrefix(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// SetBanner sets the template of the banner above each cluster's non-table output
+func (m *Merger) SetBanner(tmpl *template.Template) {
+	m.banner = tmpl
+}
+
+// SetPrefix sets a template prefixed to each line of non-table output, which
+// replaces the banners (compact mode)
+func (m *Merger) SetPrefix(tmpl *template.Template) {
+	m.prefix = tmpl
+}
+
+// SetAliases sets the aliases of the contexts, available to banner and prefix templates
+func (m *Merger) SetAliases(aliases map[string]string) {
+	m.aliases = aliases
+}
+
+// Templates of the default banner and prefix
+var (
+	defaultBanner = template.Must(ParsePrefix("banner", DefaultBanner))
+	defaultPrefix = template.Must(ParsePrefix("prefix", DefaultPrefix))
+)
+
+// renderPrefix renders a banner or prefix template for a cluster, or the
+// fallback template if tmpl is unset
+func (m *Merger) renderPrefix(tmpl, fallback *template.Template, context, errMsg string) string {
+	if tmpl == nil {
+		tmpl = fallback
+	}
+	alias := m.aliases[context]
+	if alias == "" {
+		alias = context
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, PrefixData{Context: context, Alias: alias, Error: errMsg}); err != nil {
+		return fmt.Sprintf("%s (%v) ", context, err)
+	}
+	return out.String()
+}
@@ -0,0 +1,122 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	// progressDelay avoids flashing the indicator for commands that finish quickly
+	progressDelay    = 500 * time.Millisecond
+	progressInterval = 100 * time.Millisecond
+	// maxWaitingShown limits how many pending clusters are listed by name
+	maxWaitingShown = 3
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Progress renders a single-line progress indicator on stderr while clusters are executing
+type Progress struct {
+	mu      sync.Mutex
+	out     io.Writer
+	order   []string
+	pending map[string]bool
+	frame   int
+	drawn   bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// StderrIsTerminal reports whether stderr is a terminal
+func StderrIsTerminal() bool {
+	return term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// NewProgress creates a progress indicator for the given contexts
+func NewProgress(contexts []string) *Progress {
+	pending := make(map[string]bool, len(contexts))
+	for _, ctx := range contexts {
+		pending[ctx] = true
+	}
+	return &Progress{
+		out:     os.Stderr,
+		order:   contexts,
+		pending: pending,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start begins rendering the indicator in the background
+func (p *Progress) Start() {
+	go func() {
+		defer close(p.done)
+
+		select {
+		case <-time.After(progressDelay):
+		case <-p.stop:
+			return
+		}
+
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			p.draw()
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Done marks a context as finished
+func (p *Progress) Done(contextName string) {
+	p.mu.Lock()
+	delete(p.pending, contextName)
+	p.mu.Unlock()
+}
+
+// Stop stops the indicator and clears its line
+func (p *Progress) Stop() {
+	close(p.stop)
+	<-p.done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.drawn {
+		fmt.Fprint(p.out, "\r\x1b[K")
+	}
+}
+
+func (p *Progress) draw() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var waiting []string
+	for _, ctx := range p.order {
+		if p.pending[ctx] {
+			waiting = append(waiting, ctx)
+		}
+	}
+	if len(waiting) > maxWaitingShown {
+		waiting = append(waiting[:maxWaitingShown], fmt.Sprintf("+%d more", len(waiting)-maxWaitingShown))
+	}
+
+	total := len(p.order)
+	line := fmt.Sprintf("%s %d/%d clusters done", spinnerFrames[p.frame%len(spinnerFrames)], total-len(p.pending), total)
+	if len(waiting) > 0 {
+		line += fmt.Sprintf(" (waiting: %s)", strings.Join(waiting, ", "))
+	}
+	p.frame++
+
+	fmt.Fprintf(p.out, "\r\x1b[K%s", line)
+	p.drawn = true
+}
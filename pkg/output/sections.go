@@ -0,0 +1,91 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// section is one table of a cluster's output. "get all" and gets of several
+// resource types print one table per type, separated by blank lines.
+type section struct {
+	key    string
+	output string
+}
+
+// splitSections splits a cluster's output into its tables
+func (m *Merger) splitSections(output string) []section {
+	var sections []section
+	for _, chunk := range strings.Split(strings.TrimSuffix(output, "\n"), "\n\n") {
+		chunk = strings.Trim(chunk, "\n")
+		if chunk == "" {
+			continue
+		}
+		sections = append(sections, section{key: m.sectionKey(strings.Split(chunk, "\n")), output: chunk + "\n"})
+	}
+	return sections
+}
+
+// sectionKey identifies the table formed by lines across clusters: the
+// resource type prefixed to its names (e.g. "deployment.apps" for
+// "deployment.apps/web"), or else its column names. Lines without a header
+// all share the empty key.
+func (m *Merger) sectionKey(lines []string) string {
+	if !m.isHeader(0, lines[0]) {
+		return ""
+	}
+	columns := parseColumns(lines[0])
+	if len(lines) > 1 {
+		cells := splitRow(lines[1], columns)
+		for i, col := range columns {
+			if col.Name != "NAME" {
+				continue
+			}
+			if kind, _, ok := strings.Cut(cells[i], "/"); ok {
+				return kind
+			}
+		}
+	}
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// mergeSections merges output made of several tables section by section, so
+// each resource type gets its own merged table. Returns false if no cluster's
+// output has more than one kind of table.
+func (m *Merger) mergeSections(results []executor.Result, showHeaders bool) (string, bool) {
+	var keys []string
+	byKey := make(map[string][]executor.Result)
+	for _, result := range results {
+		if (result.Error != nil && !hasPartialOutput(result)) || result.Output == "" {
+			continue
+		}
+		for _, s := range m.splitSections(result.Output) {
+			if _, ok := byKey[s.key]; !ok {
+				keys = append(keys, s.key)
+			}
+			byKey[s.key] = append(byKey[s.key], executor.Result{Context: result.Context, Output: s.output})
+		}
+	}
+	if len(keys) < 2 {
+		return "", false
+	}
+
+	var parts []string
+	for _, key := range keys {
+		// Leave out tables whose rows were all filtered out
+		if len(m.BuildTable(byKey[key]).Rows) == 0 {
+			continue
+		}
+		parts = append(parts, m.MergeResults(byKey[key], showHeaders))
+	}
+	// Quiet output is data lines only, without blank lines between tables
+	separator := "\n"
+	if m.quiet {
+		separator = ""
+	}
+	return strings.Join(parts, separator) + m.inlineErrors(results), true
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// warningPrefix is the prefix kubectl uses for warnings (deprecations, throttling, etc.)
+const warningPrefix = "Warning:"
+
+// StripWarnings removes kubectl warning lines from each result's stderr and error message
+func StripWarnings(results []executor.Result) {
+	for i := range results {
+		r := &results[i]
+		stripped := stripWarningLines(r.Stderr)
+		if r.Error != nil && r.Error.Error() == r.Stderr {
+			r.Error = fmt.Errorf("%s", stripped)
+		}
+		r.Stderr = stripped
+	}
+}
+
+func stripWarningLines(s string) string {
+	if s == "" {
+		return s
+	}
+	var kept []string
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		if strings.HasPrefix(line, warningPrefix) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return strings.Join(kept, "\n") + "\n"
+}
+
+// MergeStderr renders the stderr of successful clusters, prefixing each line
+// with the cluster name. Stderr of failed clusters is already part of their error.
+func (m *Merger) MergeStderr(results []executor.Result) string {
+	var output strings.Builder
+
+	for _, result := range results {
+		if result.Error != nil || result.Stderr == "" {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(result.Stderr, "\n"), "\n") {
+			if m.palette != nil && strings.HasPrefix(line, warningPrefix) {
+				line = m.palette.paint(m.palette.Warning, line)
+			}
+			output.WriteString(fmt.Sprintf("[%s] %s\n", m.colorCluster(result.Context), line))
+		}
+	}
+
+	return output.String()
+}
@@ -0,0 +1,173 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// streamBufferSize bounds the output buffered before it is written out
+const streamBufferSize = 64 * 1024
+
+// openOutput returns a reader of a result's output, from its OutputFile if
+// the output was spooled to a file
+func openOutput(result executor.Result) (io.ReadCloser, error) {
+	if result.OutputFile == "" {
+		return io.NopCloser(strings.NewReader(result.Output)), nil
+	}
+	f, err := os.Open(result.OutputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the output of cluster %s: %w", result.Context, err)
+	}
+	return f, nil
+}
+
+// WriteJSON writes the same List as MergeJSON, formatted as by FormatJSON,
+// to w one item at a time: only one object of one cluster is held in memory
+// at once, however large the output of each cluster. Output already written
+// is left as is if a cluster's JSON can't be parsed.
+func (m *Merger) WriteJSON(w io.Writer, results []executor.Result) error {
+	out := bufio.NewWriterSize(w, streamBufferSize)
+	defer out.Flush()
+
+	fmt.Fprint(out, "{\n    \"apiVersion\": \"v1\",\n    \"items\": [")
+	written := 0
+	write := func(item interface{}) error {
+		data, err := json.MarshalIndent(item, "        ", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		if written > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString("\n        ")
+		out.Write(data)
+		written++
+		return nil
+	}
+
+	for _, result := range results {
+		if result.Error != nil {
+			continue
+		}
+		if err := streamJSONItems(result, write); err != nil {
+			return err
+		}
+	}
+
+	if written > 0 {
+		out.WriteString("\n    ")
+	}
+	fmt.Fprint(out, "],\n    \"kind\": \"List\"\n}\n")
+	return out.Flush()
+}
+
+// streamJSONItems calls write with each object of a cluster's JSON output,
+// annotated with its cluster: the items of a List, decoded one at a time, or
+// the output itself if it is a single object
+func streamJSONItems(result executor.Result, write func(interface{}) error) error {
+	r, err := openOutput(result)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	parseErr := func(err error) error {
+		return fmt.Errorf("failed to parse JSON from cluster %s: %w", result.Context, err)
+	}
+	decoder := json.NewDecoder(bufio.NewReaderSize(r, streamBufferSize))
+	for decoder.More() {
+		if tok, err := decoder.Token(); err != nil {
+			return parseErr(err)
+		} else if tok != json.Delim('{') {
+			return parseErr(fmt.Errorf("expected an object, got %v", tok))
+		}
+
+		// The fields of a single object; a List's items are written as they are decoded
+		obj := make(map[string]interface{})
+		list := false
+		for decoder.More() {
+			tok, err := decoder.Token()
+			if err != nil {
+				return parseErr(err)
+			}
+			key, _ := tok.(string)
+			var value interface{}
+			if key == "items" {
+				if tok, err = decoder.Token(); err != nil {
+					return parseErr(err)
+				}
+				if tok == json.Delim('[') {
+					list = true
+					if err := streamJSONList(decoder, result.Context, write); err != nil {
+						return parseErr(err)
+					}
+					continue
+				}
+				// Not a List after all, e.g. "items": null
+				if value, err = tokenValue(decoder, tok); err != nil {
+					return parseErr(err)
+				}
+			} else if err := decoder.Decode(&value); err != nil {
+				return parseErr(err)
+			}
+			obj[key] = value
+		}
+		if _, err := decoder.Token(); err != nil {
+			return parseErr(err)
+		}
+		if !list {
+			injectCluster(obj, result.Context)
+			if err := write(obj); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// streamJSONList writes the items of the array whose opening bracket
+// decoder just read, annotated with cluster
+func streamJSONList(decoder *json.Decoder, cluster string, write func(interface{}) error) error {
+	for decoder.More() {
+		var item interface{}
+		if err := decoder.Decode(&item); err != nil {
+			return err
+		}
+		if itemObj, ok := item.(map[string]interface{}); ok {
+			injectCluster(itemObj, cluster)
+		}
+		if err := write(item); err != nil {
+			return err
+		}
+	}
+	_, err := decoder.Token()
+	return err
+}
+
+// tokenValue decodes the rest of the value that tok, just read by decoder,
+// starts: an object, or tok itself if it is a scalar
+func tokenValue(decoder *json.Decoder, tok json.Token) (interface{}, error) {
+	if tok != json.Delim('{') {
+		return tok, nil
+	}
+	obj := make(map[string]interface{})
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		obj[key.(string)] = value
+	}
+	_, err := decoder.Token()
+	return obj, err
+}
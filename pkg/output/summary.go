@@ -0,0 +1,71 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// RenderSummary renders a footer with the status and duration of each cluster
+func (m *Merger) RenderSummary(results []executor.Result) string {
+	table := &Table{Headers: []string{"CLUSTER", "STATUS", "EXIT", "DURATION"}}
+	// Show who each command ran as when any cluster used impersonation
+	showAs := false
+	for _, r := range results {
+		if r.As != "" {
+			showAs = true
+			table.Headers = append(table.Headers, "AS")
+			break
+		}
+	}
+
+	var slowest executor.Result
+	failed := 0
+	for _, r := range results {
+		status := "ok"
+		switch {
+		case r.TimedOut:
+			status = "timeout"
+			failed++
+		case r.Error != nil:
+			status = "error"
+			failed++
+		}
+		if r.FailoverFor != "" {
+			status += " (failover for " + r.FailoverFor + ")"
+		}
+		row := []string{r.Context, status, fmt.Sprintf("%d", r.ExitCode), FormatDuration(r.Duration)}
+		if showAs {
+			as := r.As
+			if as == "" {
+				as = "-"
+			}
+			row = append(row, as)
+		}
+		table.Rows = append(table.Rows, row)
+		if r.Duration > slowest.Duration {
+			slowest = r
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(renderAligned(table, true, 0, m.palette))
+	b.WriteString(fmt.Sprintf("%d/%d clusters succeeded", len(results)-failed, len(results)))
+	if slowest.Context != "" {
+		b.WriteString(fmt.Sprintf(", slowest: %s (%s)", slowest.Context, FormatDuration(slowest.Duration)))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// FormatDuration renders a duration with precision suited to a summary
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
@@ -0,0 +1,147 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// Table is a merged table of rows from all clusters with a leading CLUSTER column
+type Table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// column describes a column of kubectl table output by its header name and start offset
+type column struct {
+	Name  string
+	Start int
+}
+
+// parseColumns determines the columns of a kubectl table from its header line.
+// kubectl pads columns with at least three spaces, so a column starts at a
+// non-space character preceded by two or more spaces. This keeps headers
+// such as "NOMINATED NODE" together as a single column.
+func parseColumns(header string) []column {
+	var columns []column
+	for i := 0; i < len(header); i++ {
+		if header[i] == ' ' {
+			continue
+		}
+		if i == 0 || (i >= 2 && header[i-1] == ' ' && header[i-2] == ' ') {
+			columns = append(columns, column{Start: i})
+		}
+	}
+	for i := range columns {
+		end := len(header)
+		if i+1 < len(columns) {
+			end = columns[i+1].Start
+		}
+		columns[i].Name = strings.TrimSpace(header[columns[i].Start:end])
+	}
+	return columns
+}
+
+// splitRow splits a data line into cells using the column offsets of its header
+func splitRow(line string, columns []column) []string {
+	cells := make([]string, len(columns))
+	for i, col := range columns {
+		if col.Start >= len(line) {
+			continue
+		}
+		end := len(line)
+		if i+1 < len(columns) && columns[i+1].Start < len(line) {
+			end = columns[i+1].Start
+		}
+		cells[i] = strings.TrimSpace(line[col.Start:end])
+	}
+	return cells
+}
+
+// BuildTable parses the table output of every cluster into a single structured
+// table. Each cluster's rows are split using that cluster's own header so
+// differing column widths between clusters don't matter. Output with several
+// tables (e.g. "get all") is split per table and cells are placed by column
+// name, so the merged headers are the union of all tables' columns.
+func (m *Merger) BuildTable(results []executor.Result) *Table {
+	table := &Table{}
+
+	for _, result := range results {
+		if (result.Error != nil && !hasPartialOutput(result)) || result.Output == "" {
+			continue
+		}
+
+		var columns []column
+		var positions []int
+		// i counts the lines of the current table; a blank line starts the next one
+		i := 0
+		for _, line := range strings.Split(strings.TrimSuffix(result.Output, "\n"), "\n") {
+			if line == "" {
+				i = 0
+				continue
+			}
+			i++
+			if m.isHeader(i-1, line) {
+				columns = parseColumns(line)
+				if table.Headers == nil {
+					table.Headers = []string{"CLUSTER"}
+				}
+				positions = table.positions(columns)
+				continue
+			}
+			if m.filter != nil && !m.filter.MatchRow(result.Context, m.formatLine(result.Context, line), line, columns) {
+				continue
+			}
+
+			if columns == nil {
+				table.Rows = append(table.Rows, []string{result.Context, line})
+				continue
+			}
+			row := make([]string, len(table.Headers))
+			row[0] = result.Context
+			for k, cell := range splitRow(line, columns) {
+				row[positions[k]] = cell
+			}
+			table.Rows = append(table.Rows, row)
+		}
+	}
+
+	if table.Headers == nil {
+		table.Headers = []string{"CLUSTER", "OUTPUT"}
+	}
+	// Rows added before a later table introduced more columns are padded
+	for i, row := range table.Rows {
+		for len(row) < len(table.Headers) {
+			row = append(row, "")
+		}
+		table.Rows[i] = row
+	}
+	return table
+}
+
+// positions returns the index in t.Headers of each column, adding the
+// columns not present yet. Repeated names map to successive columns.
+func (t *Table) positions(columns []column) []int {
+	positions := make([]int, len(columns))
+	used := make(map[int]bool)
+	for k, col := range columns {
+		positions[k] = -1
+		for j := 1; j < len(t.Headers); j++ {
+			if t.Headers[j] == col.Name && !used[j] {
+				positions[k] = j
+				break
+			}
+		}
+		if positions[k] < 0 {
+			t.Headers = append(t.Headers, col.Name)
+			positions[k] = len(t.Headers) - 1
+		}
+		used[positions[k]] = true
+	}
+	return positions
+}
+
+// HasHeader reports whether a kubectl header was recognized in any cluster's output
+func (t *Table) HasHeader() bool {
+	return len(t.Headers) != 2 || t.Headers[1] != "OUTPUT"
+}
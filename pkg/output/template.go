@@ -0,0 +1,126 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// TemplateData is the model an output template is evaluated against
+type TemplateData struct {
+	// Command is the kubectl command that was run
+	Command string
+	// Headers are the merged table headers, starting with CLUSTER
+	Headers []string
+	// Rows are the merged table rows of every cluster
+	Rows []TemplateRow
+	// Clusters holds the result of each cluster, in result order
+	Clusters []TemplateCluster
+	// Errors holds the clusters that failed
+	Errors []TemplateCluster
+	// Succeeded and Failed count the clusters by outcome
+	Succeeded int
+	Failed    int
+	// Slowest is the cluster that took longest
+	Slowest TemplateCluster
+}
+
+// TemplateRow is a single row of the merged table
+type TemplateRow struct {
+	Cluster string
+	// Cells are the row's values in header order, without the cluster
+	Cells []string
+	// Fields maps each header (e.g. "STATUS") to its value
+	Fields map[string]string
+}
+
+// TemplateCluster is the outcome of the command on a single cluster
+type TemplateCluster struct {
+	Name     string
+	Status   string
+	ExitCode int
+	Duration time.Duration
+	Error    string
+	Output   string
+	Rows     []TemplateRow
+}
+
+// templateFuncs are the functions available to output templates in addition to the builtins
+var templateFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"trim":     strings.TrimSpace,
+	"duration": FormatDuration,
+}
+
+// ParseTemplate reads and parses a Go template file for RenderTemplate
+func ParseTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Option("missingkey=zero").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// RenderTemplate evaluates tmpl against the merged table and per-cluster results
+func (m *Merger) RenderTemplate(tmpl *template.Template, args []string, results []executor.Result) (string, error) {
+	table := m.BuildTable(results)
+	data := TemplateData{
+		Command: strings.Join(args, " "),
+		Headers: table.Headers,
+	}
+
+	rowsByCluster := make(map[string][]TemplateRow)
+	for _, cells := range table.Rows {
+		row := TemplateRow{Cluster: cells[0], Cells: cells[1:], Fields: make(map[string]string)}
+		for i, header := range table.Headers {
+			if i < len(cells) {
+				row.Fields[header] = cells[i]
+			}
+		}
+		data.Rows = append(data.Rows, row)
+		rowsByCluster[row.Cluster] = append(rowsByCluster[row.Cluster], row)
+	}
+
+	for _, r := range results {
+		c := TemplateCluster{
+			Name:     r.Context,
+			Status:   "ok",
+			ExitCode: r.ExitCode,
+			Duration: r.Duration,
+			Output:   r.Output,
+			Rows:     rowsByCluster[r.Context],
+		}
+		if r.Error != nil {
+			c.Status = "error"
+			if r.TimedOut {
+				c.Status = "timeout"
+			}
+			c.Error = strings.TrimSpace(r.Error.Error())
+			data.Errors = append(data.Errors, c)
+			data.Failed++
+		} else {
+			data.Succeeded++
+		}
+		if r.Duration > data.Slowest.Duration {
+			data.Slowest = c
+		}
+		data.Clusters = append(data.Clusters, c)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,134 @@
+package output
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	// minTruncatedWidth is the narrowest a truncated column may become
+	minTruncatedWidth = 5
+	ellipsis          = "…"
+)
+
+// IsTerminal reports whether stdout is a terminal
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// TerminalWidth returns the width of the terminal attached to stdout, or 0 if stdout is not a terminal
+func TerminalWidth() int {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// columnWidths returns the display width of each column of the table
+func columnWidths(headers []string, rows [][]string) []int {
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = utf8.RuneCountInString(header)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) {
+				if n := utf8.RuneCountInString(cell); n > widths[i] {
+					widths[i] = n
+				}
+			}
+		}
+	}
+	return widths
+}
+
+// fitWidths shrinks the widest columns until the table fits in maxWidth.
+// The CLUSTER and NAME columns are never shrunk.
+func fitWidths(headers []string, widths []int, maxWidth int) {
+	total := func() int {
+		sum := 3 * (len(widths) - 1)
+		for _, w := range widths {
+			sum += w
+		}
+		return sum
+	}
+
+	for total() > maxWidth {
+		widest := -1
+		for i, w := range widths {
+			if i == 0 || headers[i] == "NAME" || w <= minTruncatedWidth {
+				continue
+			}
+			if widest == -1 || w > widths[widest] {
+				widest = i
+			}
+		}
+		if widest == -1 {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+// truncateCell shortens a cell to width, marking the cut with an ellipsis
+func truncateCell(cell string, width int) string {
+	if utf8.RuneCountInString(cell) <= width {
+		return cell
+	}
+	runes := []rune(cell)
+	return string(runes[:width-1]) + ellipsis
+}
+
+// renderAligned renders the table as aligned text, truncating cells so each
+// line fits in maxWidth (0 means no limit)
+func renderAligned(table *Table, showHeaders bool, maxWidth int, palette *Palette) string {
+	widths := columnWidths(table.Headers, table.Rows)
+	if maxWidth > 0 {
+		fitWidths(table.Headers, widths, maxWidth)
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string, header bool) {
+		var line strings.Builder
+		for i, width := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = truncateCell(cells[i], width)
+			}
+			if i > 0 {
+				line.WriteString("   ")
+			}
+			padded := cell
+			if i < len(widths)-1 {
+				padded += strings.Repeat(" ", width-utf8.RuneCountInString(cell))
+			}
+			if i == 0 && !header && palette != nil {
+				padded = palette.paint(palette.Cluster, padded)
+			}
+			line.WriteString(padded)
+		}
+		text := strings.TrimRight(line.String(), " ")
+		if header && palette != nil {
+			text = palette.paint(palette.Header, text)
+		}
+		b.WriteString(text)
+		b.WriteString("\n")
+	}
+
+	if showHeaders {
+		writeRow(table.Headers, true)
+	}
+	for _, row := range table.Rows {
+		writeRow(row, false)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,191 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// WatchTable writes the table rows kubectl get --watch prints in every
+// cluster as one table, each row written as it arrives with its cluster.
+// Each cluster's watch is tracked separately: when one is re-established,
+// kubectl lists the objects again and the rows of objects unchanged since
+// they were last written are skipped. Objects deleted while the watch was
+// down are only found by comparing a fresh listing, see Relisted.
+type WatchTable struct {
+	merger *Merger
+	w      io.Writer
+
+	// jsonl, if set, takes the rows instead of w
+	jsonl *JSONLWriter
+
+	mu         sync.Mutex
+	clusters   map[string]*watchState
+	headerDone bool
+}
+
+// watchState is the bookkeeping of one cluster's watch
+type watchState struct {
+	columns []column
+	// awaitingHeader is set until the header line of a new watch was read
+	awaitingHeader bool
+	// rows holds the last row read per object, without its AGE
+	rows map[string]string
+	// lines holds the last row written per object, as kubectl printed it
+	lines map[string]string
+}
+
+// NewWatchTable creates a watch table writing to w
+func (m *Merger) NewWatchTable(w io.Writer, contexts []string) *WatchTable {
+	m.clusterColumnWidth = len("CLUSTER")
+	for _, ctx := range contexts {
+		m.clusterColumnWidth = max(m.clusterColumnWidth, len(ctx))
+	}
+	wt := &WatchTable{merger: m, w: w, clusters: make(map[string]*watchState)}
+	for _, ctx := range contexts {
+		wt.clusters[ctx] = &watchState{rows: make(map[string]string), lines: make(map[string]string)}
+		wt.Restart(ctx)
+	}
+	return wt
+}
+
+// SetJSONL makes the table emit each row as a JSON Lines record, keyed by
+// its cluster's header, instead of writing it
+func (wt *WatchTable) SetJSONL(j *JSONLWriter) {
+	wt.jsonl = j
+}
+
+// Restart tells the table a cluster's watch is being re-established, so
+// its next line is a header again
+func (wt *WatchTable) Restart(context string) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	wt.clusters[context].awaitingHeader = wt.merger.headerMode != HeaderNone
+}
+
+// Add takes a line of a cluster's output
+func (wt *WatchTable) Add(context, line string) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	m := wt.merger
+	state := wt.clusters[context]
+	if state.awaitingHeader {
+		state.awaitingHeader = false
+		state.columns = parseColumns(line)
+		if !wt.headerDone && !m.quiet && wt.jsonl == nil {
+			fmt.Fprintln(wt.w, m.colorHeader(m.formatLine("CLUSTER", line)))
+		}
+		wt.headerDone = true
+		return
+	}
+
+	key, content := watchRow(line, state.columns)
+	if state.rows[key] == content {
+		return
+	}
+	state.rows[key] = content
+	if m.filter != nil && !m.filter.MatchRow(context, m.formatLine(context, line), line, state.columns) {
+		return
+	}
+	state.lines[key] = line
+	if wt.jsonl != nil {
+		wt.emit(context, line, state.columns)
+		return
+	}
+	wt.write(context, line)
+}
+
+// Relisted takes a fresh listing of a cluster's objects, header included,
+// taken before its watch is re-established. A new watch lists only the
+// objects that exist, so those written before but missing from the listing
+// were deleted while the watch was down and are reported here.
+func (wt *WatchTable) Relisted(context, listing string) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	state := wt.clusters[context]
+	lines := strings.Split(strings.TrimRight(listing, "\n"), "\n")
+	if len(state.columns) == 0 || len(lines) == 0 {
+		// Without a header, rows can't be told apart by object
+		return
+	}
+	columns := parseColumns(lines[0])
+	listed := make(map[string]bool)
+	for _, line := range lines[1:] {
+		key, _ := watchRow(line, columns)
+		listed[key] = true
+	}
+	for _, key := range slices.Sorted(maps.Keys(state.rows)) {
+		if listed[key] {
+			continue
+		}
+		delete(state.rows, key)
+		line, written := state.lines[key]
+		if !written {
+			continue
+		}
+		delete(state.lines, key)
+		if wt.jsonl != nil {
+			wt.jsonl.Deleted(context, rowFields(line, state.columns))
+			continue
+		}
+		wt.write(context, fmt.Sprintf("--- deleted while disconnected: %s ---", key))
+	}
+}
+
+// emit hands a row to the JSON Lines writer, as fields when the header is known
+func (wt *WatchTable) emit(context, line string, columns []column) {
+	if len(columns) == 0 {
+		wt.jsonl.Line(context, line)
+		return
+	}
+	wt.jsonl.Row(context, rowFields(line, columns))
+}
+
+// rowFields returns the cells of a row keyed by column
+func rowFields(line string, columns []column) map[string]string {
+	fields := make(map[string]string)
+	for i, cell := range splitRow(line, columns) {
+		fields[columns[i].Name] = cell
+	}
+	return fields
+}
+
+// Note writes a marker line, e.g. a reconnection, into a cluster's rows.
+// Markers are left out of JSON Lines.
+func (wt *WatchTable) Note(context, line string) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	if wt.jsonl != nil {
+		return
+	}
+	wt.write(context, line)
+}
+
+func (wt *WatchTable) write(context, line string) {
+	cluster := fmt.Sprintf("%-*s", wt.merger.clusterColumnWidth, context)
+	fmt.Fprintln(wt.w, wt.merger.clusterColor(context, cluster)+"   "+line)
+}
+
+// watchRow returns the object a watch row is about, by its NAMESPACE and
+// NAME cells, and the row's cells without the AGE, which grows on every
+// listing. Without columns the whole line is both.
+func watchRow(line string, columns []column) (string, string) {
+	if len(columns) == 0 {
+		return line, line
+	}
+	cells := splitRow(line, columns)
+	var key, content []string
+	for i, col := range columns {
+		switch col.Name {
+		case "NAMESPACE", "NAME":
+			key = append(key, cells[i])
+		case "AGE":
+			continue
+		}
+		content = append(content, cells[i])
+	}
+	return strings.Join(key, "/"), strings.Join(content, "\x00")
+}
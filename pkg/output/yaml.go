@@ -0,0 +1,164 @@
+package output
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+	"gopkg.in/yaml.v3"
+)
+
+// WriteYAML merges `-o yaml` output from multiple clusters into one stream of
+// "---" separated documents, one per object, written to w. Each document is
+// preceded by a "# cluster: <context>" comment and annotated with its cluster
+// like MergeJSON, so the stream can be piped to kubectl apply or yq as is.
+// The items of a List are split off its text and parsed one at a time, so
+// only one object of one cluster is held in memory at once. Output already
+// written is left as is if a cluster's YAML can't be parsed.
+func (m *Merger) WriteYAML(w io.Writer, results []executor.Result) error {
+	out := bufio.NewWriterSize(w, streamBufferSize)
+	defer out.Flush()
+
+	for _, result := range results {
+		if result.Error != nil {
+			continue
+		}
+		if err := streamYAMLObjects(out, result); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// streamYAMLObjects writes the objects of a cluster's YAML output to out. The
+// output is read line by line: each document's top-level "items:" sequence,
+// as kubectl prints a List, is cut into its items at the "- " starting each,
+// and documents without one are written as a single object.
+func streamYAMLObjects(out io.Writer, result executor.Result) error {
+	r, err := openOutput(result)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// doc holds the top-level lines of the current document, item the lines
+	// of the current List item
+	var doc, item strings.Builder
+	inItems, isList := false, false
+	emit := func(text string) error {
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(text), &node); err != nil {
+			return fmt.Errorf("failed to parse YAML from cluster %s: %w", result.Context, err)
+		}
+		if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+			return nil
+		}
+		obj := node.Content[0]
+		annotateNode(obj, result.Context)
+		fmt.Fprintf(out, "---\n# cluster: %s\n", result.Context)
+		encoder := yaml.NewEncoder(out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(obj); err != nil {
+			return fmt.Errorf("failed to marshal YAML: %w", err)
+		}
+		return encoder.Close()
+	}
+	flushItem := func() error {
+		if item.Len() == 0 {
+			return nil
+		}
+		defer item.Reset()
+		return emit(item.String())
+	}
+	flushDoc := func() error {
+		if err := flushItem(); err != nil {
+			return err
+		}
+		defer doc.Reset()
+		if isList || strings.TrimSpace(doc.String()) == "" {
+			return nil
+		}
+		return emit(doc.String())
+	}
+
+	reader := bufio.NewReaderSize(r, streamBufferSize)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			switch {
+			case line == "---\n" || line == "---":
+				if err := flushDoc(); err != nil {
+					return err
+				}
+				inItems, isList = false, false
+			case inItems && strings.HasPrefix(line, "- "):
+				if err := flushItem(); err != nil {
+					return err
+				}
+				item.WriteString(line[2:])
+			case inItems && (strings.HasPrefix(line, "  ") || strings.TrimSpace(line) == ""):
+				item.WriteString(strings.TrimPrefix(line, "  "))
+			case strings.TrimRight(line, "\n") == "items:":
+				inItems, isList = true, true
+			case strings.TrimRight(line, "\n") == "items: []":
+				isList = true
+			default:
+				if inItems {
+					if err := flushItem(); err != nil {
+						return err
+					}
+					inItems = false
+				}
+				doc.WriteString(line)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read the output of cluster %s: %w", result.Context, err)
+		}
+	}
+	return flushDoc()
+}
+
+// mappingValue returns the value of key in a YAML mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// childMapping returns the mapping under key in a YAML mapping node, adding
+// an empty one if the key is missing or null
+func childMapping(node *yaml.Node, key string) *yaml.Node {
+	if value := mappingValue(node, key); value != nil {
+		if value.Kind != yaml.MappingNode {
+			*value = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		return value
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	return value
+}
+
+// annotateNode records the cluster name in a YAML object's annotations
+func annotateNode(obj *yaml.Node, cluster string) {
+	annotations := childMapping(childMapping(obj, "metadata"), "annotations")
+	if value := mappingValue(annotations, ClusterAnnotation); value != nil {
+		value.Value = cluster
+		return
+	}
+	annotations.Content = append(annotations.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: ClusterAnnotation},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: cluster})
+}
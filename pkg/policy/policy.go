@@ -0,0 +1,309 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Action is what a matching rule does with a command
+type Action string
+
+const (
+	Allow   Action = "allow"
+	Deny    Action = "deny"
+	Confirm Action = "confirm"
+)
+
+// AllNamespaces is the namespace of a request made with -A/--all-namespaces
+const AllNamespaces = "*"
+
+// UnknownResource is the resource of a request whose objects can't be told
+// from the command line, such as apply -f
+const UnknownResource = "?"
+
+// Match selects the commands a rule applies to. Empty fields match anything.
+type Match struct {
+	Verbs      []string `yaml:"verbs,omitempty"`
+	Resources  []string `yaml:"resources,omitempty"`
+	Namespaces []string `yaml:"namespaces,omitempty"`
+	// ClusterSelector is a label selector on the context labels from the config
+	ClusterSelector string `yaml:"clusterSelector,omitempty"`
+
+	selector labels.Selector
+}
+
+// Rule is a single policy rule
+type Rule struct {
+	Name    string `yaml:"name"`
+	Match   Match  `yaml:"match"`
+	Action  Action `yaml:"action"`
+	Message string `yaml:"message,omitempty"`
+}
+
+// Policy is an ordered list of rules. The first matching rule decides;
+// commands that match no rule are allowed.
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Request is a command about to run against one cluster
+type Request struct {
+	Verb      string
+	Resource  string
+	Namespace string
+	Cluster   string
+	Labels    map[string]string
+}
+
+// Load reads a policy file. A missing file yields a nil policy.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		switch r.Action {
+		case Allow, Deny, Confirm:
+		default:
+			return nil, fmt.Errorf("invalid policy: %s: action must be allow, deny, or confirm", r.Name)
+		}
+		if r.Match.ClusterSelector != "" {
+			sel, err := labels.Parse(r.Match.ClusterSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid policy: %s: clusterSelector: %w", r.Name, err)
+			}
+			r.Match.selector = sel
+		}
+	}
+	return &p, nil
+}
+
+// Evaluate returns the first rule matching the request, or nil if none does.
+// Deny and confirm rules naming resources match an UnknownResource, so that
+// they fail closed; allow rules don't.
+func (p *Policy) Evaluate(req Request) *Rule {
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Match.matches(req, rule.Action != Allow) {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (m Match) matches(req Request, unknownResource bool) bool {
+	if len(m.Verbs) > 0 && !slices.Contains(m.Verbs, req.Verb) {
+		return false
+	}
+	if len(m.Resources) > 0 && req.Resource == UnknownResource {
+		if !unknownResource {
+			return false
+		}
+	} else if len(m.Resources) > 0 && !slices.ContainsFunc(m.Resources, func(r string) bool {
+		return req.Resource != "" && NormalizeResource(r) == req.Resource
+	}) {
+		return false
+	}
+	// A request across all namespaces touches every namespace a rule names
+	if len(m.Namespaces) > 0 && req.Namespace != AllNamespaces && !slices.Contains(m.Namespaces, req.Namespace) {
+		return false
+	}
+	if m.selector != nil && !m.selector.Matches(labels.Set(req.Labels)) {
+		return false
+	}
+	return true
+}
+
+// shortNames maps common kubectl short names to resources
+var shortNames = map[string]string{
+	"po": "pod", "deploy": "deployment", "svc": "service", "ns": "namespace",
+	"no": "node", "cm": "configmap", "ds": "daemonset", "sts": "statefulset",
+	"rs": "replicaset", "ing": "ingress", "pvc": "persistentvolumeclaim",
+	"pv": "persistentvolume", "sa": "serviceaccount", "cj": "cronjob",
+	"ep": "endpoints", "hpa": "horizontalpodautoscaler", "netpol": "networkpolicy",
+	"crd": "customresourcedefinition", "pdb": "poddisruptionbudget",
+}
+
+// NormalizeResource reduces a resource as written on the command line
+// ("pods", "po", "deployment.apps/web") to its lowercase singular kind name
+func NormalizeResource(resource string) string {
+	resource = strings.ToLower(resource)
+	resource, _, _ = strings.Cut(resource, "/")
+	resource, _, _ = strings.Cut(resource, ".")
+	if full, ok := shortNames[resource]; ok {
+		return full
+	}
+	switch {
+	case resource == "endpoints":
+		return resource
+	case strings.HasSuffix(resource, "sses"), strings.HasSuffix(resource, "ches"):
+		return strings.TrimSuffix(resource, "es")
+	case strings.HasSuffix(resource, "ies"):
+		return strings.TrimSuffix(resource, "ies") + "y"
+	case strings.HasSuffix(resource, "s") && !strings.HasSuffix(resource, "ss"):
+		return strings.TrimSuffix(resource, "s")
+	}
+	return resource
+}
+
+// subcommandVerbs are kubectl verbs whose resource follows a subcommand, e.g. "rollout restart deploy/web"
+var subcommandVerbs = []string{"rollout", "set"}
+
+// valueFlags are the kubectl flags, global and of its commands, whose value
+// can be a separate argument. Boolean flags and flags only given as
+// --flag=value don't need to be listed.
+var valueFlags = []string{
+	// Global flags
+	"-n", "--namespace", "-s", "--server", "-v", "--v", "--as", "--as-group", "--as-uid",
+	"--cache-dir", "--certificate-authority", "--client-certificate", "--client-key",
+	"--cluster", "--context", "--kubeconfig", "--password", "--username", "--user",
+	"--token", "--request-timeout", "--tls-server-name", "--profile", "--profile-output",
+	"--log-file", "--log-dir", "--vmodule", "--log-flush-frequency", "--kuberc",
+	// Selecting objects
+	"-f", "--filename", "-k", "--kustomize", "-l", "--selector", "--field-selector",
+	"--raw", "--subresource", "--chunk-size", "--resource-version", "--for",
+	// Output
+	"-o", "--output", "--template", "--sort-by", "-L", "--label-columns",
+	// Changing objects
+	"--replicas", "--current-replicas", "--grace-period", "--cascade", "--timeout",
+	"--type", "--patch", "--patch-file", "--field-manager", "--overrides",
+	"--image", "--image-pull-policy", "--env", "--port", "--target-port",
+	"--protocol", "--name", "--labels", "--restart", "--limits", "--requests",
+	"--serviceaccount", "--schedule", "--prune-allowlist", "--applyset",
+	"--cluster-ip", "--external-ip", "--load-balancer-ip",
+	"--session-affinity", "--hostport", "--revision", "--to-revision",
+	"--min", "--max", "--cpu-percent", "--min-available", "--max-unavailable",
+	"--from", "--from-file", "--from-literal", "--from-env-file", "--docker-server",
+	"--docker-username", "--docker-password", "--docker-email", "--cert", "--key",
+	"--role", "--clusterrole", "--group", "--verb", "--resource", "--resource-name",
+	"--aggregation-rule", "--duration", "--audience", "--bound-object-kind",
+	"--bound-object-name", "--bound-object-uid", "--containers", "--pod-selector",
+	"--skip-wait-for-delete-timeout", "--pod-running-timeout",
+	// Logs, exec, attach, cp and port-forward
+	"-c", "--container", "--since", "--since-time", "--tail", "--limit-bytes",
+	"--max-log-requests", "--address", "--retries",
+	// Debug
+	"--target", "--copy-to", "--set-image", "--custom",
+}
+
+// fileFlags give the objects of a command in files instead of on the command line
+var fileFlags = []string{"-f", "--filename", "-k", "--kustomize"}
+
+// FromFiles reports whether kubectl args take their objects from files (-f, -k)
+func FromFiles(args []string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool {
+		name, _, _ := strings.Cut(arg, "=")
+		return slices.Contains(fileFlags, name)
+	})
+}
+
+// TakesValue reports whether flag, given to a kubectl verb, takes the next
+// argument as its value. -p is a patch for patch but --previous for logs.
+func TakesValue(verb, flag string) bool {
+	if flag == "-p" {
+		return verb == "patch" || verb == "exec"
+	}
+	return slices.Contains(valueFlags, flag)
+}
+
+// positionalIndexes returns the indexes of the positional kubectl arguments:
+// the verb, its subcommand, resources and names, but not flags and their
+// values. Arguments after "--" are the command run in a container.
+func positionalIndexes(args []string) []int {
+	var indexes []int
+	verb := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return indexes
+		case TakesValue(verb, arg):
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			if verb == "" {
+				verb = arg
+			}
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// Positional returns the positional kubectl arguments, starting with the verb
+func Positional(args []string) []string {
+	var positional []string
+	for _, i := range positionalIndexes(args) {
+		positional = append(positional, args[i])
+	}
+	return positional
+}
+
+// VerbFirst returns args with the verb moved in front of the kubectl flags
+// given before it, which kubectl runs the same way: "-n kube-system delete
+// pod x" becomes "delete -n kube-system pod x"
+func VerbFirst(args []string) []string {
+	indexes := positionalIndexes(args)
+	if len(indexes) == 0 || indexes[0] == 0 {
+		return args
+	}
+	verb := indexes[0]
+	return slices.Concat(args[verb:verb+1], args[:verb], args[verb+1:])
+}
+
+// ParseArgs extracts the verb, normalized resource and namespace from kubectl
+// arguments. The namespace is "" if not given and AllNamespaces for -A.
+func ParseArgs(args []string) (verb, resource, namespace string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			i = len(args)
+		case arg == "-A" || arg == "--all-namespaces" || arg == "--all-namespaces=true":
+			namespace = AllNamespaces
+		case arg == "-n" || arg == "--namespace":
+			if i+1 < len(args) {
+				namespace = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(arg, "--namespace="):
+			namespace = strings.TrimPrefix(arg, "--namespace=")
+		case strings.HasPrefix(arg, "-n") && !strings.HasPrefix(arg, "--"):
+			namespace = strings.TrimPrefix(arg[2:], "=")
+		case TakesValue("", arg):
+			i++
+		}
+	}
+
+	positional := Positional(args)
+	if len(positional) == 0 {
+		return "", "", namespace
+	}
+	verb = positional[0]
+	rest := positional[1:]
+	if slices.Contains(subcommandVerbs, verb) && len(rest) > 0 {
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		resource = NormalizeResource(rest[0])
+	}
+	return verb, resource, namespace
+}
@@ -0,0 +1,90 @@
+package quantity
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// suffixes maps Kubernetes quantity suffixes to multipliers, longest first
+var suffixes = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"Ki", 1 << 10},
+	{"Mi", 1 << 20},
+	{"Gi", 1 << 30},
+	{"Ti", 1 << 40},
+	{"Pi", 1 << 50},
+	{"Ei", 1 << 60},
+	{"n", 1e-9},
+	{"u", 1e-6},
+	{"m", 1e-3},
+	{"k", 1e3},
+	{"M", 1e6},
+	{"G", 1e9},
+	{"T", 1e12},
+	{"P", 1e15},
+	{"E", 1e18},
+}
+
+// Parse parses a Kubernetes resource quantity such as "500m", "2Gi" or "1e3"
+// into its value in base units (cores, bytes, counts)
+func Parse(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty quantity")
+	}
+
+	multiplier := 1.0
+	number := s
+	for _, sfx := range suffixes {
+		if strings.HasSuffix(s, sfx.suffix) {
+			// "1E3" style exponents are handled by ParseFloat, not as the E suffix
+			if sfx.suffix == "E" && strings.ContainsAny(strings.TrimSuffix(s, "E"), "eE") {
+				continue
+			}
+			multiplier = sfx.multiplier
+			number = strings.TrimSuffix(s, sfx.suffix)
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	return value * multiplier, nil
+}
+
+// FormatCPU renders a number of cores compactly, e.g. "250m" or "3.5"
+func FormatCPU(cores float64) string {
+	if cores != 0 && math.Abs(cores) < 1 {
+		return fmt.Sprintf("%dm", int64(math.Round(cores*1000)))
+	}
+	return strconv.FormatFloat(math.Round(cores*100)/100, 'f', -1, 64)
+}
+
+// FormatBytes renders a byte count with a binary suffix, e.g. "15.6Gi"
+func FormatBytes(bytes float64) string {
+	units := []string{"", "Ki", "Mi", "Gi", "Ti", "Pi", "Ei"}
+	i := 0
+	for math.Abs(bytes) >= 1024 && i < len(units)-1 {
+		bytes /= 1024
+		i++
+	}
+	return strconv.FormatFloat(math.Round(bytes*10)/10, 'f', -1, 64) + units[i]
+}
+
+// Format renders a quantity for the given resource name, using cores for
+// CPU, binary units for memory and storage, and plain numbers otherwise
+func Format(resource string, value float64) string {
+	switch {
+	case strings.Contains(resource, "cpu"):
+		return FormatCPU(value)
+	case strings.Contains(resource, "memory"), strings.Contains(resource, "storage"):
+		return FormatBytes(value)
+	}
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
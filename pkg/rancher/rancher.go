@@ -0,0 +1,117 @@
+package rancher
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// DefaultPrefix is prepended to Rancher cluster names to form context names
+const DefaultPrefix = "rancher-"
+
+// Cluster is a downstream cluster managed by Rancher
+type Cluster struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// Client talks to the Rancher management (v3) API
+type Client struct {
+	url   string
+	token string
+	http  *http.Client
+}
+
+// NewClient creates a client for the Rancher server at serverURL using an API token
+func NewClient(serverURL, token string, insecure bool) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &Client{
+		url:   strings.TrimRight(serverURL, "/"),
+		token: token,
+		http:  &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}
+}
+
+// ListClusters returns every cluster visible to the token, following pagination
+func (c *Client) ListClusters() ([]Cluster, error) {
+	var clusters []Cluster
+	next := c.url + "/v3/clusters"
+	for next != "" {
+		var page struct {
+			Data       []Cluster `json:"data"`
+			Pagination struct {
+				Next string `json:"next"`
+			} `json:"pagination"`
+		}
+		if err := c.get(next, &page); err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, page.Data...)
+		next = page.Pagination.Next
+	}
+	return clusters, nil
+}
+
+func (c *Client) get(u string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Rancher: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Rancher returned %s for %s", resp.Status, u)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse Rancher response: %w", err)
+	}
+	return nil
+}
+
+// KubeConfig builds a kubeconfig with one context per cluster, named prefix+name.
+// Contexts reach the clusters through the Rancher API proxy using the same token.
+func (c *Client) KubeConfig(clusters []Cluster, prefix string, insecure bool) *clientcmdapi.Config {
+	config := clientcmdapi.NewConfig()
+
+	user := prefix + hostname(c.url)
+	config.AuthInfos[user] = &clientcmdapi.AuthInfo{Token: c.token}
+
+	for _, cl := range clusters {
+		name := prefix + cl.Name
+		config.Clusters[name] = &clientcmdapi.Cluster{
+			Server:                fmt.Sprintf("%s/k8s/clusters/%s", c.url, cl.ID),
+			InsecureSkipTLSVerify: insecure,
+		}
+		config.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: user}
+	}
+	return config
+}
+
+func hostname(serverURL string) string {
+	if u, err := url.Parse(serverURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return serverURL
+}
+
+// Hostname returns the host of the Rancher server, used to name generated files
+func (c *Client) Hostname() string {
+	return hostname(c.url)
+}
@@ -0,0 +1,154 @@
+package reachability
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/executor"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultStateFile = "reachability"
+	DefaultTTL       = 2 * time.Minute
+	// ProbeTimeout bounds a probe, much shorter than a command's timeout
+	ProbeTimeout = 5 * time.Second
+)
+
+// ProbeArgs is the cheap request used to probe a cluster
+var ProbeArgs = []string{"get", "--raw", "/version"}
+
+// Entry is the last known reachability of a context
+type Entry struct {
+	Reachable bool `yaml:"reachable"`
+	// Latency is the round trip of the last probe, if the last check was one
+	Latency time.Duration `yaml:"latency,omitempty"`
+	Checked time.Time     `yaml:"checked"`
+}
+
+// Cache keeps whether each context could be reached by recent invocations,
+// so that a cluster's health is known without probing it on every command.
+// Entries older than the TTL are ignored.
+type Cache struct {
+	Contexts map[string]*Entry `yaml:"contexts,omitempty"`
+
+	ttl     time.Duration
+	changed bool
+}
+
+// GetStatePath returns the path to the reachability cache file
+func GetStatePath() string {
+	return filepath.Join(config.GetConfigDir(), DefaultStateFile)
+}
+
+// Load loads the reachability cache from file
+func Load(ttl time.Duration) (*Cache, error) {
+	c := &Cache{
+		Contexts: make(map[string]*Entry),
+		ttl:      ttl,
+	}
+
+	data, err := os.ReadFile(GetStatePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("failed to read reachability cache: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to parse reachability cache: %w", err)
+	}
+	if c.Contexts == nil {
+		c.Contexts = make(map[string]*Entry)
+	}
+
+	return c, nil
+}
+
+// Save saves the reachability cache to file if it was modified. Expired
+// entries are dropped.
+func (c *Cache) Save() error {
+	if !c.changed {
+		return nil
+	}
+
+	for ctx, entry := range c.Contexts {
+		if time.Since(entry.Checked) >= c.ttl {
+			delete(c.Contexts, ctx)
+		}
+	}
+
+	if err := os.MkdirAll(config.GetConfigDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reachability cache: %w", err)
+	}
+
+	// Concurrent invocations save too, so the file is replaced atomically
+	// rather than rewritten in place
+	f, err := os.CreateTemp(config.GetConfigDir(), DefaultStateFile+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write reachability cache: %w", err)
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), GetStatePath())
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write reachability cache: %w", err)
+	}
+
+	c.changed = false
+	return nil
+}
+
+// Get returns the entry of a context if it was checked within the TTL
+func (c *Cache) Get(context string) (Entry, bool) {
+	entry, ok := c.Contexts[context]
+	if !ok || time.Since(entry.Checked) >= c.ttl {
+		return Entry{}, false
+	}
+	return *entry, true
+}
+
+// Stale returns the contexts without an entry checked within the TTL
+func (c *Cache) Stale(contexts []string) []string {
+	var stale []string
+	for _, ctx := range contexts {
+		if _, ok := c.Get(ctx); !ok {
+			stale = append(stale, ctx)
+		}
+	}
+	return stale
+}
+
+// Record updates the entries of the contexts results ran on. A cluster
+// answering with an error such as NotFound was reached. Probe tells whether
+// the results come from ProbeArgs, whose durations are kept as latencies;
+// those of other commands measure the command rather than the cluster.
+// Canceled results and those that never started kubectl, e.g. timing out
+// waiting for the rate limit, tell nothing about the cluster and are skipped.
+func (c *Cache) Record(results []executor.Result, probe bool) {
+	for _, r := range results {
+		if r.Canceled || r.StartTime.IsZero() {
+			continue
+		}
+		entry := &Entry{Reachable: !r.Unreachable(), Checked: time.Now()}
+		if probe && entry.Reachable {
+			entry.Latency = r.Duration
+		}
+		c.Contexts[r.Context] = entry
+		c.changed = true
+	}
+}
@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/multikubectl/pkg/cluster"
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/discovery"
+)
+
+// DiscoveryProviders returns the cluster discovery providers enabled in the config
+func DiscoveryProviders(mgr *cluster.Manager, cfg *config.MultiKubeConfig) []discovery.Provider {
+	var providers []discovery.Provider
+
+	if cfg.Teleport != nil && cfg.Teleport.Enabled {
+		t := discovery.NewTeleport(filepath.Join(config.GetConfigDir(), "teleport", "kubeconfig"))
+		t.Proxy = cfg.Teleport.Proxy
+		t.Cluster = cfg.Teleport.Cluster
+		if cfg.Teleport.Prefix != "" {
+			t.Prefix = cfg.Teleport.Prefix
+		}
+		if cfg.Teleport.Binary != "" {
+			t.Binary = cfg.Teleport.Binary
+		}
+		providers = append(providers, t)
+	}
+
+	if cfg.VCluster != nil && cfg.VCluster.Enabled {
+		hosts := cfg.VCluster.Hosts
+		if len(hosts) == 0 {
+			hosts = mgr.GetContexts()
+		}
+		v := discovery.NewVCluster(hosts, filepath.Join(config.GetConfigDir(), "vcluster", "kubeconfig"))
+		v.KubeConfigPath = strings.Join(mgr.GetKubeConfigPaths(), string(filepath.ListSeparator))
+		v.HostKubeConfigs = make(map[string]string)
+		for _, host := range hosts {
+			if path := mgr.KubeConfigFor(host); path != "" {
+				v.HostKubeConfigs[host] = path
+			}
+		}
+		if cfg.VCluster.Binary != "" {
+			v.Binary = cfg.VCluster.Binary
+		}
+		providers = append(providers, v)
+	}
+
+	return providers
+}
+
+// AddExtraContexts merges the extra kubeconfig files from the config and
+// runs the enabled discovery providers, adding their contexts to mgr.
+// Failures are reported to stderr as warnings. It returns the providers
+// that succeeded by name.
+func AddExtraContexts(mgr *cluster.Manager, cfg *config.MultiKubeConfig, stderr io.Writer) map[string]discovery.Provider {
+	for _, path := range cfg.KubeConfigs {
+		if err := mgr.AddKubeConfigFile(path); err != nil {
+			fmt.Fprintf(stderr, "Warning: %v\n", err)
+		}
+	}
+
+	providers := make(map[string]discovery.Provider)
+	for _, p := range DiscoveryProviders(mgr, cfg) {
+		names, err := p.Contexts()
+		if err != nil {
+			fmt.Fprintf(stderr, "Warning: %s discovery failed: %v\n", p.Name(), err)
+			continue
+		}
+		mgr.AddDiscovered(p.Name(), names)
+		providers[p.Name()] = p
+	}
+	return providers
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// Effective is what kubectl runs with for a context once flags, environment
+// variables and config are resolved
+type Effective struct {
+	Context string
+	// KubeConfig is the file kubectl reads the context from
+	KubeConfig string
+	Server     string
+	Auth       string
+	// Namespace is the context's default namespace
+	Namespace string
+	// Tunnel is the SSH jump host the API server is reached through
+	Tunnel string
+	// As is the identity the context's commands are impersonating
+	As string
+	// ExtraArgs are the flags added to each kubectl command for the context
+	// besides --kubeconfig and --context
+	ExtraArgs []string
+	// Env holds the variables added to kubectl's environment
+	Env     []string
+	Timeout time.Duration
+}
+
+// Effective resolves what kubectl runs with for ctx, as NewExecutor and
+// prepare set it up, without logging in or opening tunnels
+func (r *Runner) Effective(ctx string) Effective {
+	settings := r.cfg.SettingsFor(ctx)
+	e := Effective{
+		Context:    ctx,
+		KubeConfig: r.kubeConfigFile(ctx),
+		Server:     r.mgr.GetServer(ctx),
+		Auth:       r.mgr.GetAuthMethod(ctx),
+		Namespace:  r.defaultNamespace(ctx),
+		Env:        proxyEnv(settings.Proxy, r.mgr.GetProxyURL(ctx)),
+		Timeout:    r.opts.Timeout,
+	}
+	if e.Timeout <= 0 {
+		e.Timeout = DefaultTimeout
+	}
+	if settings.Tunnel != nil {
+		e.Tunnel = settings.Tunnel.Host
+		e.ExtraArgs = tunnelArgs(e.Server, settings.Tunnel.LocalPort)
+	}
+	var as executor.Impersonation
+	if settings.Impersonate != nil {
+		as = executor.Impersonation{User: settings.Impersonate.User, Groups: settings.Impersonate.Groups}
+	} else {
+		as = executor.Impersonation{User: r.opts.As, Groups: r.opts.AsGroups}
+	}
+	e.As = as.String()
+	e.ExtraArgs = append(e.ExtraArgs, as.Args()...)
+	return e
+}
+
+// tunnelArgs returns the flags routing kubectl through an SSH tunnel to
+// server, as the tunnel sets them once open. The local port is only known
+// then unless the config fixes it.
+func tunnelArgs(server string, localPort int) []string {
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	port := "<port>"
+	if localPort != 0 {
+		port = strconv.Itoa(localPort)
+	}
+	return []string{"--server", u.Scheme + "://127.0.0.1:" + port + u.Path, "--tls-server-name", u.Hostname()}
+}
+
+// kubeConfigFile returns the kubeconfig file kubectl reads ctx from: a
+// discovery provider's, an extra kubeconfig, or the file of the loaded
+// kubeconfig defining it
+func (r *Runner) kubeConfigFile(ctx string) string {
+	if slices.Contains(r.mgr.EphemeralContexts(), ctx) {
+		return "(ephemeral)"
+	}
+	if p, ok := r.providers[r.mgr.DiscoveredBy(ctx)]; ok {
+		return p.KubeConfig()
+	}
+	if path := r.mgr.KubeConfigFor(ctx); path != "" {
+		return path
+	}
+	if info, ok := r.mgr.Context(ctx); ok && info.File != "" {
+		return info.File
+	}
+	return strings.Join(r.mgr.GetKubeConfigPaths(), string(filepath.ListSeparator))
+}
@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/policy"
+)
+
+const (
+	// ExistsWarn warns about contexts missing the objects a command changes but still runs there
+	ExistsWarn = "warn"
+	// ExistsSkip leaves contexts missing the objects a command changes out of the run
+	ExistsSkip = "skip"
+)
+
+// existenceVerbs are the commands changing named objects whose existence
+// the pre-flight checks
+var existenceVerbs = []string{"annotate", "autoscale", "label", "patch", "rollout", "scale", "set"}
+
+// selectingFlags select the objects by other means than their name, in which
+// case there is nothing to look up
+var selectingFlags = []string{"-l", "--selector", "-f", "--filename", "-k", "--kustomize", "--all", "--field-selector"}
+
+// existenceArgs returns the args of a get listing the objects kubectl args
+// change, e.g. "get deployment/web -n shop -o name", or nil if the command
+// doesn't change objects given by name
+func existenceArgs(args []string) []string {
+	args = policy.VerbFirst(args)
+	if len(args) == 0 || !slices.Contains(existenceVerbs, args[0]) {
+		return nil
+	}
+	var namespace, positional []string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		name, _, hasValue := strings.Cut(arg, "=")
+		switch {
+		case arg == "--":
+			i = len(args)
+		case slices.Contains(selectingFlags, name):
+			return nil
+		case name == "-n" || name == "--namespace":
+			namespace = append(namespace, arg)
+			if !hasValue && i+1 < len(args) {
+				i++
+				namespace = append(namespace, args[i])
+			}
+		case strings.HasPrefix(arg, "-n") && !strings.HasPrefix(arg, "--"):
+			namespace = append(namespace, arg)
+		case strings.HasPrefix(arg, "-"):
+			if policy.TakesValue(args[0], name) && !hasValue {
+				i++
+			}
+		case hasValue, strings.HasSuffix(arg, "-"):
+			// Changes: label/annotate key=value or key-, set image container=image
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	// rollout and set take a subcommand before the objects
+	if (args[0] == "rollout" || args[0] == "set") && len(positional) > 0 {
+		positional = positional[1:]
+	}
+	if len(positional) == 0 || (len(positional) == 1 && !strings.Contains(positional[0], "/")) {
+		return nil
+	}
+	return slices.Concat([]string{"get"}, positional, namespace, []string{"-o", "name"})
+}
+
+// checkExists looks up the objects changed by args in each context before
+// running and reports the contexts where any of them does not exist. With
+// ExistsSkip those contexts are left out of the returned list. Contexts where
+// the lookup fails for another reason, e.g. unreachable ones, are kept so
+// that kubectl reports the problem. Raw commands (each) are not checked.
+func (r *Runner) checkExists(ctx context.Context, exec *executor.Executor, contexts []string, args []string) []string {
+	if exec.RawCommand() {
+		return contexts
+	}
+	getArgs := existenceArgs(args)
+	if getArgs == nil {
+		return contexts
+	}
+
+	var kept, missing []string
+	for _, res := range exec.Probe(ctx, contexts, getArgs) {
+		if res.Error != nil && strings.Contains(res.Error.Error(), "NotFound") {
+			missing = append(missing, fmt.Sprintf("%s (%s)", res.Context, notFound(res.Error.Error())))
+			continue
+		}
+		kept = append(kept, res.Context)
+	}
+	if len(missing) == 0 {
+		return contexts
+	}
+
+	if r.opts.ExistsCheck == ExistsSkip {
+		fmt.Fprintf(r.stderr, "Skipping contexts without the target objects: %s\n", strings.Join(missing, ", "))
+		return kept
+	}
+	fmt.Fprintf(r.stderr, "Warning: the target objects do not exist in: %s\n", strings.Join(missing, ", "))
+	return contexts
+}
+
+// notFound returns the objects kubectl reported as not found, e.g.
+// `deployments.apps "web" not found`
+func notFound(stderr string) string {
+	var objects []string
+	for _, line := range strings.Split(stderr, "\n") {
+		if _, object, ok := strings.Cut(line, "(NotFound): "); ok {
+			objects = append(objects, strings.TrimSpace(object))
+		}
+	}
+	if len(objects) == 0 {
+		return firstLine(stderr)
+	}
+	return strings.Join(objects, "; ")
+}
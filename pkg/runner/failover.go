@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+)
+
+// resolveFailover pairs each target context with the secondary configured
+// for it and leaves the secondaries of targeted primaries out of the
+// targets: they only run when their primary can't be reached
+func (r *Runner) resolveFailover() {
+	r.secondaries = make(map[string]string)
+	for _, ctx := range r.contexts {
+		secondary := r.cfg.SettingsFor(ctx).Secondary
+		if secondary == "" {
+			continue
+		}
+		if len(r.mgr.FilterContexts([]string{secondary})) == 0 {
+			fmt.Fprintf(r.stderr, "Warning: secondary '%s' of context '%s' is not in the kubeconfig\n", secondary, ctx)
+			continue
+		}
+		r.secondaries[ctx] = secondary
+	}
+	standby := r.standby()
+	r.contexts = slices.DeleteFunc(r.contexts, func(ctx string) bool {
+		return slices.Contains(standby, ctx)
+	})
+}
+
+// standby returns the secondary contexts that take over for unreachable primaries
+func (r *Runner) standby() []string {
+	var contexts []string
+	for _, secondary := range r.secondaries {
+		if !slices.Contains(contexts, secondary) {
+			contexts = append(contexts, secondary)
+		}
+	}
+	slices.Sort(contexts)
+	return contexts
+}
+
+// guarded returns the contexts verb restrictions and the policy are checked
+// against: the targets and the secondaries that may run in their place
+func (r *Runner) guarded() []string {
+	return slices.Concat(r.contexts, r.standby())
+}
+
+// failover reruns args on the secondary of every primary in results that
+// could not be reached, replacing the primary's result with the secondary's.
+// Returns the results of the secondaries' runs.
+func (r *Runner) failover(ctx context.Context, exec *executor.Executor, results Results, args []string) (Results, error) {
+	var secondaries []string
+	for _, res := range results {
+		if secondary := r.secondaries[res.Context]; secondary != "" && res.Unreachable() && !slices.Contains(secondaries, secondary) {
+			fmt.Fprintf(r.stderr, "Context '%s' failed (%s), failing over to '%s'\n", res.Context, firstLine(res.Error.Error()), secondary)
+			secondaries = append(secondaries, secondary)
+		}
+	}
+	if len(secondaries) == 0 {
+		return nil, nil
+	}
+
+	if r.opts.ReplayDir == "" {
+		cleanup, err := r.prepare(exec, secondaries)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+	}
+	// Secondaries get the same checks as the targets they stand in for
+	if r.opts.NamespaceCheck != "" && r.opts.ReplayDir == "" {
+		secondaries = r.checkNamespace(ctx, exec, secondaries, args)
+	}
+	if r.opts.ExistsCheck != "" && r.opts.ReplayDir == "" {
+		secondaries = r.checkExists(ctx, exec, secondaries, args)
+	}
+	if len(secondaries) == 0 {
+		return nil, nil
+	}
+	ran := Results(exec.ExecuteContext(ctx, secondaries, args))
+	for i, res := range results {
+		secondary := r.secondaries[res.Context]
+		if j := slices.Index(secondaries, secondary); j >= 0 && res.Unreachable() {
+			failover := ran[j]
+			failover.FailoverFor = res.Context
+			results[i] = failover
+		}
+	}
+	return ran, nil
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/executor"
+)
+
+// checkGroupPolicies reports a group policy for an unknown group or with an
+// invalid mode
+func checkGroupPolicies(cfg *config.MultiKubeConfig) error {
+	for name, p := range cfg.GroupPolicies {
+		if _, ok := cfg.Groups[name]; !ok {
+			return fmt.Errorf("group policy for unknown group %q", name)
+		}
+		switch p.Mode {
+		case "", config.GroupParallel, config.GroupSerial:
+		default:
+			return fmt.Errorf("invalid mode %q for group %q (must be %s or %s)", p.Mode, name, config.GroupParallel, config.GroupSerial)
+		}
+		if p.MaxParallel < 0 {
+			return fmt.Errorf("invalid maxParallel %d for group %q", p.MaxParallel, name)
+		}
+	}
+	return nil
+}
+
+// lanes puts each context whose groups have a policy limiting them into the
+// lane of the most restrictive of those groups
+func (r *Runner) lanes() []executor.Lane {
+	var lanes []executor.Lane
+	for _, ctx := range slices.Concat(r.contexts, r.standby()) {
+		group, limit := "", 0
+		for _, g := range r.cfg.GroupsOf(ctx) {
+			p := r.cfg.GroupPolicies[g]
+			if p == nil || p.Limit() == 0 {
+				continue
+			}
+			if group == "" || p.Limit() < limit {
+				group, limit = g, p.Limit()
+			}
+		}
+		if group == "" {
+			continue
+		}
+		i := slices.IndexFunc(lanes, func(l executor.Lane) bool { return l.Name == group })
+		if i < 0 {
+			lanes = append(lanes, executor.Lane{Name: group, Limit: limit})
+			i = len(lanes) - 1
+		}
+		lanes[i].Contexts = append(lanes[i].Contexts, ctx)
+	}
+	return lanes
+}
@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/policy"
+)
+
+const (
+	// NamespaceWarn warns about contexts missing the -n namespace but still runs there
+	NamespaceWarn = "warn"
+	// NamespaceSkip leaves contexts missing the -n namespace out of the run
+	NamespaceSkip = "skip"
+)
+
+// checkNamespace looks up the namespace given with -n in args in each
+// context and warns about the contexts where it does not exist. With
+// NamespaceSkip those contexts are left out of the returned list. Contexts
+// where the lookup fails for another reason, e.g. unreachable ones, are kept
+// so that kubectl reports the problem. Raw commands (each) are not checked,
+// since their -n is not kubectl's.
+func (r *Runner) checkNamespace(ctx context.Context, exec *executor.Executor, contexts []string, args []string) []string {
+	if exec.RawCommand() {
+		return contexts
+	}
+	_, resource, namespace := policy.ParseArgs(args)
+	if namespace == "" || namespace == policy.AllNamespaces || resource == "namespace" {
+		return contexts
+	}
+
+	var kept, missing []string
+	for _, res := range exec.Probe(ctx, contexts, []string{"get", "namespace", namespace, "-o", "name"}) {
+		if res.Error != nil && strings.Contains(res.Error.Error(), "NotFound") {
+			missing = append(missing, res.Context)
+			continue
+		}
+		kept = append(kept, res.Context)
+	}
+	if len(missing) == 0 {
+		return contexts
+	}
+
+	if r.opts.NamespaceCheck == NamespaceSkip {
+		fmt.Fprintf(r.stderr, "Skipping contexts without namespace '%s': %s\n", namespace, strings.Join(missing, ", "))
+		return kept
+	}
+	fmt.Fprintf(r.stderr, "Warning: namespace '%s' does not exist in: %s\n", namespace, strings.Join(missing, ", "))
+	return contexts
+}
@@ -0,0 +1,26 @@
+package runner
+
+import (
+	"github.com/multikubectl/pkg/executor"
+)
+
+// Plan resolves the command each of contexts would run for args with exec,
+// without running anything. The kubeconfig files are passed as for a run, but
+// discovered clusters are not logged into, SSH tunnels are not opened and the
+// namespace and existence checks are not made.
+func (r *Runner) Plan(exec *executor.Executor, contexts []string, args []string) []executor.Planned {
+	if r.opts.ReplayDir == "" {
+		if ephemeral := r.mgr.EphemeralContexts(); len(ephemeral) > 0 {
+			exec.SetKubeConfigFor(ephemeral, "<ephemeral kubeconfig>")
+		}
+		for _, ctx := range contexts {
+			if path := r.mgr.KubeConfigFor(ctx); path != "" {
+				exec.SetKubeConfigFor([]string{ctx}, path)
+			}
+			if p, ok := r.providers[r.mgr.DiscoveredBy(ctx)]; ok {
+				exec.SetKubeConfigFor([]string{ctx}, p.KubeConfig())
+			}
+		}
+	}
+	return exec.Plan(contexts, args)
+}
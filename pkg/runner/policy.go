@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/policy"
+)
+
+// CheckVerbRestrictions returns an error if verb is restricted in the config and
+// any target context, or secondary that may fail over for one, is outside the
+// allowed contexts, unless ForceTargets is set
+func (r *Runner) CheckVerbRestrictions(verb string) error {
+	allowed, restricted := r.cfg.VerbRestrictions[verb]
+	if !restricted || r.opts.ForceTargets {
+		return nil
+	}
+
+	allowedContexts, err := r.cfg.ExpandContexts(allowed)
+	if err != nil {
+		return err
+	}
+	var denied []string
+	for _, ctx := range r.guarded() {
+		if !slices.Contains(allowedContexts, ctx) {
+			denied = append(denied, ctx)
+		}
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("'%s' is restricted to %s by the config; not allowed on: %s (use --force-targets to override)",
+			verb, strings.Join(allowed, ", "), strings.Join(denied, ", "))
+	}
+	return nil
+}
+
+// PolicyPath returns the policy file from the config, or the default location
+func PolicyPath(cfg *config.MultiKubeConfig) string {
+	if cfg.PolicyFile != "" {
+		return cfg.PolicyFile
+	}
+	return filepath.Join(config.GetConfigDir(), "policy.yaml")
+}
+
+// CheckPolicy evaluates the policy file against the kubectl command for every
+// target context and every secondary that may fail over for one. It returns
+// an error if any context is denied, and asks Options.Confirm once per rule
+// that requires confirmation.
+func (r *Runner) CheckPolicy(args []string) error {
+	verb, resource, namespace := policy.ParseArgs(args)
+	if resource == "" && policy.FromFiles(args) {
+		resource = policy.UnknownResource
+	}
+	return r.checkPolicy(verb, resource, namespace, args)
+}
+
+// CheckCommandPolicy is like CheckPolicy for an arbitrary command run under
+// verb, such as each. Its objects and namespaces can't be told, so deny and
+// confirm rules naming resources or namespaces apply to it.
+func (r *Runner) CheckCommandPolicy(verb string, command []string) error {
+	return r.checkPolicy(verb, policy.UnknownResource, policy.AllNamespaces, append([]string{verb}, command...))
+}
+
+func (r *Runner) checkPolicy(verb, resource, namespace string, args []string) error {
+	p, err := policy.Load(PolicyPath(r.cfg))
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+
+	denied := make(map[*policy.Rule][]string)
+	confirm := make(map[*policy.Rule][]string)
+	for _, ctx := range r.guarded() {
+		req := policy.Request{
+			Verb:      verb,
+			Resource:  resource,
+			Namespace: namespace,
+			Cluster:   ctx,
+			Labels:    r.cfg.SettingsFor(ctx).Labels,
+		}
+		if req.Namespace == "" {
+			req.Namespace = r.defaultNamespace(ctx)
+		}
+
+		rule := p.Evaluate(req)
+		if rule == nil {
+			continue
+		}
+		switch rule.Action {
+		case policy.Deny:
+			denied[rule] = append(denied[rule], ctx)
+		case policy.Confirm:
+			confirm[rule] = append(confirm[rule], ctx)
+		}
+	}
+
+	command := strings.Join(args, " ")
+	if len(denied) > 0 {
+		var errs []error
+		for _, rule := range sortedRules(denied) {
+			msg := fmt.Sprintf("policy rule '%s' denies '%s' on: %s", rule.Name, command, strings.Join(denied[rule], ", "))
+			if rule.Message != "" {
+				msg += "\n  " + rule.Message
+			}
+			errs = append(errs, errors.New(msg))
+		}
+		return errors.Join(errs...)
+	}
+
+	for _, rule := range sortedRules(confirm) {
+		message := fmt.Sprintf("Policy rule '%s' requires confirmation to run '%s' on: %s.", rule.Name, command, strings.Join(confirm[rule], ", "))
+		if rule.Message != "" {
+			message += " " + rule.Message
+		}
+		if r.opts.Confirm == nil || !r.opts.Confirm(message) {
+			return fmt.Errorf("policy rule '%s' requires confirmation to run '%s' on: %s, which was not given",
+				rule.Name, command, strings.Join(confirm[rule], ", "))
+		}
+	}
+	return nil
+}
+
+// defaultNamespace returns the namespace kubectl uses for a context when none is given
+func (r *Runner) defaultNamespace(ctx string) string {
+	if c, ok := r.mgr.Context(ctx); ok && c.Namespace != "" {
+		return c.Namespace
+	}
+	return "default"
+}
+
+// sortedRules returns the rules of m in a stable order
+func sortedRules(m map[*policy.Rule][]string) []*policy.Rule {
+	rules := make([]*policy.Rule, 0, len(m))
+	for rule := range m {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
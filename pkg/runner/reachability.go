@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/reachability"
+)
+
+// Reachability returns the last known reachability of each of contexts, from
+// the reachability cache where it is recent and otherwise by probing the
+// context with exec. Discovered contexts are not probed, as that could mean
+// logging in to them; they are missing from the result unless cached.
+func (r *Runner) Reachability(ctx context.Context, exec *executor.Executor, contexts []string) map[string]reachability.Entry {
+	entries := make(map[string]reachability.Entry)
+	if r.reach == nil {
+		return entries
+	}
+
+	var probe []string
+	for _, name := range r.reach.Stale(contexts) {
+		if r.mgr.DiscoveredBy(name) == "" {
+			probe = append(probe, name)
+		}
+	}
+	if len(probe) > 0 {
+		cleanup, err := r.prepare(exec, probe)
+		if err != nil {
+			fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+			return entries
+		}
+		r.reach.Record(exec.Probe(ctx, probe, reachability.ProbeArgs), true)
+		cleanup()
+		if err := r.reach.Save(); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+		}
+	}
+
+	for _, name := range contexts {
+		if entry, ok := r.reach.Get(name); ok {
+			entries[name] = entry
+		}
+	}
+	return entries
+}
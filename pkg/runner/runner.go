@@ -0,0 +1,714 @@
+// Package runner runs kubectl commands across many clusters. It is the engine
+// behind the multikubectl CLI and can be embedded by other Go programs that
+// need structured per-cluster results instead of merged text output.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/multikubectl/pkg/audit"
+	"github.com/multikubectl/pkg/breaker"
+	"github.com/multikubectl/pkg/cluster"
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/discovery"
+	"github.com/multikubectl/pkg/executor"
+	"github.com/multikubectl/pkg/history"
+	"github.com/multikubectl/pkg/output"
+	"github.com/multikubectl/pkg/policy"
+	"github.com/multikubectl/pkg/reachability"
+	"github.com/multikubectl/pkg/tunnel"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultTimeout is the per-context kubectl timeout used when Options.Timeout is not set
+const DefaultTimeout = 30 * time.Second
+
+// CurrentContext stands for the kubeconfig's current-context in Options.Contexts
+const CurrentContext = "."
+
+// Options configures which clusters are targeted and how kubectl runs against them.
+// The zero value targets the contexts saved in ~/.multikube/config, or every
+// kubeconfig context if none are saved.
+type Options struct {
+	// Args are the kubectl arguments, e.g. ["get", "pods", "-A"] (used by Run)
+	Args []string
+	// KubeConfig is the kubeconfig path; empty uses $KUBECONFIG or ~/.kube/config
+	KubeConfig string
+	// Config is the multikube configuration; nil loads ~/.multikube/config
+	Config *config.MultiKubeConfig
+
+	// Contexts lists the contexts or groups to target, overriding the config.
+	// CurrentContext stands for the kubeconfig's current-context.
+	Contexts []string
+	// AllContexts targets every kubeconfig context, ignoring the config
+	AllContexts bool
+	// ContextSelector narrows the targets to contexts whose labels match, e.g. "env=prod"
+	ContextSelector string
+	// Clusters are targeted in addition to the kubeconfig contexts
+	Clusters []cluster.Ephemeral
+
+	// ForceTargets ignores the verb restrictions in the config
+	ForceTargets bool
+	// Confirm is asked once per policy rule requiring confirmation and returns
+	// whether to proceed. Without it such commands are refused.
+	Confirm func(message string) bool
+
+	// Timeout bounds each kubectl invocation (default DefaultTimeout)
+	Timeout time.Duration
+	// Serial runs contexts one at a time, waiting SerialDelay in between
+	Serial      bool
+	SerialDelay time.Duration
+	// QPS and Burst override the rate limit from the config
+	QPS   float64
+	Burst int
+	// As and AsGroups impersonate a user in contexts without their own impersonation settings
+	As       string
+	AsGroups []string
+
+	// BreakerThreshold is the number of consecutive failures before a context
+	// is skipped (0 disables the circuit breaker)
+	BreakerThreshold int
+	// IncludeFailing targets contexts skipped by the circuit breaker anyway
+	IncludeFailing bool
+	// HideWarnings strips kubectl warnings from stderr
+	HideWarnings bool
+	// Failover runs commands only on primary contexts, and on the secondary
+	// configured for a primary (ContextSettings.Secondary) when the primary
+	// can't be reached
+	Failover bool
+	// NamespaceCheck checks that the -n namespace exists in each context
+	// before running: NamespaceWarn or NamespaceSkip (default: the config's
+	// checkNamespace, or no check)
+	NamespaceCheck string
+	// ExistsCheck checks that the objects named by commands changing them
+	// (patch, scale, label, ...) exist in each context before running:
+	// ExistsWarn or ExistsSkip (default: the config's checkExists, or no check)
+	ExistsCheck string
+
+	// RecordDir saves every result as a fixture
+	RecordDir string
+	// ReplayDir serves results from fixtures instead of running kubectl
+	ReplayDir string
+
+	// Stderr receives notices and warnings, such as skipped contexts (default: discarded)
+	Stderr io.Writer
+}
+
+// Results holds the result of each target context, in target order
+type Results []executor.Result
+
+// Failed returns the results of contexts where kubectl failed
+func (r Results) Failed() Results {
+	var failed Results
+	for _, res := range r {
+		if res.Error != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Runner holds the resolved target contexts and runs commands against them
+type Runner struct {
+	opts     Options
+	mgr      *cluster.Manager
+	cfg      *config.MultiKubeConfig
+	contexts []string
+	breaker  *breaker.Breaker
+	reach    *reachability.Cache
+	// providers holds the discovery providers by name
+	providers map[string]discovery.Provider
+	stderr    io.Writer
+	// prepared is the executor set up by Prepare, which later runs reuse
+	prepared *executor.Executor
+	// secondaries maps primary contexts to their secondary with Options.Failover
+	secondaries map[string]string
+	// noHistory leaves runs out of the replay history, see DisableHistory
+	noHistory bool
+}
+
+// Run resolves the target contexts, checks the verb restrictions and policy,
+// and runs kubectl with opts.Args against every context
+func Run(ctx context.Context, opts Options) (Results, error) {
+	if len(opts.Args) == 0 {
+		return nil, errors.New("no kubectl arguments given")
+	}
+
+	r, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	verb, _, _ := policy.ParseArgs(opts.Args)
+	if err := r.CheckVerbRestrictions(verb); err != nil {
+		return nil, err
+	}
+	if err := r.CheckPolicy(opts.Args); err != nil {
+		return nil, err
+	}
+	return r.Execute(ctx, r.NewExecutor(), opts.Args)
+}
+
+// New resolves the target contexts like Resolve and then skips contexts held
+// open by the circuit breaker
+func New(opts Options) (*Runner, error) {
+	r, err := Resolve(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ReplayDir != "" {
+		// Fixtures never touch a cluster, so there is nothing to skip
+		return r, nil
+	}
+
+	brk, err := breaker.Load(opts.BreakerThreshold, breaker.DefaultCooldown)
+	if err != nil {
+		fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+		return r, nil
+	}
+	r.breaker = brk
+	if r.reach != nil {
+		brk.SetReachability(r.reach)
+	}
+	if opts.IncludeFailing {
+		return r, nil
+	}
+
+	targets, skipped := brk.Filter(r.contexts)
+	for _, ctx := range skipped {
+		fmt.Fprintf(r.stderr, "Skipping context '%s': failed %d times in a row (use --include-failing to force)\n", ctx, brk.Failures(ctx))
+		// Its secondary takes over right away
+		if secondary := r.secondaries[ctx]; secondary != "" && !slices.Contains(targets, secondary) {
+			fmt.Fprintf(r.stderr, "Failing over from '%s' to '%s'\n", ctx, secondary)
+			targets = append(targets, secondary)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("all target contexts were skipped by the circuit breaker")
+	}
+	r.contexts = targets
+	return r, nil
+}
+
+// Resolve loads the kubeconfig and multikube config and resolves the target
+// contexts from the options and config, without consulting the circuit breaker
+func Resolve(opts Options) (*Runner, error) {
+	if opts.ReplayDir != "" && opts.RecordDir != "" {
+		return nil, errors.New("--record-fixtures and --replay cannot be used together")
+	}
+
+	mgr, err := cluster.NewManager(opts.KubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.AddEphemeral(opts.Clusters); err != nil {
+		return nil, err
+	}
+
+	cfg := opts.Config
+	if cfg == nil {
+		if cfg, err = config.Load(); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.NamespaceCheck == "" {
+		opts.NamespaceCheck = cfg.CheckNamespace
+	}
+	switch opts.NamespaceCheck {
+	case "", NamespaceWarn, NamespaceSkip:
+	default:
+		return nil, fmt.Errorf("invalid namespace check %q (must be %s or %s)", opts.NamespaceCheck, NamespaceWarn, NamespaceSkip)
+	}
+	if opts.ExistsCheck == "" {
+		opts.ExistsCheck = cfg.CheckExists
+	}
+	switch opts.ExistsCheck {
+	case "", ExistsWarn, ExistsSkip:
+	default:
+		return nil, fmt.Errorf("invalid existence check %q (must be %s or %s)", opts.ExistsCheck, ExistsWarn, ExistsSkip)
+	}
+	if err := checkGroupPolicies(cfg); err != nil {
+		return nil, err
+	}
+	if err := checkTimeouts(cfg); err != nil {
+		return nil, err
+	}
+
+	r := &Runner{
+		opts:   opts,
+		mgr:    mgr,
+		cfg:    cfg,
+		stderr: opts.Stderr,
+	}
+	if r.stderr == nil {
+		r.stderr = io.Discard
+	}
+
+	if opts.ReplayDir != "" {
+		r.contexts, err = r.replayContexts()
+	} else {
+		// Add contexts from extra kubeconfigs and discovery providers (e.g. Teleport)
+		r.providers = AddExtraContexts(mgr, cfg, r.stderr)
+		r.contexts, err = r.targetContexts()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.ReplayDir == "" {
+		if r.reach, err = reachability.Load(reachability.DefaultTTL); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+		}
+	}
+	if opts.Failover {
+		r.resolveFailover()
+	}
+	// Higher priorities first, the rest in the order they were given
+	slices.SortStableFunc(r.contexts, func(a, b string) int {
+		return r.cfg.SettingsFor(b).Priority - r.cfg.SettingsFor(a).Priority
+	})
+	return r, nil
+}
+
+// targetContexts determines which contexts to use.
+// Priority: 1. Contexts  2. AllContexts  3. ~/.multikube/config  4. all contexts
+func (r *Runner) targetContexts() ([]string, error) {
+	var targets []string
+	switch {
+	case len(r.opts.Contexts) > 0:
+		expanded, err := r.requestedContexts()
+		if err != nil {
+			return nil, err
+		}
+		targets = r.mgr.FilterContexts(expanded)
+	case r.opts.AllContexts || r.opts.ContextSelector != "":
+		// A selector picks from all contexts
+		targets = r.mgr.GetContexts()
+	case len(r.cfg.Contexts) > 0:
+		expanded, err := r.cfg.ExpandContexts(r.cfg.Contexts)
+		if err != nil {
+			return nil, err
+		}
+		targets = r.mgr.FilterContexts(expanded)
+	default:
+		targets = r.mgr.GetContexts()
+	}
+
+	// Narrow down to contexts whose labels match the selector
+	if r.opts.ContextSelector != "" {
+		selector, err := labels.Parse(r.opts.ContextSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --context-selector: %w", err)
+		}
+		var matched []string
+		for _, ctx := range targets {
+			if selector.Matches(labels.Set(r.cfg.SettingsFor(ctx).Labels)) {
+				matched = append(matched, ctx)
+			}
+		}
+		targets = matched
+	}
+
+	// Ephemeral clusters are always targeted
+	for _, ctx := range r.mgr.EphemeralContexts() {
+		if !slices.Contains(targets, ctx) {
+			targets = append(targets, ctx)
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, errors.New("no valid contexts found")
+	}
+	return targets, nil
+}
+
+// requestedContexts expands the groups in Contexts, and "." to the
+// kubeconfig's current-context
+func (r *Runner) requestedContexts() ([]string, error) {
+	names := slices.Clone(r.opts.Contexts)
+	for i, name := range names {
+		if name != CurrentContext {
+			continue
+		}
+		if names[i] = r.mgr.GetCurrentContext(); names[i] == "" {
+			return nil, errors.New("the kubeconfig has no current-context")
+		}
+	}
+	return r.cfg.ExpandContexts(names)
+}
+
+// replayContexts returns the contexts recorded in the replay directory,
+// narrowed down by Contexts
+func (r *Runner) replayContexts() ([]string, error) {
+	recorded, err := executor.FixtureContexts(r.opts.ReplayDir)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := recorded
+	if len(r.opts.Contexts) > 0 {
+		expanded, err := r.requestedContexts()
+		if err != nil {
+			return nil, err
+		}
+		targets = nil
+		for _, ctx := range expanded {
+			if slices.Contains(recorded, ctx) {
+				targets = append(targets, ctx)
+			}
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no recorded contexts found in %s", r.opts.ReplayDir)
+	}
+	return targets, nil
+}
+
+// Contexts returns the target contexts
+func (r *Runner) Contexts() []string {
+	return r.contexts
+}
+
+// Manager returns the cluster manager holding the merged kubeconfig
+func (r *Runner) Manager() *cluster.Manager {
+	return r.mgr
+}
+
+// Config returns the multikube configuration
+func (r *Runner) Config() *config.MultiKubeConfig {
+	return r.cfg
+}
+
+// Source describes where a context came from: "ephemeral", a discovery
+// provider, the path of an extra kubeconfig, or "kubeconfig"
+func (r *Runner) Source(ctx string) string {
+	switch {
+	case slices.Contains(r.mgr.EphemeralContexts(), ctx):
+		return "ephemeral"
+	case r.mgr.DiscoveredBy(ctx) != "":
+		return r.mgr.DiscoveredBy(ctx)
+	case r.mgr.KubeConfigFor(ctx) != "":
+		return r.mgr.KubeConfigFor(ctx)
+	default:
+		return "kubeconfig"
+	}
+}
+
+// NewExecutor creates an executor configured from the options and config
+func (r *Runner) NewExecutor() *executor.Executor {
+	timeout := r.opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	exec := executor.NewExecutor(r.mgr.GetKubeConfigPath(), timeout)
+	if limiter := r.rateLimiter(); limiter != nil {
+		exec.SetRateLimiter(limiter)
+	}
+	if r.opts.Serial || r.opts.SerialDelay > 0 {
+		exec.SetSerial(true, r.opts.SerialDelay)
+	}
+	exec.SetLanes(r.lanes())
+	if r.opts.RecordDir != "" {
+		exec.SetRecordDir(r.opts.RecordDir)
+	}
+	if r.opts.ReplayDir != "" {
+		exec.SetReplayDir(r.opts.ReplayDir)
+		return exec
+	}
+	for _, ctx := range slices.Concat(r.contexts, r.standby()) {
+		settings := r.cfg.SettingsFor(ctx)
+		if env := proxyEnv(settings.Proxy, r.mgr.GetProxyURL(ctx)); env != nil {
+			exec.SetEnv(ctx, env)
+		}
+		if settings.Impersonate != nil {
+			exec.SetImpersonation(ctx, executor.Impersonation{User: settings.Impersonate.User, Groups: settings.Impersonate.Groups})
+		} else if r.opts.As != "" || len(r.opts.AsGroups) > 0 {
+			exec.SetImpersonation(ctx, executor.Impersonation{User: r.opts.As, Groups: r.opts.AsGroups})
+		}
+	}
+	return exec
+}
+
+// rateLimiter creates the per-context rate limiter from config and options,
+// counting the requests of every invocation. Returns nil if no limits are
+// configured.
+func (r *Runner) rateLimiter() *executor.RateLimiter {
+	var defaults executor.RateLimit
+	if r.cfg.RateLimit != nil {
+		defaults = executor.RateLimit{QPS: r.cfg.RateLimit.QPS, Burst: r.cfg.RateLimit.Burst}
+	}
+	if r.opts.QPS > 0 {
+		defaults.QPS = r.opts.QPS
+	}
+	if r.opts.Burst > 0 {
+		defaults.Burst = r.opts.Burst
+	}
+
+	overrides := make(map[string]executor.RateLimit)
+	for name, settings := range r.cfg.ContextSettings {
+		if settings != nil && settings.RateLimit != nil {
+			overrides[name] = executor.RateLimit{QPS: settings.RateLimit.QPS, Burst: settings.RateLimit.Burst}
+		}
+	}
+
+	if defaults.QPS <= 0 && len(overrides) == 0 {
+		return nil
+	}
+	limiter := executor.NewRateLimiter(defaults, overrides)
+	limiter.SetStateFile(filepath.Join(config.GetConfigDir(), executor.DefaultRateLimitFile))
+	return limiter
+}
+
+// proxyEnv returns the proxy environment for a context's kubectl process, or nil.
+// kubectl already uses a proxy-url from the kubeconfig, so that wins over the config.
+func proxyEnv(proxy *config.Proxy, kubeconfigProxy string) []string {
+	if proxy == nil || proxy.URL == "" || kubeconfigProxy != "" {
+		return nil
+	}
+	env := []string{"HTTPS_PROXY=" + proxy.URL, "HTTP_PROXY=" + proxy.URL}
+	if len(proxy.NoProxy) > 0 {
+		env = append(env, "NO_PROXY="+strings.Join(proxy.NoProxy, ","))
+	}
+	return env
+}
+
+// Execute runs kubectl args against every target context with exec, then
+// updates the circuit breaker, audit log and history
+func (r *Runner) Execute(ctx context.Context, exec *executor.Executor, args []string) (Results, error) {
+	return r.ExecuteOn(ctx, exec, r.contexts, args)
+}
+
+// ExecuteOn is like Execute, but runs against a subset of the target contexts
+func (r *Runner) ExecuteOn(ctx context.Context, exec *executor.Executor, contexts []string, args []string) (Results, error) {
+	for _, name := range contexts {
+		if !slices.Contains(r.contexts, name) {
+			return nil, fmt.Errorf("context %q is not a target context", name)
+		}
+	}
+
+	if r.opts.ReplayDir == "" && exec != r.prepared {
+		cleanup, err := r.prepare(exec, contexts)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+	}
+	if r.opts.NamespaceCheck != "" && r.opts.ReplayDir == "" {
+		if contexts = r.checkNamespace(ctx, exec, contexts, args); len(contexts) == 0 {
+			return nil, errors.New("the namespace does not exist in any target context")
+		}
+	}
+	if r.opts.ExistsCheck != "" && r.opts.ReplayDir == "" {
+		if contexts = r.checkExists(ctx, exec, contexts, args); len(contexts) == 0 {
+			return nil, errors.New("the target objects do not exist in any target context")
+		}
+	}
+
+	results := Results(exec.ExecuteContext(ctx, contexts, args))
+	// ran holds every kubectl run, including the failovers replacing results
+	ran := slices.Clone(results)
+	if len(r.secondaries) > 0 {
+		failovers, err := r.failover(ctx, exec, results, args)
+		if err != nil {
+			return nil, err
+		}
+		ran = append(ran, failovers...)
+	}
+
+	if r.breaker != nil {
+		r.breaker.Record(ran)
+		if err := r.breaker.Save(); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+		}
+	}
+	if r.reach != nil {
+		r.reach.Record(ran, false)
+		if err := r.reach.Save(); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if r.opts.ReplayDir == "" {
+		var ranOn []string
+		for _, res := range ran {
+			ranOn = append(ranOn, res.Context)
+		}
+		r.record(args, ranOn, ran)
+	}
+
+	if r.opts.HideWarnings {
+		output.StripWarnings(results)
+	}
+	return results, nil
+}
+
+// ExecuteInteractive runs kubectl args against one target context connected to
+// the terminal, such as for attach, and records the run in the audit log
+func (r *Runner) ExecuteInteractive(ctx context.Context, exec *executor.Executor, name string, args []string) (executor.Result, error) {
+	if !slices.Contains(r.contexts, name) {
+		return executor.Result{}, fmt.Errorf("context %q is not a target context", name)
+	}
+	if r.opts.ReplayDir != "" {
+		return executor.Result{}, errors.New("interactive commands can't be replayed from fixtures")
+	}
+
+	if exec != r.prepared {
+		cleanup, err := r.prepare(exec, []string{name})
+		if err != nil {
+			return executor.Result{}, err
+		}
+		defer cleanup()
+	}
+
+	result := exec.ExecuteInteractive(ctx, name, args)
+	r.record(args, []string{name}, Results{result})
+	return result, nil
+}
+
+// record writes the run to the audit log and the replay history
+func (r *Runner) record(args []string, contexts []string, results Results) {
+	entry := audit.NewEntry(args, contexts, results)
+	if logger := audit.New(r.cfg.Audit); logger != nil {
+		if err := logger.Write(entry); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if h := r.cfg.History; (h == nil || !h.Disabled) && !r.noHistory {
+		limit := 0
+		if h != nil {
+			limit = h.Limit
+		}
+		// The history keeps the args as redacted for the audit log, and
+		// secrets only as hashes
+		rec := history.NewRecord(entry.ID, entry.Args, contexts, results)
+		if audit.ReadsSecrets(args) {
+			rec.HashOutputs()
+		}
+		if err := history.Save(rec, limit); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
+// DisableHistory leaves the following runs out of the replay history, for
+// internal commands whose output is not meant to be kept. They are still
+// written to the audit log.
+func (r *Runner) DisableHistory() {
+	r.noHistory = true
+}
+
+// Prepare sets up tunnels, logins and kubeconfigs for every target context
+// once, so that runs with exec reuse them instead of setting them up each
+// time, as a long-lived shell does. The returned function tears them down.
+func (r *Runner) Prepare(exec *executor.Executor) (func(), error) {
+	if r.opts.ReplayDir != "" {
+		return func() {}, nil
+	}
+	cleanup, err := r.prepare(exec, r.contexts)
+	if err != nil {
+		return nil, err
+	}
+	r.prepared = exec
+	return func() {
+		r.prepared = nil
+		cleanup()
+	}, nil
+}
+
+// prepare sets up the per-run resources kubectl needs for contexts: the private
+// kubeconfig of ephemeral contexts, logins to discovered clusters and SSH
+// tunnels. The returned function tears them down.
+func (r *Runner) prepare(exec *executor.Executor, contexts []string) (func(), error) {
+	var cleanups []func()
+	cleanup := func() {
+		for _, fn := range cleanups {
+			fn()
+		}
+	}
+
+	// Ephemeral contexts live in a private kubeconfig that only exists while kubectl runs
+	if ephemeral := r.mgr.EphemeralContexts(); len(ephemeral) > 0 {
+		path, err := r.mgr.WriteEphemeralKubeConfig()
+		if err != nil {
+			return nil, err
+		}
+		cleanups = append(cleanups, func() { os.Remove(path) })
+		exec.SetKubeConfigFor(ephemeral, path)
+	}
+
+	// Contexts from extra kubeconfig files need that file passed to kubectl
+	for _, ctx := range contexts {
+		if path := r.mgr.KubeConfigFor(ctx); path != "" {
+			exec.SetKubeConfigFor([]string{ctx}, path)
+		}
+	}
+
+	// Log into discovered clusters on demand; they live in the provider's own kubeconfig
+	for _, ctx := range contexts {
+		p, ok := r.providers[r.mgr.DiscoveredBy(ctx)]
+		if !ok {
+			continue
+		}
+		if err := p.Login(ctx); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+		}
+		exec.SetKubeConfigFor([]string{ctx}, p.KubeConfig())
+	}
+
+	// Open tunnels in parallel since each waits for ssh to connect
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ctx := range contexts {
+		settings := r.cfg.SettingsFor(ctx)
+		if settings.Tunnel == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(ctx string, tc *config.Tunnel) {
+			defer wg.Done()
+			t, err := tunnel.Open(tunnel.Options{
+				Host:         tc.Host,
+				Port:         tc.Port,
+				LocalPort:    tc.LocalPort,
+				IdentityFile: tc.IdentityFile,
+			}, r.mgr.GetServer(ctx))
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				// kubectl then fails against the original server and is reported like any other unreachable cluster
+				fmt.Fprintf(r.stderr, "Warning: failed to open tunnel for context '%s': %v\n", ctx, err)
+				return
+			}
+			cleanups = append(cleanups, t.Close)
+			exec.SetExtraArgs(ctx, t.KubectlArgs())
+			if exec.RawCommand() {
+				// Raw commands don't get the flags, so their kubeconfig points at the tunnel
+				pinned, err := r.mgr.WriteTunnelKubeConfig(ctx, t.Server, t.ServerName)
+				if err != nil {
+					fmt.Fprintf(r.stderr, "Warning: failed to route context '%s' through its tunnel: %v\n", ctx, err)
+					return
+				}
+				cleanups = append(cleanups, func() { os.Remove(pinned) })
+				base := exec.KubeConfigFor(ctx)
+				if base == "" {
+					base = strings.Join(r.mgr.GetKubeConfigPaths(), string(filepath.ListSeparator))
+				}
+				exec.SetKubeConfigFor([]string{ctx}, pinned+string(filepath.ListSeparator)+base)
+			}
+		}(ctx, settings.Tunnel)
+	}
+	wg.Wait()
+
+	return cleanup, nil
+}
@@ -0,0 +1,40 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/multikubectl/pkg/config"
+	"github.com/multikubectl/pkg/policy"
+)
+
+// checkTimeouts reports a per-verb timeout in the config that is not a duration
+func checkTimeouts(cfg *config.MultiKubeConfig) error {
+	for verb, value := range cfg.Timeouts {
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid timeout %q for %q: %w", value, verb, err)
+		}
+	}
+	return nil
+}
+
+// TimeoutFor returns the config's default timeout for kubectl args, looked up
+// by their verb and subcommand first (e.g. "rollout status"), then by verb.
+// 0 means no timeout. Returns false if the config sets none.
+func (r *Runner) TimeoutFor(args []string) (time.Duration, bool) {
+	positional := policy.Positional(args)
+	if len(positional) == 0 {
+		return 0, false
+	}
+	keys := []string{positional[0]}
+	if len(positional) > 1 {
+		keys = []string{positional[0] + " " + positional[1], positional[0]}
+	}
+	for _, key := range keys {
+		if value, ok := r.cfg.Timeouts[key]; ok {
+			timeout, _ := time.ParseDuration(value)
+			return timeout, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,145 @@
+package tmux
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Pane is a tmux pane to open
+type Pane struct {
+	// Title is shown in the pane border
+	Title string
+	// Env are environment variables ("KEY=value") set in the pane
+	Env []string
+	// Command is a shell command run in the pane before the interactive shell
+	// starts; empty starts just the shell
+	Command string
+}
+
+// InSession reports whether we are running inside a tmux session
+func InSession() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// OpenWindow opens a window named name with one tiled pane per entry of panes.
+// Inside a tmux session the window is added to it; otherwise a new session is
+// created and attached, which returns once the user detaches or exits.
+// With sync, keystrokes are sent to every pane at once.
+func OpenWindow(name string, panes []Pane, sync bool) error {
+	if len(panes) == 0 {
+		return fmt.Errorf("no panes to open")
+	}
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return fmt.Errorf("tmux not found in PATH")
+	}
+
+	attach := !InSession()
+	session := ""
+	var args []string
+	if attach {
+		session = sessionName(name)
+		args = []string{"new-session", "-d", "-s", session, "-n", name}
+	} else {
+		args = []string{"new-window", "-n", name}
+	}
+	args = append(args, "-P", "-F", "#{window_id}")
+	window, err := run(append(append(args, paneArgs(panes[0])...), shellCommand(panes[0].Command))...)
+	if err != nil {
+		return err
+	}
+	window = strings.TrimSpace(window)
+	if _, err := run("select-pane", "-t", window, "-T", panes[0].Title); err != nil {
+		return err
+	}
+
+	for _, pane := range panes[1:] {
+		args := append([]string{"split-window", "-t", window, "-P", "-F", "#{pane_id}"}, paneArgs(pane)...)
+		id, err := run(append(args, shellCommand(pane.Command))...)
+		if err != nil {
+			return err
+		}
+		if _, err := run("select-pane", "-t", strings.TrimSpace(id), "-T", pane.Title); err != nil {
+			return err
+		}
+		// Re-tile after each split so there is room for the next one
+		if _, err := run("select-layout", "-t", window, "tiled"); err != nil {
+			return err
+		}
+	}
+
+	options := [][]string{
+		{"pane-border-status", "top"},
+		{"pane-border-format", " #{pane_title} "},
+	}
+	if sync {
+		options = append(options, []string{"synchronize-panes", "on"})
+	}
+	for _, option := range options {
+		if _, err := run("set-window-option", "-t", window, option[0], option[1]); err != nil {
+			return err
+		}
+	}
+	if _, err := run("select-pane", "-t", window+".0"); err != nil {
+		return err
+	}
+
+	if !attach {
+		return nil
+	}
+	cmd := exec.Command("tmux", "attach-session", "-t", session)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// paneArgs returns the tmux flags setting a pane's environment
+func paneArgs(pane Pane) []string {
+	var args []string
+	for _, env := range pane.Env {
+		args = append(args, "-e", env)
+	}
+	return args
+}
+
+// shellCommand returns the command a pane runs: the user's shell, after
+// running command if given
+func shellCommand(command string) string {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	if command == "" {
+		return shell
+	}
+	return fmt.Sprintf("%s -c %s", shell, quote(command+"; exec "+shell))
+}
+
+// sessionName returns a tmux session name for name that is not in use yet
+func sessionName(name string) string {
+	candidate := name
+	for i := 2; ; i++ {
+		if err := exec.Command("tmux", "has-session", "-t", "="+candidate).Run(); err != nil {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+}
+
+// run runs a tmux command and returns its output
+func run(args ...string) (string, error) {
+	cmd := exec.Command("tmux", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tmux %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// quote quotes s for a POSIX shell
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
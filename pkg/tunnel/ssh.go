@@ -0,0 +1,125 @@
+package tunnel
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readyTimeout is how long Open waits for the forwarded port to accept connections
+const readyTimeout = 15 * time.Second
+
+// Options configures an SSH tunnel to a cluster's API server
+type Options struct {
+	// Host is the jump host, e.g. "user@bastion.example.com" or an alias from ~/.ssh/config
+	Host string
+	// Port is the SSH port of the jump host (0 uses ssh's default)
+	Port int
+	// LocalPort is the local end of the tunnel (0 picks a free port)
+	LocalPort int
+	// IdentityFile is an optional private key passed to ssh -i
+	IdentityFile string
+}
+
+// Tunnel is a running "ssh -N -L" port forward
+type Tunnel struct {
+	cmd    *exec.Cmd
+	stderr bytes.Buffer
+	// Server is the API server URL rewritten to go through the tunnel
+	Server string
+	// ServerName is the original API server host, used for TLS verification
+	ServerName string
+}
+
+// Open starts an SSH port forward from a local port to the host and port of
+// server through the jump host, and waits until the local port is ready
+func Open(opts Options, server string) (*Tunnel, error) {
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid server address %q", server)
+	}
+	remotePort := u.Port()
+	if remotePort == "" {
+		remotePort = "443"
+	}
+
+	localPort := opts.LocalPort
+	if localPort == 0 {
+		if localPort, err = freePort(); err != nil {
+			return nil, fmt.Errorf("failed to pick a local port: %w", err)
+		}
+	}
+
+	args := []string{"-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "BatchMode=yes",
+		"-L", fmt.Sprintf("127.0.0.1:%d:%s:%s", localPort, u.Hostname(), remotePort),
+	}
+	if opts.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(opts.Port))
+	}
+	if opts.IdentityFile != "" {
+		args = append(args, "-i", opts.IdentityFile)
+	}
+	args = append(args, opts.Host)
+
+	t := &Tunnel{ServerName: u.Hostname()}
+	t.cmd = exec.Command("ssh", args...)
+	t.cmd.Stderr = &t.stderr
+	if err := t.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ssh: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- t.cmd.Wait() }()
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort))
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		select {
+		case <-exited:
+			return nil, fmt.Errorf("ssh to %s exited: %s", opts.Host, strings.TrimSpace(t.stderr.String()))
+		default:
+		}
+		if conn, err := net.DialTimeout("tcp", addr, 200*time.Millisecond); err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.cmd.Process.Kill()
+			return nil, fmt.Errorf("ssh tunnel via %s not ready after %s", opts.Host, readyTimeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	u.Host = addr
+	t.Server = u.String()
+	return t, nil
+}
+
+// KubectlArgs returns the kubectl flags that route a command through the tunnel
+// while still verifying the certificate against the original server name
+func (t *Tunnel) KubectlArgs() []string {
+	return []string{"--server", t.Server, "--tls-server-name", t.ServerName}
+}
+
+// Close tears down the tunnel
+func (t *Tunnel) Close() {
+	if t.cmd.Process != nil {
+		t.cmd.Process.Kill()
+	}
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}